@@ -0,0 +1,84 @@
+package skiplist
+
+import (
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// foldRune returns the smallest rune that r is equal to under simple
+// case folding, so runes strings.EqualFold treats as equal fold alike
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// foldCompare compares two strings rune by rune after case folding,
+// returning -1, 0 or 1. it is 0 exactly when strings.EqualFold is true
+func foldCompare(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if fa, fb := foldRune(ra), foldRune(rb); fa != fb {
+			if fa < fb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	}
+	return 1
+}
+
+// FoldLess orders string keys ignoring case. keys that differ only
+// in case, "a" and "A", are equal, so they are the same key in a map
+func FoldLess(a, b interface{}) bool {
+	return foldCompare(a.(string), b.(string)) < 0
+}
+
+// CaseInsensitiveLess orders string keys ignoring case, like FoldLess,
+// but breaks ties between keys that differ only in case by comparing
+// bytes, so "A" comes just before "a" and both can be in a map
+func CaseInsensitiveLess(a, b interface{}) bool {
+	sa, sb := a.(string), b.(string)
+	if c := foldCompare(sa, sb); c != 0 {
+		return c < 0
+	}
+	return sa < sb
+}
+
+// NewCollatedStringMap creates a new empty map with string keys in the
+// order used for the language tag, as given by golang.org/x/text/collate.
+// strings the collation finds equal are ordered by their bytes, so
+// distinct strings are always distinct keys
+func NewCollatedStringMap(tag language.Tag, opts ...Option) *Map {
+	// a Collator keeps buffers between calls, so it can't be shared by
+	// readers comparing keys at the same time under the read lock. the
+	// map keeps a pool of its own, so they each take one rather than
+	// waiting on a lock
+	collators := &sync.Pool{New: func() interface{} { return collate.New(tag) }}
+	return NewMap(func(a, b interface{}) bool {
+		sa, sb := a.(string), b.(string)
+		col := collators.Get().(*collate.Collator)
+		c := col.CompareString(sa, sb)
+		collators.Put(col)
+		if c != 0 {
+			return c < 0
+		}
+		return sa < sb
+	}, opts...)
+}
@@ -0,0 +1,109 @@
+package skiplist
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	. "gopkg.in/check.v1"
+)
+
+type CollateSuite struct{}
+
+var _ = Suite(&CollateSuite{})
+
+func (s *CollateSuite) TestFoldCompare(c *C) {
+	words := []string{"", "a", "A", "ab", "aB", "b", "straße", "STRASSE", "k", "K", "Σ", "ς", "σ", "é", "É"}
+	for _, a := range words {
+		for _, b := range words {
+			c.Assert(foldCompare(a, b) == 0, Equals, strings.EqualFold(a, b), Commentf("%q %q", a, b))
+			c.Assert(foldCompare(a, b), Equals, -foldCompare(b, a))
+		}
+	}
+}
+
+func (s *CollateSuite) TestFoldSameKey(c *C) {
+	m := NewMap(FoldLess)
+	c.Assert(m.Put("Apple", 1), Equals, false)
+	c.Assert(m.Put("apple", 2), Equals, true)
+	c.Assert(m.Put("APPLE", 3), Equals, true)
+	m.Put("banana", 4)
+	m.Put("Avocado", 5)
+	c.Assert(m.Len(), Equals, 3)
+	v, ok := m.Get("aPPle")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 3)
+	// the first spelling put is the one kept
+	c.Assert(m.Keys(), DeepEquals, []interface{}{"Apple", "Avocado", "banana"})
+	c.Assert(m.Remove("BANANA"), Equals, true)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *CollateSuite) TestCaseInsensitiveDistinctKeys(c *C) {
+	m := NewMap(CaseInsensitiveLess)
+	for i, k := range []string{"apple", "Banana", "APPLE", "Apple", "banana", "avocado"} {
+		c.Assert(m.Put(k, i), Equals, false)
+	}
+	c.Assert(m.Keys(), DeepEquals, []interface{}{"APPLE", "Apple", "apple", "avocado", "Banana", "banana"})
+	v, ok := m.Get("Apple")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 3)
+	_, ok = m.Get("aPPLE")
+	c.Assert(ok, Equals, false)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *CollateSuite) TestCollatedStringMap(c *C) {
+	words := []string{"zebra", "Äpfel", "apple", "Zoo", "école", "eagle", "Apple"}
+	m := NewCollatedStringMap(language.English)
+	for i, w := range words {
+		m.Put(w, i)
+	}
+	c.Assert(m.Keys(), DeepEquals, []interface{}{"Äpfel", "apple", "Apple", "eagle", "école", "zebra", "Zoo"})
+	c.Assert(m.Validate(), IsNil)
+	// byte order would put every capital first
+	b := NewMap(compareStrings)
+	for i, w := range words {
+		b.Put(w, i)
+	}
+	c.Assert(b.Keys()[0], Equals, "Apple")
+
+	sv := NewCollatedStringMap(language.Swedish)
+	for i, w := range []string{"öl", "zoo", "apa"} {
+		sv.Put(w, i)
+	}
+	// in swedish ö comes after z
+	c.Assert(sv.Keys(), DeepEquals, []interface{}{"apa", "zoo", "öl"})
+}
+
+func (s *CollateSuite) TestConcurrentReaders(c *C) {
+	en := NewCollatedStringMap(language.English)
+	sv := NewCollatedStringMap(language.Swedish)
+	words := []string{"öl", "zoo", "apa", "Äpfel", "école", "eagle"}
+	for i, w := range words {
+		en.Put(w, i)
+		sv.Put(w, i)
+	}
+	// run with -race, readers of either map each compare with a
+	// collator of their own
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			m := en
+			if g%2 == 1 {
+				m = sv
+			}
+			for i := 0; i < 200; i++ {
+				w := words[i%len(words)]
+				v, ok := m.Get(w)
+				c.Check(ok, Equals, true)
+				c.Check(v, Equals, i%len(words))
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Assert(sv.Keys()[len(words)-1], Equals, "öl")
+	c.Assert(en.Keys()[len(words)-1], Equals, "zoo")
+}
@@ -0,0 +1,58 @@
+package skiplist
+
+import (
+	"math"
+)
+
+// Reverse returns a comparison function ordering the opposite way
+// to less, for maps that iterate from the largest key down
+func Reverse(less func(a, b interface{}) bool) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		return less(b, a)
+	}
+}
+
+// Chain returns a comparison function for composite ordering: keys
+// are ordered by the first function, ties (where neither key is less
+// than the other) by the second and so on. keys tied under every
+// function are equal
+func Chain(less ...func(a, b interface{}) bool) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		for _, l := range less {
+			if l(a, b) {
+				return true
+			}
+			if l(b, a) {
+				return false
+			}
+		}
+		return false
+	}
+}
+
+// NewDescendingMap creates a new empty map that iterates from the
+// largest key down, it takes a comparison function that should
+// implement Less for ascending order. everything that speaks of order
+// follows the map's: Min and First give the largest key, PopMin
+// removes it, and Max and PopMax work on the smallest
+func NewDescendingMap(less func(a, b interface{}) bool, opts ...Option) *Map {
+	return NewMap(Reverse(less), opts...)
+}
+
+// Float64Comparator is a comparison function for float64 keys that
+// orders every value, NaN included, where < leaves NaN equal to every
+// key. NaN sorts after +Inf, every NaN being the same key, and -0 and
+// +0 are the same key, as they are under ==
+func Float64Comparator(a, b interface{}) bool {
+	x, y := a.(float64), b.(float64)
+	if math.IsNaN(x) {
+		return false
+	}
+	return x < y || math.IsNaN(y)
+}
+
+// NewFloat64Map creates a new empty map with float64 keys ordered by
+// Float64Comparator
+func NewFloat64Map(opts ...Option) *Map {
+	return NewMap(Float64Comparator, opts...)
+}
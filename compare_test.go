@@ -0,0 +1,140 @@
+package skiplist
+
+import (
+	"math"
+
+	. "gopkg.in/check.v1"
+)
+
+type CompareSuite struct{}
+
+var _ = Suite(&CompareSuite{})
+
+type person struct {
+	last, first string
+}
+
+func compareLast(a, b interface{}) bool  { return a.(person).last < b.(person).last }
+func compareFirst(a, b interface{}) bool { return a.(person).first < b.(person).first }
+
+func (s *CompareSuite) TestReverse(c *C) {
+	m := NewMap(Reverse(compareInts))
+	for i := 0; i < 100; i++ {
+		m.Put(i, i*2)
+	}
+	c.Assert(m.Validate(), IsNil)
+	i := 99
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		c.Assert(e.key, Equals, i)
+		i--
+	}
+	c.Assert(i, Equals, -1)
+	x, ok := m.Get(42)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 84)
+}
+
+func (s *CompareSuite) TestChain(c *C) {
+	m := NewMap(Chain(compareLast, compareFirst))
+	m.Put(person{"smith", "john"}, 1)
+	m.Put(person{"jones", "zoe"}, 2)
+	m.Put(person{"smith", "anna"}, 3)
+	m.Put(person{"jones", "adam"}, 4)
+	c.Assert(m.Put(person{"smith", "john"}, 5), Equals, true)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.FirstN(4), DeepEquals, []Entry{
+		{person{"jones", "adam"}, 4},
+		{person{"jones", "zoe"}, 2},
+		{person{"smith", "anna"}, 3},
+		{person{"smith", "john"}, 5},
+	})
+}
+
+func (s *CompareSuite) TestChainReverseField(c *C) {
+	m := NewMap(Chain(compareLast, Reverse(compareFirst)))
+	m.Put(person{"b", "x"}, 1)
+	m.Put(person{"a", "x"}, 2)
+	m.Put(person{"b", "y"}, 3)
+	c.Assert(m.FirstN(3), DeepEquals, []Entry{
+		{person{"a", "x"}, 2},
+		{person{"b", "y"}, 3},
+		{person{"b", "x"}, 1},
+	})
+}
+
+func (s *CompareSuite) TestChainEmpty(c *C) {
+	less := Chain()
+	c.Assert(less(1, 2), Equals, false)
+	c.Assert(less(2, 1), Equals, false)
+}
+
+func (s *CompareSuite) TestDescendingMap(c *C) {
+	m := NewDescendingMap(compareInts)
+	for _, k := range []int{3, 1, 4, 1, 5, 9, 2, 6} {
+		m.Put(k, k*10)
+	}
+	c.Assert(m.Keys(), DeepEquals, []interface{}{9, 6, 5, 4, 3, 2, 1})
+	c.Assert(m.Validate(), IsNil)
+	k, v, ok := m.Min()
+	c.Assert([]interface{}{k, v, ok}, DeepEquals, []interface{}{9, 90, true})
+	k, _, _ = m.Max()
+	c.Assert(k, Equals, 1)
+	k, _, _ = m.PopMin()
+	c.Assert(k, Equals, 9)
+	k, _, _ = m.PopMax()
+	c.Assert(k, Equals, 1)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{6, 5, 4, 3, 2})
+	// ranges run from the logically smaller key too
+	c.Assert(collect(m, 5, 2), DeepEquals, []Entry{{5, 50}, {4, 40}, {3, 30}})
+}
+
+func (s *CompareSuite) TestFloat64Map(c *C) {
+	keys := []float64{math.NaN(), math.Inf(1), math.Inf(-1), math.Copysign(0, -1), 0, 1.5, -2.25, math.MaxFloat64, -math.SmallestNonzeroFloat64}
+	m := NewFloat64Map(WithComparatorCheck())
+	for i, k := range keys {
+		m.Put(k, i)
+	}
+	// -0 and +0 are one key, so the +0 overwrote the -0
+	c.Assert(m.Len(), Equals, len(keys)-1)
+	c.Assert(m.Validate(), IsNil)
+	got := m.Keys()
+	c.Assert(got[:len(got)-1], DeepEquals, []interface{}{math.Inf(-1), -2.25, -math.SmallestNonzeroFloat64, 0.0, 1.5, math.MaxFloat64, math.Inf(1)})
+	c.Assert(math.IsNaN(got[len(got)-1].(float64)), Equals, true)
+	for i, k := range keys {
+		v, ok := m.Get(k)
+		c.Assert(ok, Equals, true)
+		if i != 3 {
+			c.Assert(v, Equals, i)
+		}
+	}
+	v, _ := m.Get(math.Copysign(0, -1))
+	c.Assert(v, Equals, 4)
+	// a NaN with another payload is the same key
+	m.Put(math.Float64frombits(0x7ff8000000000001), "nan")
+	v, _ = m.Get(math.NaN())
+	c.Assert(v, Equals, "nan")
+	c.Assert(m.Len(), Equals, len(keys)-1)
+}
+
+func (s *CompareSuite) TestComparatorCheck(c *C) {
+	naive := func(a, b interface{}) bool { return a.(float64) < b.(float64) }
+	m := NewMap(naive, WithComparatorCheck())
+	for _, k := range []float64{1, 2, 3} {
+		m.Put(k, k)
+	}
+	// NaN is equal to 1 under <, but not less than 2
+	c.Assert(func() { m.Put(math.NaN(), 0.0) }, PanicMatches, "skiplist: incomparable key NaN.*")
+	c.Assert(m.Keys(), DeepEquals, []interface{}{1.0, 2.0, 3.0})
+	v, _ := m.Get(1.0)
+	c.Assert(v, Equals, 1.0)
+	m.Put(2.0, "two")
+	c.Assert(m.Validate(), IsNil)
+
+	// without the check the NaN silently replaces the first pair
+	m = NewMap(naive)
+	m.Put(1.0, 1.0)
+	m.Put(2.0, 2.0)
+	m.Put(math.NaN(), 0.0)
+	v, _ = m.Get(1.0)
+	c.Assert(v, Equals, 0.0)
+}
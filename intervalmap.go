@@ -0,0 +1,204 @@
+package skiplist
+
+import (
+	"sync"
+)
+
+// Interval is a closed interval [Start, End] with a value, as
+// stored in an IntervalMap
+type Interval struct {
+	Start interface{}
+	End   interface{}
+	Val   interface{}
+}
+
+// IntervalMap holds intervals, which may overlap, in a skip list
+// ordered by start then end. every link also records the largest end
+// among the intervals it passes over, so stabbing and overlap queries
+// can skip whole stretches of the list that end too early
+type IntervalMap struct {
+	less   func(a, b interface{}) bool
+	head   *intervalElement
+	length int
+	rnd    levelDraw
+	mutex  sync.RWMutex
+}
+
+// intervalElement is an interval in the list. maxEnd[level] is the
+// largest end of the intervals from this element up to but not
+// including next[level], or nil if there are none, which is only the
+// case at level 0 of the head
+type intervalElement struct {
+	Interval
+	next   []*intervalElement
+	maxEnd []interface{}
+}
+
+// NewIntervalMap creates a new empty interval map, it takes a
+// comparison function for the points of the intervals that
+// should implement Less. it chooses levels the way a Map does
+func NewIntervalMap(less func(a, b interface{}) bool) *IntervalMap {
+	return NewIntervalMapWithSeed(less, defaultSeed)
+}
+
+// NewIntervalMapWithSeed creates a new empty interval map like
+// NewIntervalMap, choosing levels from a source seeded with seed, as a
+// Map made WithSeed(seed) does
+func NewIntervalMapWithSeed(less func(a, b interface{}) bool, seed uint64) *IntervalMap {
+	return &IntervalMap{
+		less: less,
+		head: &intervalElement{
+			next:   make([]*intervalElement, maxHeight),
+			maxEnd: make([]interface{}, maxHeight),
+		},
+		rnd: newLevelDraw(seed),
+	}
+}
+
+// before returns true if e sorts before the interval [start, end]
+func (im *IntervalMap) before(e *intervalElement, start, end interface{}) bool {
+	if im.less(e.Start, start) {
+		return true
+	}
+	return !im.less(start, e.Start) && im.less(e.End, end)
+}
+
+// after returns true if e sorts after the interval [start, end]
+func (im *IntervalMap) after(e *intervalElement, start, end interface{}) bool {
+	if im.less(start, e.Start) {
+		return true
+	}
+	return !im.less(e.Start, start) && im.less(end, e.End)
+}
+
+// max returns the larger of two ends, nil meaning no end
+func (im *IntervalMap) max(a, b interface{}) interface{} {
+	if a == nil || b != nil && im.less(a, b) {
+		return b
+	}
+	return a
+}
+
+// fixMaxEnd recomputes e.maxEnd[level] from the level below
+func (im *IntervalMap) fixMaxEnd(e *intervalElement, level int) {
+	if level == 0 {
+		if e != im.head {
+			e.maxEnd[0] = e.End
+		}
+		return
+	}
+	var max interface{}
+	for x := e; x != e.next[level]; x = x.next[level-1] {
+		max = im.max(max, x.maxEnd[level-1])
+	}
+	e.maxEnd[level] = max
+}
+
+// Insert adds the interval [start, end] with a value, after any equal
+// intervals already in the map. it panics if end is less than start
+// or either is nil
+func (im *IntervalMap) Insert(start, end, val interface{}) {
+	if start == nil || end == nil {
+		panic("skiplist: nil interval point")
+	}
+	if im.less(end, start) {
+		panic("skiplist: interval ends before it starts")
+	}
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	var backPointer [maxHeight]*intervalElement
+	prev := im.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && !im.after(next, start, end); next = prev.next[level] {
+			prev = next
+		}
+		backPointer[level] = prev
+	}
+	levels := im.rnd.draw(maxHeight)
+	e := &intervalElement{Interval{start, end, val}, make([]*intervalElement, levels), make([]interface{}, levels)}
+	for level := 0; level < levels; level++ {
+		e.next[level] = backPointer[level].next[level]
+		backPointer[level].next[level] = e
+	}
+	// bottom up, so each level is built from a finished one
+	for level := 0; level < maxHeight; level++ {
+		if level < levels {
+			im.fixMaxEnd(e, level)
+		}
+		im.fixMaxEnd(backPointer[level], level)
+	}
+	im.length++
+}
+
+// Remove removes the first interval [start, end] in the map,
+// returns true if it found and removed one, false otherwise
+func (im *IntervalMap) Remove(start, end interface{}) bool {
+	if start == nil || end == nil {
+		return false
+	}
+	im.mutex.Lock()
+	defer im.mutex.Unlock()
+	var backPointer [maxHeight]*intervalElement
+	prev := im.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && im.before(next, start, end); next = prev.next[level] {
+			prev = next
+		}
+		backPointer[level] = prev
+	}
+	e := prev.next[0]
+	if e == nil || im.after(e, start, end) {
+		return false
+	}
+	for level := range e.next {
+		backPointer[level].next[level] = e.next[level]
+	}
+	for level := 0; level < maxHeight; level++ {
+		im.fixMaxEnd(backPointer[level], level)
+	}
+	im.length--
+	return true
+}
+
+// Stab returns the intervals containing p, in order
+func (im *IntervalMap) Stab(p interface{}) []Interval {
+	return im.Overlaps(p, p)
+}
+
+// Overlaps returns the intervals sharing any points with [start, end],
+// in order
+func (im *IntervalMap) Overlaps(start, end interface{}) []Interval {
+	ret := []Interval{}
+	im.mutex.RLock()
+	defer im.mutex.RUnlock()
+	im.overlaps(im.head, maxHeight-1, start, end, &ret)
+	return ret
+}
+
+// overlaps appends the intervals overlapping [start, end] from the
+// stretch of the list covered by e at a level to ret, skipping the
+// stretch if nothing in it ends late enough
+func (im *IntervalMap) overlaps(e *intervalElement, level int, start, end interface{}, ret *[]Interval) {
+	if e.maxEnd[level] == nil || im.less(e.maxEnd[level], start) {
+		return
+	}
+	if level == 0 {
+		// e ends late enough, and the caller checked its start
+		*ret = append(*ret, e.Interval)
+		return
+	}
+	for x := e; x != e.next[level]; x = x.next[level-1] {
+		if x != im.head && im.less(end, x.Start) {
+			// everything from here on starts too late
+			return
+		}
+		im.overlaps(x, level-1, start, end, ret)
+	}
+}
+
+// Len returns the number of intervals in the map
+func (im *IntervalMap) Len() int {
+	im.mutex.RLock()
+	defer im.mutex.RUnlock()
+	return im.length
+}
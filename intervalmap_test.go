@@ -0,0 +1,115 @@
+package skiplist
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type IntervalSuite struct{}
+
+var _ = Suite(&IntervalSuite{})
+
+// bruteOverlaps finds the intervals overlapping [start, end] the slow
+// way, in the order an IntervalMap keeps them
+func bruteOverlaps(all []Interval, start, end int) []Interval {
+	ret := []Interval{}
+	for _, iv := range all {
+		if iv.Start.(int) <= end && start <= iv.End.(int) {
+			ret = append(ret, iv)
+		}
+	}
+	return ret
+}
+
+// sortedIntervals inserts iv into all keeping the IntervalMap's order
+func sortedIntervals(all []Interval, iv Interval) []Interval {
+	i := 0
+	for i < len(all) && (all[i].Start.(int) < iv.Start.(int) ||
+		all[i].Start == iv.Start && all[i].End.(int) <= iv.End.(int)) {
+		i++
+	}
+	all = append(all, Interval{})
+	copy(all[i+1:], all[i:])
+	all[i] = iv
+	return all
+}
+
+func (s *IntervalSuite) TestAgainstBruteForce(c *C) {
+	r := rand.New(rand.NewSource(42))
+	im := NewIntervalMap(compareInts)
+	all := []Interval{}
+	for round := 0; round < 2000; round++ {
+		if len(all) > 0 && r.Intn(3) == 0 {
+			iv := all[r.Intn(len(all))]
+			c.Assert(im.Remove(iv.Start, iv.End), Equals, true)
+			// the first equal interval goes
+			for i := range all {
+				if all[i].Start == iv.Start && all[i].End == iv.End {
+					all = append(all[:i], all[i+1:]...)
+					break
+				}
+			}
+		} else {
+			start := r.Intn(1000)
+			end := start + r.Intn(r.Intn(200)+1)
+			iv := Interval{start, end, round}
+			im.Insert(start, end, round)
+			all = sortedIntervals(all, iv)
+		}
+		c.Assert(im.Len(), Equals, len(all))
+		if round%20 == 0 {
+			p := r.Intn(1200) - 100
+			c.Assert(im.Stab(p), DeepEquals, bruteOverlaps(all, p, p))
+			start := r.Intn(1200) - 100
+			end := start + r.Intn(100)
+			c.Assert(im.Overlaps(start, end), DeepEquals, bruteOverlaps(all, start, end))
+		}
+	}
+	for p := -5; p < 1300; p += 7 {
+		c.Assert(im.Stab(p), DeepEquals, bruteOverlaps(all, p, p))
+	}
+}
+
+func (s *IntervalSuite) TestStab(c *C) {
+	im := NewIntervalMap(compareInts)
+	im.Insert(1, 10, "a")
+	im.Insert(2, 3, "b")
+	im.Insert(5, 20, "c")
+	im.Insert(12, 12, "d")
+	c.Assert(im.Stab(0), DeepEquals, []Interval{})
+	c.Assert(im.Stab(3), DeepEquals, []Interval{{1, 10, "a"}, {2, 3, "b"}})
+	c.Assert(im.Stab(10), DeepEquals, []Interval{{1, 10, "a"}, {5, 20, "c"}})
+	c.Assert(im.Stab(12), DeepEquals, []Interval{{5, 20, "c"}, {12, 12, "d"}})
+	c.Assert(im.Overlaps(11, 11), DeepEquals, []Interval{{5, 20, "c"}})
+	c.Assert(im.Overlaps(-5, 1), DeepEquals, []Interval{{1, 10, "a"}})
+	c.Assert(im.Remove(5, 20), Equals, true)
+	c.Assert(im.Remove(5, 20), Equals, false)
+	c.Assert(im.Remove(1, 9), Equals, false)
+	c.Assert(im.Stab(15), DeepEquals, []Interval{})
+	c.Assert(func() { im.Insert(3, 2, nil) }, PanicMatches, "skiplist: interval ends before it starts")
+	c.Assert(NewIntervalMap(compareInts).Stab(1), DeepEquals, []Interval{})
+}
+
+func (s *IntervalSuite) TestWithSeed(c *C) {
+	// levels come from the same source as a Map's, so the same seed
+	// gives the same heights
+	m := NewMap(compareInts, WithSeed(5))
+	im := NewIntervalMapWithSeed(compareInts, 5)
+	other := NewIntervalMapWithSeed(compareInts, 6)
+	heights := func(im *IntervalMap) []int {
+		ret := []int{}
+		for e := im.head.next[0]; e != nil; e = e.next[0] {
+			ret = append(ret, len(e.next))
+		}
+		return ret
+	}
+	for i := 0; i < 200; i++ {
+		m.Put(i, i)
+		im.Insert(i, i+5, i)
+		other.Insert(i, i+5, i)
+	}
+	c.Assert(heights(im), DeepEquals, shape(m))
+	c.Assert(heights(other), Not(DeepEquals), shape(m))
+	c.Assert(other.Stab(10), HasLen, 6)
+}
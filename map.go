@@ -1,207 +1,555 @@
 package skiplist
 
 import (
+	"errors"
 	//"log"
-	"math"
-	"math/rand"
+	"math/bits"
 	"sync"
+	"sync/atomic"
 )
 
-// Map is the struct to hold the details of a map
+// Map is the struct to hold the details of a map.
+// keys may not be nil, values may
 type Map struct {
 	comp      func(a, b interface{}) bool
-	head      []*mapElement
+	head      *mapElement
 	mutex     *sync.RWMutex
 	length    int
 	maxLevels int
-	r         *rand.Rand
+	ceiling   int
+	rnd       levelDraw
+	multi     bool
+	tieBreak  func(a, b interface{}) bool
+	watchers  []*watcher
+	dropped   uint64
+	onChange  []func(op string, k, v interface{})
+	index     *hashIndex
+	wal       *wal
+	slow      *slowHook
+	version   uint64
+	frozen    atomic.Bool
+	persister Persister
+	preSave   bool
+	journal   *journal
+	digest    *fingerprint
+	flightMu  sync.Mutex
+	inflight  *Map
+	callback  callbacks
+	normalize func(k interface{}) interface{}
+	compare   func(a, b interface{}) int
+	tombstone bool
+	byteOrder [2]atomic.Int32
+	cost      *costs
+	history   int
+	checkComp bool
+	arena     *arena
 }
 
+var (
+	// ErrKeyNotFound is returned when a key needed by an operation is missing
+	ErrKeyNotFound = errors.New("skiplist: key not found")
+	// ErrKeyExists is returned when an operation would overwrite a key
+	ErrKeyExists = errors.New("skiplist: key already exists")
+)
+
 func (m *Map) Mutex() *sync.RWMutex {
 	return m.mutex
 }
 
-// mapElement is the struct to hold elements of the map
+// mapElement is the struct to hold elements of the map.
+// span[level] counts the live elements passed stepping along level 0
+// from the element to next[level], next[level] included, so tombstones
+// take no room. it is only meaningful while next[level] is not nil.
+// deleted marks a tombstone, see WithTombstones. version counts the
+// values the pair has held, see PutVersioned, and past holds the last
+// few of them if the map keeps them, see WithHistory
 type mapElement struct {
-	key  interface{}
-	val  interface{}
-	next []*mapElement
+	key     interface{}
+	val     interface{}
+	next    []*mapElement
+	span    []int
+	deleted bool
+	version uint64
+	past    *valueRing
 }
 
+// maxHeight is the most levels a map can have
+const maxHeight = 64
+
+// Option configures a map as it is created
+type Option func(m *Map)
+
 // NewMap creates a new empty map, it takes a
 // comparison function that should implement Less
-func NewMap(less func(a, b interface{}) bool) *Map {
-	return &Map{
+// and any options to configure the map
+func NewMap(less func(a, b interface{}) bool, opts ...Option) *Map {
+	m := &Map{
 		comp:      less,
-		maxLevels: 64,
-		head:      make([]*mapElement, 64),
-		r:         rand.New(rand.NewSource(123123)),
+		maxLevels: maxHeight,
+		ceiling:   maxHeight,
+		head:      newHead(maxHeight),
+		rnd:       newLevelDraw(defaultSeed),
 		mutex:     &sync.RWMutex{},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
-func newMapElement(k interface{}, v interface{}, levels int) *mapElement {
-	return &mapElement{k, v, make([]*mapElement, levels)}
+// NewMapWithCapacity creates a new empty map like NewMap, sized for
+// around expected pairs: elements get at most ceil(log2(expected))
+// levels, which is enough for searches to stay O(log n) at that size
+// without the head carrying levels that will never be used
+func NewMapWithCapacity(less func(a, b interface{}) bool, expected int, opts ...Option) *Map {
+	levels := levelsFor(expected)
+	m := NewMap(less, opts...)
+	m.maxLevels, m.ceiling = levels, levels
+	m.head = newHead(levels)
+	return m
 }
 
-func randomLevels(m *Map) int {
-	level := int(math.Log(1.0-m.r.Float64()) / math.Log(1.0-0.5))
-	if level >= m.maxLevels {
-		level = m.maxLevels
+// newHead makes the head of an empty map with levels levels. it is an
+// element with no key that every search starts from, so the element
+// before a place in the list is never missing, at worst it is the head
+func newHead(levels int) *mapElement {
+	return &mapElement{next: make([]*mapElement, levels), span: make([]int, levels)}
+}
+
+// levelsFor returns the levels a map of around n pairs needs,
+// ceil(log2(n)), but at least 1 and at most maxHeight
+func levelsFor(n int) int {
+	levels := 1
+	if n > 1 {
+		levels = bits.Len(uint(n - 1))
+	}
+	if levels > maxHeight {
+		levels = maxHeight
+	}
+	return levels
+}
+
+// Entry is a key/value pair copied out of a map
+type Entry struct {
+	Key interface{}
+	Val interface{}
+}
+
+// elementPool holds elements recycled by ClearAndRecycle
+var elementPool sync.Pool
+
+func newMapElement(k interface{}, v interface{}, levels int) *mapElement {
+	if e, ok := elementPool.Get().(*mapElement); ok && cap(e.next) >= levels {
+		e.key, e.val, e.deleted, e.version, e.past = k, v, false, 1, nil
+		e.next = e.next[:levels]
+		e.span = e.span[:levels]
+		return e
 	}
-	if level == 0 {
-		level++
+	return allocElement(k, v, levels)
+}
+
+// element1 through element4 hold an element together with the arrays
+// its links point into, so a short tower, which most are, is one
+// allocation whose links sit next to the key they lead from rather
+// than in two other objects somewhere else in memory
+type element1 struct {
+	mapElement
+	nextArr [1]*mapElement
+	spanArr [1]int
+}
+
+type element2 struct {
+	mapElement
+	nextArr [2]*mapElement
+	spanArr [2]int
+}
+
+type element3 struct {
+	mapElement
+	nextArr [3]*mapElement
+	spanArr [3]int
+}
+
+type element4 struct {
+	mapElement
+	nextArr [4]*mapElement
+	spanArr [4]int
+}
+
+// allocElement allocates a new element for k and v with levels levels,
+// in one block with its links when it has 4 levels or fewer
+func allocElement(k interface{}, v interface{}, levels int) *mapElement {
+	var e *mapElement
+	switch levels {
+	case 1:
+		b := &element1{}
+		b.next, b.span = b.nextArr[:], b.spanArr[:]
+		e = &b.mapElement
+	case 2:
+		b := &element2{}
+		b.next, b.span = b.nextArr[:], b.spanArr[:]
+		e = &b.mapElement
+	case 3:
+		b := &element3{}
+		b.next, b.span = b.nextArr[:], b.spanArr[:]
+		e = &b.mapElement
+	case 4:
+		b := &element4{}
+		b.next, b.span = b.nextArr[:], b.spanArr[:]
+		e = &b.mapElement
+	default:
+		return &mapElement{k, v, make([]*mapElement, levels), make([]int, levels), false, 1, nil}
 	}
-	return level
+	e.key, e.val, e.version = k, v, 1
+	return e
+}
+
+func randomLevels(m *Map) int {
+	return randomLevelsUnder(m, m.maxLevels)
+}
+
+// randomLevelsUnder draws a height like randomLevels, but at most max
+// rather than the levels the map has now, for elements linked up into
+// a chain without the lock, while the map may be regrowing
+func randomLevelsUnder(m *Map, max int) int {
+	return m.rnd.draw(max)
 }
 
 // Put takes a key and value, and puts the value
 // in the map for the key, replacing an existing value.
-// returns true if it overwrites, false if it inserts a new key/value pair
+// returns true if it overwrites, false if it inserts a new key/value pair.
+// in a multimap it never overwrites, the pair goes after any others for k.
+// it panics if k is nil. a persister's error is dropped, see PutE
 func (m *Map) Put(k interface{}, v interface{}) bool {
-	m.mutex.Lock()
-	var backPointer = make([]*mapElement, 64)
-	// zeroing this causes the compiler to not allocate memory each time
-	// for a 20-30% boost in speed
-	for i := 0; i < 64; i++ {
-		backPointer[i] = nil
+	ret, _ := m.PutE(k, v)
+	return ret
+}
+
+// PutE is Put for a map with a persister, returning the persister's
+// error if it fails, in which case the map is left as it was
+func (m *Map) PutE(k interface{}, v interface{}) (bool, error) {
+	ret, _, err := m.putE(k, v, false, nil)
+	return ret, err
+}
+
+// putE does the work of PutE and PutVersioned, also returning the
+// version the pair was put at if versioned is true, otherwise 0. if
+// when isn't nil the put is only made if it returns true, see putLocked
+func (m *Map) putE(k interface{}, v interface{}, versioned bool, when func(old interface{}, ok bool) bool) (bool, uint64, error) {
+	k = m.normal(k)
+	checkKey(k)
+	start := m.startOp()
+	ret, version, evicted, hooks, err := m.putLocked(k, v, versioned, when)
+	op := "put"
+	if ret {
+		op = "overwrite"
 	}
-	for level := m.maxLevels - 1; level >= 0; level-- {
-		var e *mapElement = nil
-		if level+1 == m.maxLevels || backPointer[level+1] == nil {
-			e = m.head[level]
-		} else {
-			e = backPointer[level+1]
-		}
-		for e != nil {
-			// if they are equal, overwrite
-			if m.comp(k, e.key) == m.comp(e.key, k) {
-				e.val = v
-				m.mutex.Unlock()
-				return true
-			}
-			// if inspected val is greater than k, go back and down a level
-			if m.comp(k, e.key) {
-				break
-			}
-			backPointer[level] = e
-			e = e.next[level]
+	if err == nil {
+		for _, fn := range hooks {
+			fn(op, k, v)
 		}
 	}
-	// create new element
-	e := newMapElement(k, v, randomLevels(m))
+	m.evicted(evicted)
+	m.endOp("put", k, start)
+	return ret, version, err
+}
 
-	// connect new element up with backPointer
-	for level := 0; level < len(e.next); level++ {
-		if backPointer[level] == nil {
-			e.next[level] = m.head[level]
-			m.head[level] = e
-		} else {
-			e.next[level] = backPointer[level].next[level]
-			backPointer[level].next[level] = e
+// putLocked does the part of PutE done under the write lock, returning
+// what it needs once the lock is let go. the lock is let go by a defer,
+// so a panic in the comparator doesn't leave the map locked. when, if
+// it isn't nil, is called first with the value for k and whether there
+// is one, and the map is left alone, with no hooks to call, unless it
+// returns true. it is how the conditional puts of SyncMap are made
+func (m *Map) putLocked(k, v interface{}, versioned bool, when func(old interface{}, ok bool) bool) (ret bool, version uint64, evicted []Entry, hooks []func(op string, k, v interface{}), err error) {
+	m.lock()
+	defer m.mutex.Unlock()
+	if when != nil && !when(m.get(k)) {
+		return false, 0, nil, nil, nil
+	}
+	ret, err = m.putPersisted(k, v)
+	if err == nil {
+		if versioned {
+			version = m.putVersion(k)
 		}
+		evicted = m.evict(k)
 	}
+	return ret, version, evicted, m.onChange, err
+}
+
+// put does the work of Put, the caller must hold the write lock.
+// it changes nothing until its search is done, so a panic in the
+// comparator leaves the list as it was
+func (m *Map) put(k interface{}, v interface{}) bool {
+	if m.multi {
+		m.putMulti(k, v)
+		return false
+	}
+	// an array this size stays on the stack
+	var backPointer [maxHeight]*mapElement
+	if e := m.find(k, backPointer[:]); e != nil {
+		if m.checkComp {
+			m.checkPlace(k, e)
+		}
+		old := e.val
+		e.val = v
+		m.changed(e)
+		m.notify(OpUpdate, k, old, v)
+		return true
+	}
+	if e := m.buried(k, backPointer[:]); e != nil {
+		m.revive(e, v, backPointer[:])
+		return false
+	}
+	// create new element
+	e := m.newElement(k, v, randomLevels(m))
+	m.link(e, backPointer[:])
 	//log.Println(e, backPointer)
+	return false
+}
 
+// checkKey panics if k can't be stored in a map. this is done
+// before taking the lock, so the map stays usable afterwards
+func checkKey(k interface{}) {
+	if k == nil {
+		panic("skiplist: nil key")
+	}
+}
+
+// link connects a new element up with backPointer, which holds
+// the element to link after at every level
+func (m *Map) link(e *mapElement, backPointer []*mapElement) {
+	// dist is the number of level 0 steps from backPointer[level] to e
+	dist := 1
+	for level := 0; level < m.maxLevels; level++ {
+		prev := backPointer[level]
+		if level >= len(e.next) {
+			// links passing over e get one step longer
+			if prev.next[level] != nil {
+				prev.span[level] = prev.span[level] + 1
+			}
+			continue
+		}
+		if level > 0 {
+			for p := prev; p != backPointer[level-1]; p = p.next[level-1] {
+				dist += p.span[level-1]
+			}
+		}
+		e.next[level] = prev.next[level]
+		if e.next[level] != nil {
+			e.span[level] = prev.span[level] - dist + 1
+		}
+		prev.next[level] = e
+		prev.span[level] = dist
+	}
 	m.length++
-	m.mutex.Unlock()
-	return false
+	m.regrow(m.length)
+	m.added(e)
+}
+
+// unlink disconnects an element from the list, backPointer holds
+// the element just before it at every level
+func (m *Map) unlink(e *mapElement, backPointer []*mapElement) {
+	// a tombstone was taken out of the spans and the length when it
+	// was marked, see bury
+	width := 1
+	if e.deleted {
+		width = 0
+	}
+	for level := 0; level < m.maxLevels; level++ {
+		prev := backPointer[level]
+		if level >= len(e.next) {
+			if prev.next[level] != nil {
+				prev.span[level] = prev.span[level] - width
+			}
+			continue
+		}
+		prev.next[level] = e.next[level]
+		if e.next[level] != nil {
+			prev.span[level] = prev.span[level] + e.span[level] - width
+		}
+	}
+	m.length -= width
+	// a tombstone was reported removed when it was marked
+	if !e.deleted {
+		m.removed(e.key, e.val)
+	}
+}
+
+// find returns the first element with a key equal to k, or nil if
+// there is none, filling backPointer like lowerBound
+func (m *Map) find(k interface{}, backPointer []*mapElement) *mapElement {
+	if m.compare != nil {
+		return m.findCompare(k, backPointer)
+	}
+	e := m.lowerBound(k, backPointer)
+	if e == nil || e.deleted || m.comp(k, e.key) {
+		return nil
+	}
+	return e
+}
+
+// lowerBound returns the first element whose key is not less than k,
+// or nil if there is none. if backPointer is not nil it is filled with
+// the last element before k at each level
+func (m *Map) lowerBound(k interface{}, backPointer []*mapElement) *mapElement {
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		e := prev.next[level]
+		for e != nil && m.comp(e.key, k) {
+			prev = e
+			e = e.next[level]
+		}
+		if backPointer != nil {
+			backPointer[level] = prev
+		}
+	}
+	return prev.next[0]
+}
+
+// upperBound returns the first element whose key is greater than k,
+// or nil if there is none. if backPointer is not nil it is filled with
+// the last element not greater than k at each level
+func (m *Map) upperBound(k interface{}, backPointer []*mapElement) *mapElement {
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		e := prev.next[level]
+		for e != nil && !m.comp(k, e.key) {
+			prev = e
+			e = e.next[level]
+		}
+		if backPointer != nil {
+			backPointer[level] = prev
+		}
+	}
+	return prev.next[0]
 }
 
 // Len returns the length of a Map
 func (m *Map) Len() int {
-	m.mutex.RLock()
+	defer m.endOp("len", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
 	// TODO why is this busted
 	//ret := m.length
-	e := m.head[0]
+	e := m.head.next[0]
 	ret := 0
 	for e != nil {
-		ret++
+		if !e.deleted {
+			ret++
+		}
 		//g.Println("debug:", e, ";", e.next[0])
 		e = e.next[0]
 	}
-	m.mutex.RUnlock()
+	return ret
+}
+
+// Keys returns all the keys in the map in order
+func (m *Map) Keys() []interface{} {
+	defer m.endOp("keys", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	ret := make([]interface{}, 0, m.length)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		ret = append(ret, e.key)
+	}
 	return ret
 }
 
 // Get returns the value for a key, and true if it finds the key,
-// false otherwise
+// false otherwise. in a multimap it returns the first value for the key
 func (m *Map) Get(k interface{}) (interface{}, bool) {
-	m.mutex.RLock()
-	var backPointer = make([]*mapElement, 64)
-	// zeroing this causes the compiler to not allocate memory each time
-	// for a 20-30% boost in speed
-	for i := 0; i < 64; i++ {
-		backPointer[i] = nil
+	k = m.normal(k)
+	start := m.startOp()
+	v, ok := m.getLocked(k)
+	m.endOp("get", k, start)
+	return v, ok
+}
+
+// getLocked does get under the read lock
+func (m *Map) getLocked(k interface{}) (interface{}, bool) {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.get(k)
+}
+
+// get does the work of Get, the caller must hold the lock
+func (m *Map) get(k interface{}) (interface{}, bool) {
+	if k == nil {
+		return nil, false
 	}
-	for level := m.maxLevels - 1; level >= 0; level-- {
-		var e *mapElement = nil
-		if level+1 == m.maxLevels || backPointer[level+1] == nil {
-			e = m.head[level]
-		} else {
-			e = backPointer[level+1]
-		}
-		for e != nil {
-			// if they are equal, return val
-			if m.comp(k, e.key) == m.comp(e.key, k) {
-				m.mutex.RUnlock()
-				return e.val, true
-			}
-			// if inspected val is greater than k, go back and down a level
-			if m.comp(k, e.key) {
-				break
-			}
-			backPointer[level] = e
-			e = e.next[level]
+	if m.index != nil && !m.multi {
+		if e := m.index.get(k); e != nil {
+			return e.val, true
 		}
+		return nil, false
+	}
+	if e := m.find(k, nil); e != nil {
+		return e.val, true
 	}
-	m.mutex.RUnlock()
 	return nil, false
 }
 
 // Remove removes the element (k/v pair) for a key,
-// returns true if it found and removed, false otherwise
+// returns true if it found and removed, false otherwise.
+// in a multimap it removes the first pair for the key.
+// a persister's error is dropped, see RemoveE
 func (m *Map) Remove(k interface{}) bool {
-	m.mutex.Lock()
-	var backPointer = make([]*mapElement, 64)
-	// zeroing this causes the compiler to not allocate memory each time
-	// for a 20-30% boost in speed
-	for i := 0; i < 64; i++ {
-		backPointer[i] = nil
+	ret, _ := m.RemoveE(k)
+	return ret
+}
+
+// RemoveE is Remove for a map with a persister, returning the
+// persister's error if it fails, in which case the map is left as it
+// was and the result is false
+func (m *Map) RemoveE(k interface{}) (bool, error) {
+	_, ret, err := m.removeE(k, nil)
+	return ret, err
+}
+
+// removeE does the work of RemoveE, also returning the value removed.
+// if when isn't nil the remove is only made if it returns true, see
+// removeLocked
+func (m *Map) removeE(k interface{}, when func(old interface{}, ok bool) bool) (interface{}, bool, error) {
+	k = m.normal(k)
+	start := m.startOp()
+	v, ret, hooks, err := m.removeLocked(k, when)
+	if ret {
+		for _, fn := range hooks {
+			fn("remove", k, v)
+		}
 	}
-	for level := m.maxLevels - 1; level >= 0; level-- {
-		var e *mapElement = nil
-		if level+1 == m.maxLevels || backPointer[level+1] == nil {
-			e = m.head[level]
+	m.endOp("remove", k, start)
+	return v, ret, err
+}
+
+// removeLocked does the part of RemoveE done under the write lock,
+// returning what it needs once the lock is let go. when, if it isn't
+// nil, is called first as it is by putLocked, and the map is left alone
+// unless it returns true
+func (m *Map) removeLocked(k interface{}, when func(old interface{}, ok bool) bool) (v interface{}, ret bool, hooks []func(op string, k, v interface{}), err error) {
+	m.lock()
+	defer m.mutex.Unlock()
+	if when != nil && !when(m.get(k)) {
+		return nil, false, nil, nil
+	}
+	v, ret, err = m.removePersisted(k)
+	return v, ret, m.onChange, err
+}
+
+// remove does the work of Remove, returning the value removed.
+// the caller must hold the write lock
+func (m *Map) remove(k interface{}) (interface{}, bool) {
+	if k == nil {
+		return nil, false
+	}
+	var backPointer [maxHeight]*mapElement
+	if e := m.find(k, backPointer[:]); e != nil {
+		if m.tombstone && !m.multi {
+			m.bury(e, backPointer[:])
 		} else {
-			e = backPointer[level+1]
-		}
-		for e != nil {
-			// if they are equal, remove and return true
-			if level == 0 && m.comp(k, e.key) == m.comp(e.key, k) {
-				for level := 0; level < len(e.next); level++ {
-					if backPointer[level] == nil {
-						m.head[level] = e.next[level]
-					} else {
-						backPointer[level].next[level] = e.next[level]
-					}
-				}
-
-				m.length--
-				m.mutex.Unlock()
-				return true
-			}
-			if m.comp(k, e.key) == m.comp(e.key, k) {
-				break
-			}
-			// if inspected val is greater than k, go back and down a level
-			if m.comp(k, e.key) {
-				break
-			}
-			backPointer[level] = e
-			e = e.next[level]
+			m.unlink(e, backPointer[:])
 		}
+		return e.val, true
 	}
-	m.mutex.Unlock()
-	return false
+	return nil, false
 }
@@ -0,0 +1,96 @@
+package skiplist
+
+// defaultArenaChunk is how many elements a chunk of an arena holds
+// when WithArena isn't given a size
+const defaultArenaChunk = 4096
+
+// arena hands out elements, and the slices for their links, carved
+// from large chunks, so a map of millions of pairs is a few thousand
+// objects to the garbage collector rather than millions. a chunk is
+// only freed once nothing points into it
+type arena struct {
+	chunk int
+	elems []mapElement
+	next  []*mapElement
+	span  []int
+}
+
+// newArena makes an empty arena whose chunks hold chunk elements
+func newArena(chunk int) *arena {
+	if chunk <= 0 {
+		chunk = defaultArenaChunk
+	}
+	return &arena{chunk: chunk}
+}
+
+// alloc returns a new element for k and v with levels levels
+func (a *arena) alloc(k, v interface{}, levels int) *mapElement {
+	if len(a.elems) == 0 {
+		a.elems = make([]mapElement, a.chunk)
+	}
+	// an element has 2 levels on average
+	if len(a.next) < levels {
+		a.next = make([]*mapElement, 2*a.chunk+levels)
+		a.span = make([]int, 2*a.chunk+levels)
+	}
+	e := &a.elems[0]
+	a.elems = a.elems[1:]
+	*e = mapElement{k, v, a.next[:levels:levels], a.span[:levels:levels], false, 1, nil}
+	a.next, a.span = a.next[levels:], a.span[levels:]
+	return e
+}
+
+// WithArena allocates the map's elements from chunks of chunk
+// elements, or 4096 if chunk isn't positive, carving the slices for
+// their links from shared arrays too, which cuts the time the garbage
+// collector spends tracing a large map and speeds up loading it. the
+// price is memory: a removed pair's element, key and value stay in its
+// chunk until CompactArena copies the live pairs into new chunks or
+// Clear drops them all, and a chunk is kept while any of its elements
+// is in use. pairs added by BulkLoadChan are allocated as usual
+func WithArena(chunk int) Option {
+	return func(m *Map) {
+		m.arena = newArena(chunk)
+	}
+}
+
+// newElement returns a new element for k and v with levels levels,
+// from the map's arena if it has one. the caller must hold the write
+// lock
+func (m *Map) newElement(k, v interface{}, levels int) *mapElement {
+	if m.arena != nil {
+		return m.arena.alloc(k, v, levels)
+	}
+	return newMapElement(k, v, levels)
+}
+
+// CompactArena copies the live pairs of a map made WithArena into new
+// chunks and lets the old ones go, returning the memory held by removed
+// pairs. it takes the write lock for an O(n) pass, and since every
+// element moves, iterators find their place again by key. it does
+// nothing for a map without an arena
+func (m *Map) CompactArena() {
+	defer m.endOp("compactarena", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	if m.arena == nil {
+		return
+	}
+	m.purge()
+	a := newArena(m.arena.chunk)
+	c := newChain(m.maxLevels)
+	if m.index != nil {
+		m.index.clear()
+	}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		x := a.alloc(e.key, e.val, len(e.next))
+		x.version, x.past = e.version, e.past
+		c.push(x)
+		if m.index != nil {
+			m.index.add(x)
+		}
+	}
+	m.head.next, m.head.span = c.head, c.headSpan
+	m.arena = a
+	m.version++
+}
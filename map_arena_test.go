@@ -0,0 +1,146 @@
+package skiplist
+
+import (
+	"math/rand"
+	"runtime"
+
+	. "gopkg.in/check.v1"
+)
+
+type ArenaSuite struct{}
+
+var _ = Suite(&ArenaSuite{})
+
+func (s *ArenaSuite) TestAgainstHeap(c *C) {
+	r := rand.New(rand.NewSource(5))
+	// small chunks so elements and links cross many chunk boundaries
+	m := NewMap(compareInts, WithArena(8), WithHashIndex(hashInt, equalInts))
+	ref := NewMap(compareInts)
+	for round := 0; round < 5000; round++ {
+		k := r.Intn(500)
+		switch r.Intn(4) {
+		case 0:
+			m.Remove(k)
+			ref.Remove(k)
+		case 1:
+			m.PutOrMerge(k, 1, func(existing, incoming interface{}) interface{} {
+				return existing.(int) + incoming.(int)
+			})
+			ref.PutOrMerge(k, 1, func(existing, incoming interface{}) interface{} {
+				return existing.(int) + incoming.(int)
+			})
+		default:
+			m.Put(k, round)
+			ref.Put(k, round)
+		}
+		if round%1000 == 999 {
+			m.CompactArena()
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, ref.Entries())
+	for k := 0; k < 500; k++ {
+		v, ok := m.Get(k)
+		w, found := ref.Get(k)
+		c.Assert(ok, Equals, found)
+		c.Assert(v, Equals, w)
+	}
+}
+
+func (s *ArenaSuite) TestCompactArena(c *C) {
+	m := NewMap(compareInts, WithArena(16), WithHistory(2))
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 1000; i += 3 {
+		m.Remove(i)
+	}
+	m.Put(1, "one")
+	it := m.Iterator()
+	c.Assert(it.Next(), Equals, true)
+	c.Assert(it.Key(), Equals, 1)
+	want := m.Entries()
+	m.CompactArena()
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, want)
+	c.Assert(m.History(1), HasLen, 2)
+	// the iterator finds its place again in the new chunks
+	c.Assert(it.Next(), Equals, true)
+	c.Assert(it.Key(), Equals, 2)
+	it.Release()
+	m.Put(0, 0)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, len(want)+1)
+
+	// without an arena it does nothing
+	plain := fillMap(10)
+	plain.CompactArena()
+	c.Assert(plain.Validate(), IsNil)
+	c.Assert(plain.Len(), Equals, 10)
+}
+
+func (s *ArenaSuite) TestClear(c *C) {
+	m := NewMap(compareInts, WithArena(0))
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 5000; i++ {
+			m.Put(i, i)
+		}
+		c.Assert(m.Validate(), IsNil)
+		c.Assert(m.Len(), Equals, 5000)
+		if round == 1 {
+			m.ClearAndRecycle()
+		} else {
+			m.Clear()
+		}
+		c.Assert(m.Len(), Equals, 0)
+	}
+	left, right := fillMapWith(NewMap(compareInts, WithArena(4)), 100).Split(50)
+	left.Put(-1, -1)
+	right.Put(1000, 1000)
+	c.Assert(left.Validate(), IsNil)
+	c.Assert(right.Validate(), IsNil)
+	c.Assert(left.Len(), Equals, 51)
+	c.Assert(right.Len(), Equals, 51)
+}
+
+// fillMapWith puts the keys 0 through n-1 in m, each with double its key
+func fillMapWith(m *Map, n int) *Map {
+	for j := 0; j < n; j++ {
+		m.Put(j, j*2)
+	}
+	return m
+}
+
+const arenaBenchSize = 10000000
+
+func benchmarkLoad(c *C, opts ...Option) {
+	for i := 0; i < c.N; i++ {
+		fillMapWith(NewMap(compareInts, opts...), arenaBenchSize)
+	}
+}
+
+// benchmarkGC times a full collection with a loaded map live
+func benchmarkGC(c *C, opts ...Option) {
+	m := fillMapWith(NewMap(compareInts, opts...), arenaBenchSize)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		runtime.GC()
+	}
+	runtime.KeepAlive(m)
+}
+
+func (s *ArenaSuite) BenchmarkLoad10000000(c *C) {
+	benchmarkLoad(c)
+}
+
+func (s *ArenaSuite) BenchmarkLoadArena10000000(c *C) {
+	benchmarkLoad(c, WithArena(0))
+}
+
+func (s *ArenaSuite) BenchmarkGC10000000(c *C) {
+	benchmarkGC(c)
+}
+
+func (s *ArenaSuite) BenchmarkGCArena10000000(c *C) {
+	benchmarkGC(c, WithArena(0))
+}
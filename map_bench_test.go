@@ -98,3 +98,17 @@ func (s *MapBenchSuite) BenchmarkGetRand1000000(c *C) {
 func (s *MapBenchSuite) BenchmarkGetRand10000000(c *C) {
 	benchmarkGetNRand(10000000, c)
 }
+
+// BenchmarkPutGetRemove works the paths that search from the head
+// and relink it, with keys near the front as well as further in
+func (s *MapBenchSuite) BenchmarkPutGetRemove(c *C) {
+	m := fillMap(1000)
+	r := rand.New(rand.NewSource(77))
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		k := r.Intn(2000) - 500
+		m.Put(k, k)
+		m.Get(k + 1)
+		m.Remove(k)
+	}
+}
@@ -0,0 +1,261 @@
+package skiplist
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrOutOfOrder is returned when pairs that must come in key order
+// don't, or don't all come after the pairs already in the map
+var ErrOutOfOrder = errors.New("skiplist: keys out of order")
+
+// ErrLengthMismatch is returned when slices of keys and values that
+// go together aren't the same length
+var ErrLengthMismatch = errors.New("skiplist: keys and values differ in length")
+
+// chain is a run of elements linked up in order apart from any map,
+// built one element at a time by keeping the last element at each
+// level, and then spliced onto the end of a map in one step
+type chain struct {
+	head     []*mapElement
+	headSpan []int
+	tail     []*mapElement
+	tailRank []int
+	length   int
+}
+
+// newChain makes an empty chain with levels levels
+func newChain(levels int) *chain {
+	return &chain{
+		head:     make([]*mapElement, levels),
+		headSpan: make([]int, levels),
+		tail:     make([]*mapElement, levels),
+		tailRank: make([]int, levels),
+	}
+}
+
+// push links e onto the end of the chain
+func (c *chain) push(e *mapElement) {
+	c.length++
+	for level := range e.next {
+		if c.tail[level] == nil {
+			c.head[level] = e
+			c.headSpan[level] = c.length
+		} else {
+			c.tail[level].next[level] = e
+			c.tail[level].span[level] = c.length - c.tailRank[level]
+		}
+		c.tail[level] = e
+		c.tailRank[level] = c.length
+	}
+}
+
+// join links d onto the end of c, leaving d to be thrown away
+func (c *chain) join(d *chain) {
+	for level := range c.head {
+		if d.head[level] == nil {
+			continue
+		}
+		if c.tail[level] == nil {
+			c.head[level] = d.head[level]
+			c.headSpan[level] = c.length + d.headSpan[level]
+		} else {
+			c.tail[level].next[level] = d.head[level]
+			c.tail[level].span[level] = c.length - c.tailRank[level] + d.headSpan[level]
+		}
+		c.tail[level] = d.tail[level]
+		c.tailRank[level] = c.length + d.tailRank[level]
+	}
+	c.length += d.length
+}
+
+// last returns the last element of the chain, or nil if it is empty
+func (c *chain) last() *mapElement {
+	if c.length == 0 {
+		return nil
+	}
+	return c.tail[0]
+}
+
+// inOrder returns true if b may come straight after a in the map
+func (m *Map) inOrder(a, b interface{}) bool {
+	if m.multi {
+		return !m.comp(b, a)
+	}
+	return m.comp(a, b)
+}
+
+// splice links c onto the end of the map, returning ErrOutOfOrder,
+// and changing nothing, if it doesn't come after every key in the
+// map. the caller must hold the write lock
+func (m *Map) splice(c *chain) error {
+	if c.length == 0 {
+		return nil
+	}
+	// a tombstone at the end holds its place, get rid of it first
+	m.purge()
+	last := make([]*mapElement, m.maxLevels)
+	lastRank := make([]int, m.maxLevels)
+	pos := 0
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil; next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+		last[level] = prev
+		lastRank[level] = pos
+	}
+	if prev != m.head && !m.inOrder(prev.key, c.head[0].key) {
+		return ErrOutOfOrder
+	}
+	// a shrunk map may need levels back for its new length, which
+	// start at the head
+	m.regrow(m.length + c.length)
+	for level := len(last); level < m.maxLevels; level++ {
+		last = append(last, m.head)
+		lastRank = append(lastRank, 0)
+	}
+	// the chain may be taller than the map, or the map may have been
+	// shrunk since the chain was started
+	if len(c.head) > m.maxLevels {
+		for e := c.head[m.maxLevels]; e != nil; {
+			next := e.next[m.maxLevels]
+			cut(e, m.maxLevels)
+			e = next
+		}
+	}
+	for level := 0; level < len(c.head) && level < m.maxLevels; level++ {
+		if c.head[level] == nil {
+			continue
+		}
+		last[level].next[level] = c.head[level]
+		last[level].span[level] = m.length - lastRank[level] + c.headSpan[level]
+	}
+	m.length += c.length
+	for e := c.head[0]; e != nil; e = e.next[0] {
+		m.added(e)
+	}
+	return nil
+}
+
+// BulkLoadChan reads pairs from ch until it is closed and adds them to
+// the end of the map, linking each in at the running tail of every
+// level in constant time rather than searching for its place. the keys
+// must come in ascending order, strictly so unless the map is a
+// multimap, and after every key already in the map. the pairs are
+// linked up apart from the map and spliced on under the write lock
+// once ch is closed, so the map is usable while ch is read. if a key is
+// out of order it returns ErrOutOfOrder, leaving the map unchanged and
+// the rest of ch unread. a persister is not told of the pairs.
+// it panics if a key is nil
+func (m *Map) BulkLoadChan(ch <-chan Pair) error {
+	defer m.endOp("bulkloadchan", nil, m.startOp())
+	m.checkMade()
+	// the map may regrow while ch is read, but never past its ceiling
+	c := newChain(m.ceiling)
+	for p := range ch {
+		k := m.normal(p.Key)
+		checkKey(k)
+		if last := c.last(); last != nil && !m.inOrder(last.key, k) {
+			return ErrOutOfOrder
+		}
+		c.push(newMapElement(k, p.Val, randomLevelsUnder(m, m.ceiling)))
+	}
+	m.lock()
+	defer m.mutex.Unlock()
+	return m.splice(c)
+}
+
+// FromSortedSlice creates a new map, ordered by less, holding the pair
+// keys[i], vals[i] for every i. the keys must be in strictly ascending
+// order by less, which is checked as they are linked in at the tail of
+// every level, so the map is built in O(n) rather than put pair by
+// pair. it returns ErrLengthMismatch if the slices differ in length,
+// ErrNilKey if a key is nil and ErrOutOfOrder if the keys aren't sorted
+func FromSortedSlice(less func(a, b interface{}) bool, keys, vals []interface{}) (*Map, error) {
+	if len(keys) != len(vals) {
+		return nil, ErrLengthMismatch
+	}
+	m := NewMap(less)
+	c := newChain(m.maxLevels)
+	for i, k := range keys {
+		if k == nil {
+			return nil, ErrNilKey
+		}
+		if i > 0 && !less(keys[i-1], k) {
+			return nil, ErrOutOfOrder
+		}
+		c.push(newMapElement(k, vals[i], randomLevels(m)))
+	}
+	if err := m.splice(c); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ParallelBulkLoad adds the pairs keys[i], vals[i] to the end of the
+// map like BulkLoadChan, splitting them into workers contiguous runs,
+// or one per CPU if workers isn't positive, that are linked up at the
+// same time, then joined and spliced on under the write lock in one
+// step. the keys must be in ascending order, strictly so unless the map
+// is a multimap, and after every key already in the map. it returns
+// ErrLengthMismatch if the slices differ in length, ErrNilKey if a key
+// is nil and ErrOutOfOrder if a key is out of order, leaving the map
+// unchanged. a persister is not told of the pairs
+func (m *Map) ParallelBulkLoad(keys, vals []interface{}, workers int) error {
+	defer m.endOp("parallelbulkload", nil, m.startOp())
+	m.checkMade()
+	if len(keys) != len(vals) {
+		return ErrLengthMismatch
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+	// the map may regrow while the runs are linked, but never past its
+	// ceiling, and splice cuts the towers down to what it needs
+	levels := m.ceiling
+	chains := make([]*chain, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			chains[w], errs[w] = m.loadRun(keys, vals, levels, w*len(keys)/workers, (w+1)*len(keys)/workers)
+		}(w)
+	}
+	wg.Wait()
+	all := newChain(levels)
+	for w, c := range chains {
+		if errs[w] != nil {
+			return errs[w]
+		}
+		all.join(c)
+	}
+	m.lock()
+	defer m.mutex.Unlock()
+	return m.splice(all)
+}
+
+// loadRun links up the pairs from position lo up to hi for
+// ParallelBulkLoad, checking each key against the one before it,
+// which for the first may be in the run before
+func (m *Map) loadRun(keys, vals []interface{}, levels, lo, hi int) (*chain, error) {
+	c := newChain(levels)
+	for i := lo; i < hi; i++ {
+		if keys[i] == nil {
+			return nil, ErrNilKey
+		}
+		k := m.normal(keys[i])
+		if i > 0 && keys[i-1] != nil && !m.inOrder(m.normal(keys[i-1]), k) {
+			return nil, ErrOutOfOrder
+		}
+		c.push(newMapElement(k, vals[i], randomLevelsUnder(m, levels)))
+	}
+	return c, nil
+}
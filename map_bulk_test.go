@@ -0,0 +1,199 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type BulkSuite struct{}
+
+var _ = Suite(&BulkSuite{})
+
+// feed sends pairs for keys down a channel and closes it
+func feed(keys ...int) <-chan Pair {
+	ch := make(chan Pair)
+	go func() {
+		for _, k := range keys {
+			ch <- Pair{k, k * 2}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+func (s *BulkSuite) TestBulkLoadChan(c *C) {
+	keys := []int{}
+	for i := 0; i < 5000; i += 3 {
+		keys = append(keys, i)
+	}
+	m := NewMap(compareInts)
+	c.Assert(m.BulkLoadChan(feed(keys...)), IsNil)
+	ref := NewMap(compareInts)
+	for _, k := range keys {
+		ref.Put(k, k*2)
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, ref.Entries())
+	c.Assert(m.Len(), Equals, len(keys))
+	v, ok := m.Get(2997)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 5994)
+	_, ok = m.Get(2998)
+	c.Assert(ok, Equals, false)
+
+	// more can go on the end, and the map still works as usual
+	c.Assert(m.BulkLoadChan(feed(6000, 6001)), IsNil)
+	m.Put(1, 1)
+	m.Remove(3)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, len(keys)+2)
+	c.Assert(m.BulkLoadChan(feed()), IsNil)
+}
+
+func (s *BulkSuite) TestBulkLoadChanOutOfOrder(c *C) {
+	m := NewMap(compareInts)
+	m.Put(10, 0)
+	c.Assert(m.BulkLoadChan(feed(1, 2)), Equals, ErrOutOfOrder)
+	ch := make(chan Pair, 3)
+	ch <- Pair{20, 0}
+	ch <- Pair{20, 0}
+	ch <- Pair{30, 0}
+	close(ch)
+	c.Assert(m.BulkLoadChan(ch), Equals, ErrOutOfOrder)
+	// the rest of the channel is left
+	c.Assert(len(ch), Equals, 1)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{10})
+	c.Assert(m.Validate(), IsNil)
+
+	// equal keys are in order in a multimap
+	multi := NewMultiMap(compareInts)
+	c.Assert(multi.BulkLoadChan(feed(1, 1, 2)), IsNil)
+	c.Assert(multi.BulkLoadChan(feed(2, 3)), IsNil)
+	c.Assert(multi.Keys(), DeepEquals, []interface{}{1, 1, 2, 2, 3})
+	c.Assert(multi.Validate(), IsNil)
+}
+
+func (s *BulkSuite) TestFromSortedSlice(c *C) {
+	keys, vals := []interface{}{}, []interface{}{}
+	ref := NewMap(compareInts)
+	for i := 0; i < 3000; i += 7 {
+		keys = append(keys, i)
+		vals = append(vals, i*2)
+		ref.Put(i, i*2)
+	}
+	m, err := FromSortedSlice(compareInts, keys, vals)
+	c.Assert(err, IsNil)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, ref.Entries())
+	v, ok := m.Get(2996)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 5992)
+	m.Put(3, 3)
+	m.Remove(7)
+	c.Assert(m.Validate(), IsNil)
+
+	m, err = FromSortedSlice(compareInts, nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(m.Len(), Equals, 0)
+	m.Put(1, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *BulkSuite) TestFromSortedSliceErrors(c *C) {
+	_, err := FromSortedSlice(compareInts, []interface{}{1, 2}, []interface{}{1})
+	c.Assert(err, Equals, ErrLengthMismatch)
+	_, err = FromSortedSlice(compareInts, []interface{}{1, 3, 2}, []interface{}{1, 3, 2})
+	c.Assert(err, Equals, ErrOutOfOrder)
+	// equal keys are out of order too
+	_, err = FromSortedSlice(compareInts, []interface{}{1, 2, 2}, []interface{}{1, 2, 2})
+	c.Assert(err, Equals, ErrOutOfOrder)
+	_, err = FromSortedSlice(compareInts, []interface{}{1, nil}, []interface{}{1, 2})
+	c.Assert(err, Equals, ErrNilKey)
+}
+
+// sortedPairs returns keys 0, 3, 6 and so on, n of them, with
+// values double their keys
+func sortedPairs(n int) ([]interface{}, []interface{}) {
+	keys, vals := make([]interface{}, n), make([]interface{}, n)
+	for i := range keys {
+		keys[i], vals[i] = i*3, i*6
+	}
+	return keys, vals
+}
+
+func (s *BulkSuite) TestParallelBulkLoad(c *C) {
+	keys, vals := sortedPairs(10000)
+	ref := NewMap(compareInts)
+	for i, k := range keys {
+		ref.Put(k, vals[i])
+	}
+	for _, workers := range []int{0, 1, 3, 8, 20000} {
+		m := NewMap(compareInts)
+		m.Put(-1, -1)
+		c.Assert(m.ParallelBulkLoad(keys, vals, workers), IsNil)
+		c.Assert(m.Validate(), IsNil)
+		m.Remove(-1)
+		c.Assert(m.Entries(), DeepEquals, ref.Entries())
+		v, _ := m.Get(2997)
+		c.Assert(v, Equals, 5994)
+		m.Put(1, 1)
+		c.Assert(m.Validate(), IsNil)
+	}
+	m := NewMap(compareInts)
+	c.Assert(m.ParallelBulkLoad(nil, nil, 4), IsNil)
+	c.Assert(m.Len(), Equals, 0)
+}
+
+func (s *BulkSuite) TestParallelBulkLoadErrors(c *C) {
+	m := NewMap(compareInts)
+	c.Assert(m.ParallelBulkLoad([]interface{}{1}, nil, 2), Equals, ErrLengthMismatch)
+	keys, vals := sortedPairs(100)
+	// out of order where two runs meet
+	keys[50], keys[49] = keys[49], keys[50]
+	c.Assert(m.ParallelBulkLoad(keys, vals, 2), Equals, ErrOutOfOrder)
+	keys, _ = sortedPairs(100)
+	keys[50] = nil
+	c.Assert(m.ParallelBulkLoad(keys, vals, 2), Equals, ErrNilKey)
+	c.Assert(m.Len(), Equals, 0)
+	m.Put(1000, 0)
+	keys, _ = sortedPairs(100)
+	c.Assert(m.ParallelBulkLoad(keys, vals, 2), Equals, ErrOutOfOrder)
+	c.Assert(m.Len(), Equals, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *BulkSuite) TestSpliceAfterShrink(c *C) {
+	m := NewMap(compareInts)
+	m.Put(0, 0)
+	keys, vals := sortedPairs(1000)
+	c.Assert(m.ParallelBulkLoad(keys[1:], vals[1:], 2), IsNil)
+	// a chain started before a shrink may have taller towers
+	ch := newChain(maxHeight)
+	for i := 1000; i < 1100; i++ {
+		ch.push(newMapElement(i*3, i, 1+i%maxHeight))
+	}
+	m.ShrinkLevels()
+	m.lock()
+	err := m.splice(ch)
+	m.mutex.Unlock()
+	c.Assert(err, IsNil)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 1100)
+	m.Put(1, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func benchmarkBulkLoad(c *C, workers int) {
+	keys, vals := sortedPairs(1000000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		NewMap(compareInts).ParallelBulkLoad(keys, vals, workers)
+	}
+}
+
+func (s *BulkSuite) BenchmarkBulkLoadSerial1000000(c *C) {
+	benchmarkBulkLoad(c, 1)
+}
+
+func (s *BulkSuite) BenchmarkParallelBulkLoad1000000(c *C) {
+	benchmarkBulkLoad(c, 0)
+}
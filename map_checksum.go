@@ -0,0 +1,98 @@
+package skiplist
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+)
+
+// Checksum returns an FNV-1a hash of every pair in key order, using
+// encode to turn keys and values into bytes. maps with the same pairs
+// give the same checksum however they were built, so it is a cheap
+// way to compare maps across the network. encode is called under the
+// read lock, so it must not use the map
+func (m *Map) Checksum(encode func(interface{}) []byte) uint64 {
+	defer m.endOp("checksum", nil, m.startOp())
+	h := fnv.New64a()
+	var size [binary.MaxVarintLen64]byte
+	// each encoding is prefixed with its length so
+	// that ("ab", "c") and ("a", "bc") differ
+	write := func(b []byte) {
+		h.Write(size[:binary.PutUvarint(size[:], uint64(len(b)))])
+		h.Write(b)
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		write(encode(e.key))
+		write(encode(e.val))
+	}
+	return h.Sum64()
+}
+
+// Fingerprint returns an FNV-1a hash of every pair in key order, with
+// hashKV writing each pair into the hash. it is Checksum for callers
+// who would rather stream pairs into the hash than build byte slices.
+// hashKV must not use the map either
+func (m *Map) Fingerprint(hashKV func(h io.Writer, k, v interface{})) uint64 {
+	defer m.endOp("fingerprint", nil, m.startOp())
+	h := fnv.New64a()
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		hashKV(h, e.key, e.val)
+	}
+	return h.Sum64()
+}
+
+// fingerprint is the running fingerprint kept by
+// WithIncrementalFingerprint
+type fingerprint struct {
+	hashKV func(h io.Writer, k, v interface{})
+	sum    uint64
+}
+
+// WithIncrementalFingerprint keeps a fingerprint of the map up to date
+// as it changes, so IncrementalFingerprint is constant time. each pair
+// is hashed on its own by hashKV and the hashes XORed together, which
+// makes the fingerprint independent of order, but weaker than
+// Fingerprint: in a multimap the same pair put twice cancels out
+func WithIncrementalFingerprint(hashKV func(h io.Writer, k, v interface{})) Option {
+	return func(m *Map) {
+		m.digest = &fingerprint{hashKV: hashKV}
+	}
+}
+
+// pair returns the hash of one pair
+func (f *fingerprint) pair(k, v interface{}) uint64 {
+	h := fnv.New64a()
+	f.hashKV(h, k, v)
+	return h.Sum64()
+}
+
+// change folds a change into the fingerprint, taking out the old
+// pair and putting in the new one
+func (f *fingerprint) change(op ChangeOp, k, old, new interface{}) {
+	if op != OpInsert {
+		f.sum ^= f.pair(k, old)
+	}
+	if op != OpDelete {
+		f.sum ^= f.pair(k, new)
+	}
+}
+
+// IncrementalFingerprint returns the fingerprint kept by
+// WithIncrementalFingerprint, or 0 if the map doesn't keep one
+func (m *Map) IncrementalFingerprint() uint64 {
+	var ret uint64
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if m.digest != nil {
+		ret = m.digest.sum
+	}
+	return ret
+}
@@ -0,0 +1,101 @@
+package skiplist
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type ChecksumSuite struct{}
+
+var _ = Suite(&ChecksumSuite{})
+
+func encodeAny(x interface{}) []byte { return []byte(fmt.Sprint(x)) }
+
+func (s *ChecksumSuite) TestChecksumEqualMaps(c *C) {
+	a := fillMap(1000)
+	b := NewMap(compareInts)
+	for i := 999; i >= 0; i-- {
+		b.Put(i, i*2)
+	}
+	c.Assert(a.Checksum(encodeAny), Equals, b.Checksum(encodeAny))
+	c.Assert(NewMap(compareInts).Checksum(encodeAny), Equals, NewMap(compareInts).Checksum(encodeAny))
+}
+
+func (s *ChecksumSuite) TestChecksumChangedValue(c *C) {
+	a := fillMap(1000)
+	b := fillMap(1000)
+	b.Put(500, 0)
+	c.Assert(a.Checksum(encodeAny), Not(Equals), b.Checksum(encodeAny))
+	b.Put(500, 1000)
+	c.Assert(a.Checksum(encodeAny), Equals, b.Checksum(encodeAny))
+	b.Remove(999)
+	c.Assert(a.Checksum(encodeAny), Not(Equals), b.Checksum(encodeAny))
+}
+
+func (s *ChecksumSuite) TestChecksumBoundaries(c *C) {
+	a := NewMap(compareStrings)
+	a.Put("ab", "c")
+	b := NewMap(compareStrings)
+	b.Put("a", "bc")
+	c.Assert(a.Checksum(encodeAny), Not(Equals), b.Checksum(encodeAny))
+}
+
+func hashPair(h io.Writer, k, v interface{}) {
+	fmt.Fprintf(h, "%v=%v;", k, v)
+}
+
+func (s *ChecksumSuite) TestFingerprint(c *C) {
+	a := NewMap(compareInts)
+	b := NewMap(compareInts)
+	for i := 0; i < 100; i++ {
+		a.Put(i, i)
+		b.Put(99-i, 99-i)
+	}
+	c.Assert(a.Fingerprint(hashPair), Equals, b.Fingerprint(hashPair))
+	before := a.Fingerprint(hashPair)
+	a.Put(5, 6)
+	c.Assert(a.Fingerprint(hashPair), Not(Equals), before)
+	a.Put(5, 5)
+	c.Assert(a.Fingerprint(hashPair), Equals, before)
+}
+
+func (s *ChecksumSuite) TestIncrementalFingerprint(c *C) {
+	a := NewMap(compareInts, WithIncrementalFingerprint(hashPair))
+	b := NewMap(compareInts, WithIncrementalFingerprint(hashPair))
+	c.Assert(a.IncrementalFingerprint(), Equals, uint64(0))
+	r := rand.New(rand.NewSource(1))
+	keys := r.Perm(200)
+	for _, k := range keys {
+		a.Put(k, k)
+	}
+	for i := range keys {
+		b.Put(keys[len(keys)-1-i], keys[len(keys)-1-i])
+	}
+	c.Assert(a.IncrementalFingerprint(), Equals, b.IncrementalFingerprint())
+	// every single change moves the fingerprint
+	seen := map[uint64]bool{a.IncrementalFingerprint(): true}
+	for _, fn := range []func(){
+		func() { a.Put(7, 8) },
+		func() { a.Put(1000, 1) },
+		func() { a.Remove(3) },
+		func() { a.PopMinN(1) },
+		func() { a.SwapValues(10, 11) },
+	} {
+		fn()
+		fp := a.IncrementalFingerprint()
+		c.Assert(seen[fp], Equals, false)
+		seen[fp] = true
+	}
+	// and it always agrees with a map built from scratch
+	fresh := NewMap(compareInts, WithIncrementalFingerprint(hashPair))
+	for _, e := range a.Entries() {
+		fresh.Put(e.Key, e.Val)
+	}
+	c.Assert(a.IncrementalFingerprint(), Equals, fresh.IncrementalFingerprint())
+	a.Clear()
+	c.Assert(a.IncrementalFingerprint(), Equals, uint64(0))
+	c.Assert(NewMap(compareInts).IncrementalFingerprint(), Equals, uint64(0))
+}
@@ -0,0 +1,133 @@
+package skiplist
+
+// Clear removes every pair from the map, leaving the elements
+// for the garbage collector
+func (m *Map) Clear() {
+	defer m.endOp("clear", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	if m.watched() {
+		for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+			m.notify(OpDelete, e.key, e.val, nil)
+		}
+	}
+	m.reset()
+}
+
+// ClearAndRecycle removes every pair from the map like Clear, but
+// walks the elements clearing their references and returning them to
+// a pool that later Puts allocate from, which cuts allocation for maps
+// that are cleared and refilled over and over. a map made WithArena
+// drops its chunks instead, as Clear does
+func (m *Map) ClearAndRecycle() {
+	defer m.endOp("clearandrecycle", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	e := m.head.next[0]
+	for e != nil {
+		next := e.next[0]
+		if !e.deleted && m.watched() {
+			m.notify(OpDelete, e.key, e.val, nil)
+		}
+		e.key, e.val, e.past = nil, nil, nil
+		for level := range e.next {
+			e.next[level] = nil
+			e.span[level] = 0
+		}
+		// arena elements go when their chunk does
+		if m.arena == nil {
+			elementPool.Put(e)
+		}
+		e = next
+	}
+	m.reset()
+}
+
+// Drain empties the map and then calls fn for each of the pairs it
+// held, in order, stopping early if fn returns false, the rest being
+// dropped. the pairs are cut loose from the map under the write lock in
+// one step, and fn runs after the lock is let go, so other goroutines,
+// and fn itself, can use the emptied map while fn works through them
+func (m *Map) Drain(fn func(k, v interface{}) bool) {
+	defer m.endOp("drain", nil, m.startOp())
+	for e := m.detach(); e != nil; e = liveFrom(e.next[0]) {
+		if !fn(e.key, e.val) {
+			break
+		}
+	}
+}
+
+// detach empties the map under the write lock like Clear, returning
+// the first live element, which is still linked to the rest
+func (m *Map) detach() *mapElement {
+	m.lock()
+	defer m.mutex.Unlock()
+	e := liveFrom(m.head.next[0])
+	if m.watched() {
+		for x := e; x != nil; x = liveFrom(x.next[0]) {
+			m.notify(OpDelete, x.key, x.val, nil)
+		}
+	}
+	// reset leaves the elements linked to each other
+	m.reset()
+	return e
+}
+
+// reset empties the map, the caller must hold the write lock
+func (m *Map) reset() {
+	if m.wal != nil {
+		m.wal.write(walClear)
+	}
+	for level := range m.head.next {
+		m.head.next[level] = nil
+		m.head.span[level] = 0
+	}
+	m.length = 0
+	m.version++
+	if m.digest != nil {
+		m.digest.sum = 0
+	}
+	if m.cost != nil {
+		m.cost.total = 0
+	}
+	if m.index != nil {
+		m.index.clear()
+	}
+	if m.arena != nil {
+		m.arena = newArena(m.arena.chunk)
+	}
+}
+
+// CompactIf removes every pair whose value isTombstone returns true
+// for, in one pass over the map, and returns how many it removed
+func (m *Map) CompactIf(isTombstone func(v interface{}) bool) int {
+	defer m.endOp("compactif", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	return m.removeIf(func(e *mapElement) bool { return !e.deleted && isTombstone(e.val) })
+}
+
+// removeIf unlinks every element drop returns true for, walking level 0
+// once, and returns how many it unlinked. the caller must hold the
+// write lock
+func (m *Map) removeIf(drop func(e *mapElement) bool) int {
+	n := 0
+	// backPointer holds the last element kept at each level
+	var backPointer [maxHeight]*mapElement
+	for level := 0; level < m.maxLevels; level++ {
+		backPointer[level] = m.head
+	}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		if !drop(e) {
+			for level := 0; level < len(e.next); level++ {
+				backPointer[level] = e
+			}
+			continue
+		}
+		m.unlink(e, backPointer[:])
+		n++
+	}
+	return n
+}
@@ -0,0 +1,144 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ClearSuite struct{}
+
+var _ = Suite(&ClearSuite{})
+
+func (s *ClearSuite) TestClear(c *C) {
+	m := fillMap(1000)
+	m.Clear()
+	c.Assert(m.Len(), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+	_, ok := m.Get(1)
+	c.Assert(ok, Equals, false)
+	m.Put(1, 1)
+	c.Assert(m.Len(), Equals, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ClearSuite) TestClearAndRecycle(c *C) {
+	m := fillMap(1000)
+	for round := 0; round < 3; round++ {
+		m.ClearAndRecycle()
+		c.Assert(m.Len(), Equals, 0)
+		c.Assert(m.Validate(), IsNil)
+		for i := 0; i < 1000; i++ {
+			m.Put(i, i+round)
+		}
+		c.Assert(m.Validate(), IsNil)
+		for i := 0; i < 1000; i++ {
+			x, ok := m.Get(i)
+			c.Assert(ok, Equals, true)
+			c.Assert(x, Equals, i+round)
+		}
+	}
+}
+
+func (s *ClearSuite) TestClearWatched(c *C) {
+	m := fillMap(3)
+	ch, cancel := m.Watch(10)
+	defer cancel()
+	m.Clear()
+	c.Assert(drain(ch), DeepEquals, []ChangeEvent{
+		{OpDelete, 0, 0, nil}, {OpDelete, 1, 2, nil}, {OpDelete, 2, 4, nil},
+	})
+	m.Put(5, 5)
+	m.ClearAndRecycle()
+	c.Assert(drain(ch), DeepEquals, []ChangeEvent{{OpInsert, 5, nil, 5}, {OpDelete, 5, 5, nil}})
+}
+
+func (s *ClearSuite) TestDrain(c *C) {
+	m := fillMap(1000)
+	events, cancel := m.Watch(2000)
+	defer cancel()
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			m.Put(-1-i, i)
+		}
+		done <- true
+	}()
+	drained := []interface{}{}
+	m.Drain(func(k, v interface{}) bool {
+		drained = append(drained, k)
+		// the map is already empty and open for writes
+		if k.(int)%10 == 0 {
+			m.Put(k.(int)+10000, v)
+		}
+		return true
+	})
+	<-done
+	c.Assert(drained, DeepEquals, fillMap(1000).Keys())
+	c.Assert(m.Len(), Equals, 200)
+	c.Assert(m.Count(5), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+	deleted := 0
+	for len(events) > 0 {
+		if (<-events).Op == OpDelete {
+			deleted++
+		}
+	}
+	c.Assert(deleted, Equals, 1000)
+}
+
+func (s *ClearSuite) TestDrainStop(c *C) {
+	m := fillMap(100)
+	n := 0
+	m.Drain(func(k, v interface{}) bool {
+		c.Assert(k, Equals, n)
+		n++
+		return n < 5
+	})
+	c.Assert(n, Equals, 5)
+	// the rest went with the map
+	c.Assert(m.Len(), Equals, 0)
+	m.Drain(func(k, v interface{}) bool {
+		c.Fail()
+		return true
+	})
+}
+
+func benchmarkRefill(c *C, clear func(m *Map)) {
+	m := fillMap(10000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		clear(m)
+		for j := 0; j < 10000; j++ {
+			m.Put(j, j)
+		}
+	}
+}
+
+func (s *ClearSuite) BenchmarkClearRefill(c *C) {
+	benchmarkRefill(c, (*Map).Clear)
+}
+
+func (s *ClearSuite) BenchmarkClearAndRecycleRefill(c *C) {
+	benchmarkRefill(c, (*Map).ClearAndRecycle)
+}
+
+func (s *ClearSuite) TestCompactIf(c *C) {
+	m := fillMapRand(1000)
+	want := []Entry{}
+	i := 0
+	for _, e := range m.Entries() {
+		// mark every other pair deleted
+		if i%2 == 0 {
+			m.Put(e.Key, nil)
+		} else {
+			want = append(want, e)
+		}
+		i++
+	}
+	c.Assert(m.CompactIf(func(v interface{}) bool { return v == nil }), Equals, 500)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, want)
+	c.Assert(m.CompactIf(func(v interface{}) bool { return v == nil }), Equals, 0)
+	c.Assert(m.CompactIf(func(v interface{}) bool { return true }), Equals, 500)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 0)
+}
@@ -0,0 +1,55 @@
+package skiplist
+
+// CloneIncremental creates a new independent map like the map, copying
+// batch pairs at a time under the read lock and letting it go between
+// batches, so writers wait for one batch rather than the whole copy.
+// each batch picks up after the last key copied. the clone holds every
+// key as it was when its batch was copied: a change behind the copy is
+// missed and one ahead of it is seen, so the clone may never have
+// matched the map as a whole, but each of its pairs is one the map held
+// at some point during the copy, and the clone is always a sound map.
+// the pairs for one key in a multimap are never split between batches.
+// a batch smaller than 1 is taken as 1
+func (m *Map) CloneIncremental(batch int) *Map {
+	defer m.endOp("cloneincremental", nil, m.startOp())
+	if batch < 1 {
+		batch = 1
+	}
+	ret := m.cloneStart()
+	// the clone isn't shared until it is returned, so it needs no lock
+	c := newChain(ret.maxLevels)
+	for done := false; !done; {
+		done = m.cloneBatch(ret, c, batch)
+	}
+	ret.splice(c)
+	return ret
+}
+
+// cloneStart makes the empty clone under the read lock
+func (m *Map) cloneStart() *Map {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.emptyLike()
+}
+
+// cloneBatch pushes up to batch pairs after the last one in c onto c,
+// with heights drawn for ret, and returns true once it reaches the end
+func (m *Map) cloneBatch(ret *Map, c *chain, batch int) bool {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := m.head.next[0]
+	if last := c.last(); last != nil {
+		e = m.upperBound(last.key, nil)
+	}
+	for n := 0; e != nil; e = e.next[0] {
+		if e.deleted {
+			continue
+		}
+		if n >= batch && (!m.multi || m.comp(c.last().key, e.key)) {
+			return false
+		}
+		c.push(newMapElement(e.key, e.val, randomLevels(ret)))
+		n++
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package skiplist
+
+import (
+	"math/rand"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type CloneSuite struct{}
+
+var _ = Suite(&CloneSuite{})
+
+func (s *CloneSuite) TestCloneIncremental(c *C) {
+	m := fillMapRand(1000)
+	m.Remove(m.Keys()[10])
+	for _, batch := range []int{0, 1, 7, 1000, 5000} {
+		clone := m.CloneIncremental(batch)
+		c.Assert(clone.Validate(), IsNil)
+		c.Assert(clone.Entries(), DeepEquals, m.Entries())
+		// the clone is independent of the map
+		clone.Put(-1, -1)
+		_, ok := m.Get(-1)
+		c.Assert(ok, Equals, false)
+	}
+	c.Assert(NewMap(compareInts).CloneIncremental(10).Len(), Equals, 0)
+}
+
+func (s *CloneSuite) TestCloneIncrementalMulti(c *C) {
+	m := NewMultiMap(compareInts)
+	for i := 0; i < 50; i++ {
+		for j := 0; j < i%4; j++ {
+			m.Put(i, j)
+		}
+	}
+	clone := m.CloneIncremental(2)
+	c.Assert(clone.Validate(), IsNil)
+	c.Assert(clone.Entries(), DeepEquals, m.Entries())
+}
+
+func (s *CloneSuite) TestCloneIncrementalWithWriters(c *C) {
+	const keys = 2000
+	m := NewMap(compareInts, WithJournal(1<<20))
+	for i := 0; i < keys; i++ {
+		m.Put(i, -1)
+	}
+	before := m.Entries()
+	_, start, _ := m.ChangesSince(0)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				k := r.Intn(keys + 200)
+				if r.Intn(3) == 0 {
+					m.Remove(k)
+				} else {
+					m.Put(k, w*1000000+i)
+				}
+			}
+		}(w)
+	}
+	clone := m.CloneIncremental(16)
+	close(stop)
+	wg.Wait()
+	c.Assert(clone.Validate(), IsNil)
+
+	// every key in the clone, or missing from it, matches a state the
+	// key was in at some point while the clone was made
+	changes, _, ok := m.ChangesSince(start)
+	c.Assert(ok, Equals, true)
+	held := map[interface{}][]interface{}{}
+	absent := map[interface{}]bool{}
+	for k := 0; k < keys+200; k++ {
+		absent[k] = k >= keys
+	}
+	for _, e := range before {
+		held[e.Key] = append(held[e.Key], e.Val)
+	}
+	for _, ch := range changes {
+		if ch.Op == OpDelete {
+			absent[ch.Key] = true
+		} else {
+			held[ch.Key] = append(held[ch.Key], ch.New)
+		}
+	}
+	for k := 0; k < keys+200; k++ {
+		v, ok := clone.Get(k)
+		if !ok {
+			c.Assert(absent[k], Equals, true, Commentf("key %d", k))
+			continue
+		}
+		found := false
+		for _, x := range held[k] {
+			found = found || x == v
+		}
+		c.Assert(found, Equals, true, Commentf("key %d value %v", k, v))
+	}
+}
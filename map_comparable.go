@@ -0,0 +1,66 @@
+package skiplist
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Comparable is implemented by keys that know how to order
+// themselves. Compare returns a negative number if the key is less
+// than other, 0 if they are equal and a positive number if it is
+// greater. other is always a key of the same type
+type Comparable interface {
+	Compare(other interface{}) int
+}
+
+// compareKeys compares two Comparable keys, panicking with
+// the types involved if they can't be compared
+func compareKeys(a, b interface{}) int {
+	ca, ok := a.(Comparable)
+	if !ok {
+		panic(fmt.Sprintf("skiplist: key of type %T is not Comparable", a))
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		panic(fmt.Sprintf("skiplist: can't compare keys of types %T and %T", a, b))
+	}
+	return ca.Compare(b)
+}
+
+// NewComparableMap creates a new empty map for keys implementing
+// Comparable, taking ordering and equality from their Compare methods.
+// a lookup compares the key it stops at once, rather than calling less
+// both ways round to tell if it is equal. putting a key that isn't
+// Comparable, or of a different type to the keys already in the map,
+// panics
+func NewComparableMap(opts ...Option) *Map {
+	m := NewMap(func(a, b interface{}) bool {
+		return compareKeys(a, b) < 0
+	}, opts...)
+	m.compare = compareKeys
+	return m
+}
+
+// findCompare is find for a map with a three way compare: it keeps the
+// result of the comparison that ended the search at level 0, which
+// already says whether the element found is equal to k
+func (m *Map) findCompare(k interface{}, backPointer []*mapElement) *mapElement {
+	prev := m.head
+	c := 1
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		e := prev.next[level]
+		for e != nil {
+			if c = m.compare(e.key, k); c >= 0 {
+				break
+			}
+			prev = e
+			e = e.next[level]
+		}
+		if backPointer != nil {
+			backPointer[level] = prev
+		}
+	}
+	if e := prev.next[0]; e != nil && c == 0 && !e.deleted {
+		return e
+	}
+	return nil
+}
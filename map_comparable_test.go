@@ -0,0 +1,105 @@
+package skiplist
+
+import (
+	"math/big"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ComparableSuite struct{}
+
+var _ = Suite(&ComparableSuite{})
+
+// instant is a Comparable key wrapping a time
+type instant struct {
+	t time.Time
+}
+
+func (i instant) Compare(other interface{}) int {
+	return i.t.Compare(other.(instant).t)
+}
+
+// bigKey is a Comparable key backed by a big.Int
+type bigKey struct {
+	n *big.Int
+}
+
+func (b bigKey) Compare(other interface{}) int {
+	return b.n.Cmp(other.(bigKey).n)
+}
+
+// countingKey counts the calls to Compare made on it
+type countingKey struct {
+	n     int
+	calls *int
+}
+
+func (k countingKey) Compare(other interface{}) int {
+	*k.calls++
+	return k.n - other.(countingKey).n
+}
+
+func (s *ComparableSuite) TestTimeKeys(c *C) {
+	m := NewComparableMap()
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, h := range []int{5, 1, 3} {
+		m.Put(instant{base.Add(time.Duration(h) * time.Hour)}, h)
+	}
+	// the same instant in another zone is the same key
+	nyc := time.FixedZone("EST", -5*3600)
+	v, ok := m.Get(instant{base.Add(3 * time.Hour).In(nyc)})
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 3)
+	c.Assert(m.Put(instant{base.Add(time.Hour).In(nyc)}, 10), Equals, true)
+	vals := []interface{}{}
+	m.ForEach(func(k, v interface{}) { vals = append(vals, v) })
+	c.Assert(vals, DeepEquals, []interface{}{10, 3, 5})
+	c.Assert(m.Remove(instant{base}), Equals, false)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ComparableSuite) TestBigIntKeys(c *C) {
+	m := NewComparableMap()
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+	m.Put(bigKey{huge}, "huge")
+	m.Put(bigKey{big.NewInt(-7)}, "neg")
+	m.Put(bigKey{new(big.Int).Neg(huge)}, "tiny")
+	// a different big.Int with the same value is the same key
+	v, ok := m.Get(bigKey{new(big.Int).Lsh(big.NewInt(2), 99)})
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "huge")
+	k, _, _ := m.First()
+	c.Assert(k.(bigKey).n.Sign(), Equals, -1)
+	c.Assert(m.Len(), Equals, 3)
+}
+
+func (s *ComparableSuite) TestOneCompareToMatch(c *C) {
+	calls := 0
+	m := NewComparableMap()
+	// the same keys put in the same order give the same levels
+	plain := NewMap(func(a, b interface{}) bool { return compareKeys(a, b) < 0 })
+	for i := 0; i < 1000; i++ {
+		m.Put(countingKey{i, &calls}, i)
+		plain.Put(countingKey{i, &calls}, i)
+	}
+	for i := 0; i < 1000; i++ {
+		calls = 0
+		m.Get(countingKey{i, &calls})
+		compared := calls
+		calls = 0
+		plain.Get(countingKey{i, &calls})
+		// less has to be called the other way round once more
+		c.Assert(compared, Equals, calls-1)
+	}
+}
+
+func (s *ComparableSuite) TestMixedTypes(c *C) {
+	m := NewComparableMap()
+	m.Put(bigKey{big.NewInt(1)}, 1)
+	c.Assert(func() { m.Put(instant{time.Now()}, 2) }, PanicMatches,
+		"skiplist: can't compare keys of types skiplist.bigKey and skiplist.instant")
+	m = NewComparableMap()
+	m.Put(1, 1)
+	c.Assert(func() { m.Put(2, 2) }, PanicMatches, "skiplist: key of type int is not Comparable")
+}
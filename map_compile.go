@@ -0,0 +1,126 @@
+package skiplist
+
+import (
+	"sort"
+)
+
+// FrozenMap is a read only copy of a map kept in sorted slices, made
+// by Compile. lookups are binary searches over contiguous memory,
+// which is kinder to the cache than following the list. it needs no
+// lock, and it is a ReadView so it can stand in for a locked map
+type FrozenMap struct {
+	comp      func(a, b interface{}) bool
+	normalize func(k interface{}) interface{}
+	keys      []interface{}
+	vals      []interface{}
+}
+
+// Compile copies the map into a FrozenMap. the map is left as it was
+// and can go on changing, the copy doesn't see the changes. the copy
+// normalizes the keys it is given as the map does, see
+// WithKeyNormalizer
+func (m *Map) Compile() *FrozenMap {
+	defer m.endOp("compile", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	f := &FrozenMap{
+		comp:      m.comp,
+		normalize: m.normalize,
+		keys:      make([]interface{}, 0, m.length),
+		vals:      make([]interface{}, 0, m.length),
+	}
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		f.keys = append(f.keys, e.key)
+		f.vals = append(f.vals, e.val)
+	}
+	return f
+}
+
+// normal returns k as the map it was compiled from stores it
+func (f *FrozenMap) normal(k interface{}) interface{} {
+	if f.normalize == nil || k == nil {
+		return k
+	}
+	return f.normalize(k)
+}
+
+// search returns the position of the first key not less than k
+func (f *FrozenMap) search(k interface{}) int {
+	return sort.Search(len(f.keys), func(i int) bool { return !f.comp(f.keys[i], k) })
+}
+
+// Get returns the value for a key, and true if it finds the key,
+// false otherwise. for a multimap it returns the first value for the key
+func (f *FrozenMap) Get(k interface{}) (interface{}, bool) {
+	k = f.normal(k)
+	if k == nil {
+		return nil, false
+	}
+	i := f.search(k)
+	if i == len(f.keys) || f.comp(k, f.keys[i]) {
+		return nil, false
+	}
+	return f.vals[i], true
+}
+
+// Floor returns the pair with the largest key not greater than k,
+// and true if there is one, false otherwise
+func (f *FrozenMap) Floor(k interface{}) (interface{}, interface{}, bool) {
+	k = f.normal(k)
+	// the first key greater than k, less one
+	i := sort.Search(len(f.keys), func(i int) bool { return f.comp(k, f.keys[i]) }) - 1
+	if i < 0 {
+		return nil, nil, false
+	}
+	return f.keys[i], f.vals[i], true
+}
+
+// Ceiling returns the pair with the smallest key not less than k,
+// and true if there is one, false otherwise
+func (f *FrozenMap) Ceiling(k interface{}) (interface{}, interface{}, bool) {
+	k = f.normal(k)
+	i := f.search(k)
+	if i == len(f.keys) {
+		return nil, nil, false
+	}
+	return f.keys[i], f.vals[i], true
+}
+
+// First returns the pair with the smallest key, and true if
+// the map is not empty, false otherwise
+func (f *FrozenMap) First() (interface{}, interface{}, bool) {
+	if len(f.keys) == 0 {
+		return nil, nil, false
+	}
+	return f.keys[0], f.vals[0], true
+}
+
+// Last returns the pair with the largest key, and true if
+// the map is not empty, false otherwise
+func (f *FrozenMap) Last() (interface{}, interface{}, bool) {
+	if len(f.keys) == 0 {
+		return nil, nil, false
+	}
+	return f.keys[len(f.keys)-1], f.vals[len(f.vals)-1], true
+}
+
+// Range calls fn for every pair with from <= key < to in key order,
+// stopping early if fn returns false. a nil from starts at the first
+// key and a nil to runs to the end of the map
+func (f *FrozenMap) Range(from, to interface{}, fn func(k, v interface{}) bool) {
+	from, to = f.normal(from), f.normal(to)
+	i := 0
+	if from != nil {
+		i = f.search(from)
+	}
+	for ; i < len(f.keys) && (to == nil || f.comp(f.keys[i], to)); i++ {
+		if !fn(f.keys[i], f.vals[i]) {
+			break
+		}
+	}
+}
+
+// Len returns the number of pairs in the map
+func (f *FrozenMap) Len() int {
+	return len(f.keys)
+}
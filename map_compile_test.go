@@ -0,0 +1,133 @@
+package skiplist
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type CompileSuite struct{}
+
+var _ = Suite(&CompileSuite{})
+
+// the frozen map must stand in for a locked map
+var _ ReadView = (*FrozenMap)(nil)
+
+func (s *CompileSuite) TestCompileMatchesMap(c *C) {
+	m := NewMap(compareInts)
+	r := rand.New(rand.NewSource(9))
+	for i := 0; i < 1000; i++ {
+		k := r.Intn(5000) * 2
+		m.Put(k, k*3)
+	}
+	f := m.Compile()
+	c.Assert(f.Len(), Equals, m.Len())
+	for k := -3; k < 10003; k++ {
+		v1, ok1 := m.Get(k)
+		v2, ok2 := f.Get(k)
+		c.Assert(ok2, Equals, ok1)
+		c.Assert(v2, Equals, v1)
+	}
+	for _, b := range [][2]interface{}{{nil, nil}, {nil, 100}, {5001, nil}, {10, 11}, {11, 10}, {3001, 3999}} {
+		c.Assert(collectFrozen(f, b[0], b[1]), DeepEquals, collect(m, b[0], b[1]))
+	}
+	k1, v1, _ := m.First()
+	k2, v2, _ := f.First()
+	c.Assert([]interface{}{k2, v2}, DeepEquals, []interface{}{k1, v1})
+	k1, v1, _ = m.Last()
+	k2, v2, _ = f.Last()
+	c.Assert([]interface{}{k2, v2}, DeepEquals, []interface{}{k1, v1})
+	// the map carries on without the copy
+	m.Put(1, 1)
+	_, ok := f.Get(1)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *CompileSuite) TestCompileNormalizes(c *C) {
+	m := NewMap(compareStrings, WithKeyNormalizer(trimLower))
+	for _, k := range []string{"b", "d", "f"} {
+		m.Put(k, k)
+	}
+	f := m.Compile()
+	for _, k := range []string{"B", " d ", "C", "A", "F ", "G"} {
+		v1, ok1 := m.Get(k)
+		v2, ok2 := f.Get(k)
+		c.Assert([]interface{}{v2, ok2}, DeepEquals, []interface{}{v1, ok1}, Commentf(k))
+		k1, _, ok1 := m.Floor(k)
+		k2, _, ok2 := f.Floor(k)
+		c.Assert([]interface{}{k2, ok2}, DeepEquals, []interface{}{k1, ok1}, Commentf(k))
+		k1, _, ok1 = m.Ceiling(k)
+		k2, _, ok2 = f.Ceiling(k)
+		c.Assert([]interface{}{k2, ok2}, DeepEquals, []interface{}{k1, ok1}, Commentf(k))
+	}
+	c.Assert(collectFrozen(f, "C", "F"), DeepEquals, collect(m, "C", "F"))
+	c.Assert(collectFrozen(f, "C", "F"), DeepEquals, []Entry{{"d", "d"}})
+}
+
+func collectFrozen(f *FrozenMap, from, to interface{}) []Entry {
+	ret := []Entry{}
+	f.Range(from, to, func(k, v interface{}) bool {
+		ret = append(ret, Entry{k, v})
+		return true
+	})
+	return ret
+}
+
+func (s *CompileSuite) TestFloorCeiling(c *C) {
+	m := NewMap(compareInts)
+	for _, k := range []int{10, 20, 30} {
+		m.Put(k, k)
+	}
+	f := m.Compile()
+	for _, t := range []struct {
+		k, floor, ceiling interface{}
+	}{{5, nil, 10}, {10, 10, 10}, {15, 10, 20}, {30, 30, 30}, {35, 30, nil}} {
+		k, _, ok := f.Floor(t.k)
+		c.Assert(k, Equals, t.floor)
+		c.Assert(ok, Equals, t.floor != nil)
+		k, _, ok = f.Ceiling(t.k)
+		c.Assert(k, Equals, t.ceiling)
+		c.Assert(ok, Equals, t.ceiling != nil)
+	}
+	e := NewMap(compareInts).Compile()
+	_, _, ok := e.First()
+	c.Assert(ok, Equals, false)
+	_, _, ok = e.Floor(1)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *CompileSuite) TestCompileMulti(c *C) {
+	m := NewMultiMap(compareInts)
+	m.Put(1, "a")
+	m.Put(1, "b")
+	m.Put(2, "c")
+	f := m.Compile()
+	v, _ := f.Get(1)
+	c.Assert(v, Equals, "a")
+	k, v, _ := f.Floor(1)
+	c.Assert(k, Equals, 1)
+	c.Assert(v, Equals, "b")
+	c.Assert(collectFrozen(f, 1, 2), DeepEquals, []Entry{{1, "a"}, {1, "b"}})
+}
+
+func benchmarkCompiledGet(n int, c *C, compile bool) {
+	c.StopTimer()
+	m := fillMap(n)
+	get := m.Get
+	if compile {
+		get = m.Compile().Get
+	}
+	r := rand.New(rand.NewSource(1423123432))
+	c.StartTimer()
+	for i := 0; i < c.N; i++ {
+		get(r.Intn(n))
+	}
+}
+
+func (s *CompileSuite) BenchmarkGet10000000(c *C) {
+	benchmarkCompiledGet(10000000, c, false)
+}
+
+func (s *CompileSuite) BenchmarkCompiledGet10000000(c *C) {
+	benchmarkCompiledGet(10000000, c, true)
+}
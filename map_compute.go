@@ -0,0 +1,78 @@
+package skiplist
+
+import (
+	"errors"
+)
+
+// ErrComputePanicked is returned to callers of GetOrCompute waiting on
+// a computation that panicked
+var ErrComputePanicked = errors.New("skiplist: compute panicked")
+
+// call is a computation in flight for GetOrCompute
+type call struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// GetOrCompute returns the value for a key, calling compute for it and
+// putting the result in the map if the key is missing. compute is
+// called without the map's lock held, and callers asking for the same
+// key while it runs wait for it rather than calling compute again. if
+// compute fails nothing is put and every caller waiting on it gets the
+// error. it panics if k is nil
+func (m *Map) GetOrCompute(k interface{}, compute func() (interface{}, error)) (interface{}, error) {
+	k = m.normal(k)
+	checkKey(k)
+	if v, ok := m.Get(k); ok {
+		return v, nil
+	}
+	c, v, found, leader := m.takeOff(k)
+	if found {
+		return v, nil
+	}
+	if !leader {
+		<-c.done
+		return c.val, c.err
+	}
+	// the waiters must be let go even if compute panics
+	defer func() {
+		m.land(k)
+		close(c.done)
+	}()
+	v, err := compute()
+	if err == nil {
+		m.Put(k, v)
+	}
+	c.val, c.err = v, err
+	return v, err
+}
+
+// takeOff looks for k again under flightMu, returning its value and
+// true for found if it is there now. otherwise it returns the call
+// computing k, starting one if there is none, with true for leader if
+// it started it, in which case the caller must compute k and land it
+func (m *Map) takeOff(k interface{}) (c *call, v interface{}, found, leader bool) {
+	m.flightMu.Lock()
+	defer m.flightMu.Unlock()
+	// the key may have been put while the lock was let go
+	if v, ok := m.Get(k); ok {
+		return nil, v, true, false
+	}
+	if m.inflight == nil {
+		m.inflight = NewMap(m.comp)
+	}
+	if x, ok := m.inflight.get(k); ok {
+		return x.(*call), nil, false, false
+	}
+	c = &call{done: make(chan struct{}), err: ErrComputePanicked}
+	m.inflight.put(k, c)
+	return c, nil, false, true
+}
+
+// land takes the call computing k out of the calls in flight
+func (m *Map) land(k interface{}) {
+	m.flightMu.Lock()
+	defer m.flightMu.Unlock()
+	m.inflight.remove(k)
+}
@@ -0,0 +1,118 @@
+package skiplist
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ComputeSuite struct{}
+
+var _ = Suite(&ComputeSuite{})
+
+func (s *ComputeSuite) TestGetOrCompute(c *C) {
+	m := NewMap(compareInts)
+	m.Put(1, "one")
+	v, err := m.GetOrCompute(1, func() (interface{}, error) {
+		c.Fatal("computed a present key")
+		return nil, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "one")
+	v, err = m.GetOrCompute(2, func() (interface{}, error) { return "two", nil })
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "two")
+	v, _ = m.Get(2)
+	c.Assert(v, Equals, "two")
+}
+
+func (s *ComputeSuite) TestOneComputePerKey(c *C) {
+	m := NewMap(compareInts)
+	var calls int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			v, err := m.GetOrCompute(7, func() (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return 49, nil
+			})
+			if err != nil || v != 49 {
+				c.Errorf("got %v %v", v, err)
+			}
+			wg.Done()
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	c.Assert(atomic.LoadInt32(&calls), Equals, int32(1))
+}
+
+func (s *ComputeSuite) TestKeysComputeIndependently(c *C) {
+	m := NewMap(compareInts)
+	// key 1's compute waits for key 2's, so they must run at once
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		m.GetOrCompute(1, func() (interface{}, error) {
+			<-started
+			return 1, nil
+		})
+		wg.Done()
+	}()
+	go func() {
+		m.GetOrCompute(2, func() (interface{}, error) {
+			close(started)
+			return 2, nil
+		})
+		wg.Done()
+	}()
+	wg.Wait()
+	c.Assert(m.Keys(), DeepEquals, []interface{}{1, 2})
+}
+
+func (s *ComputeSuite) TestComputeError(c *C) {
+	m := NewMap(compareInts)
+	failure := errors.New("failed")
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			_, errs[i] = m.GetOrCompute(3, func() (interface{}, error) {
+				<-release
+				return nil, failure
+			})
+			wg.Done()
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	for _, err := range errs {
+		c.Assert(err, Equals, failure)
+	}
+	c.Assert(m.Len(), Equals, 0)
+	// a later call tries again
+	v, err := m.GetOrCompute(3, func() (interface{}, error) { return 9, nil })
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 9)
+}
+
+func (s *ComputeSuite) TestComputePanic(c *C) {
+	m := NewMap(compareInts)
+	c.Assert(func() {
+		m.GetOrCompute(1, func() (interface{}, error) { panic("boom") })
+	}, PanicMatches, "boom")
+	v, err := m.GetOrCompute(1, func() (interface{}, error) { return 1, nil })
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, 1)
+}
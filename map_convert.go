@@ -0,0 +1,28 @@
+package skiplist
+
+// ToGoMap copies the map into a built in go map, losing the order.
+// besides working with the comparator the keys must be comparable
+// with == as go maps require, otherwise this panics. in a multimap
+// only the first value for each key is kept, as Get would return
+func (m *Map) ToGoMap() map[interface{}]interface{} {
+	defer m.endOp("togomap", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	ret := make(map[interface{}]interface{}, m.length)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		if _, ok := ret[e.key]; !ok {
+			ret[e.key] = e.val
+		}
+	}
+	return ret
+}
+
+// FromGoMap creates a new map holding the pairs of a built in go map,
+// it takes a comparison function that should implement Less
+func FromGoMap(less func(a, b interface{}) bool, gm map[interface{}]interface{}) *Map {
+	m := NewMap(less)
+	for k, v := range gm {
+		m.Put(k, v)
+	}
+	return m
+}
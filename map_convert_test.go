@@ -0,0 +1,33 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ConvertSuite struct{}
+
+var _ = Suite(&ConvertSuite{})
+
+func (s *ConvertSuite) TestToGoMap(c *C) {
+	gm := fillMap(100).ToGoMap()
+	c.Assert(len(gm), Equals, 100)
+	for i := 0; i < 100; i++ {
+		c.Assert(gm[i], Equals, i*2)
+	}
+	c.Assert(NewMap(compareInts).ToGoMap(), HasLen, 0)
+}
+
+func (s *ConvertSuite) TestFromGoMap(c *C) {
+	m := FromGoMap(compareInts, map[interface{}]interface{}{3: "c", 1: "a", 2: "b"})
+	c.Assert(m.Len(), Equals, 3)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.FirstN(3), DeepEquals, []Entry{{1, "a"}, {2, "b"}, {3, "c"}})
+}
+
+// the go map step loses the order, the skiplist puts it back
+func (s *ConvertSuite) TestGoMapRoundTrip(c *C) {
+	m := fillMapRand(1000)
+	back := FromGoMap(compareInts, m.ToGoMap())
+	c.Assert(back.Validate(), IsNil)
+	c.Assert(back.FirstN(1000), DeepEquals, m.FirstN(1000))
+}
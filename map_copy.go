@@ -0,0 +1,130 @@
+package skiplist
+
+import (
+	"fmt"
+)
+
+// KeyCollisionError is returned when a copy would put the pairs for two
+// keys of the source under one key of the destination
+type KeyCollisionError struct {
+	// First and Second are the source keys, in the source's order
+	First, Second interface{}
+	// Key is the key they both become
+	Key interface{}
+}
+
+func (e *KeyCollisionError) Error() string {
+	return fmt.Sprintf("skiplist: keys %v and %v both copy to %v", e.First, e.Second, e.Key)
+}
+
+// CopyTo puts kf(k), vf(v) in dst for every pair k, v of the map, going
+// through the map in order. dst may order keys its own way. a nil kf or
+// vf leaves keys or values as they are. if kf gives two keys of the map
+// the same key in dst, CopyTo returns a *KeyCollisionError naming them,
+// unless dst is a multimap. it returns ErrNilKey if kf gives a nil key,
+// and stops at the first error from dst's persister. the pairs are read
+// under the read lock, kf and vf are called after it is let go, and dst
+// is only changed once every key has been checked, so a collision or a
+// nil key leaves it as it was. pairs already in dst for a key are
+// overwritten as Put would
+func (m *Map) CopyTo(dst *Map, kf func(k interface{}) interface{}, vf func(v interface{}) interface{}) error {
+	defer m.endOp("copyto", nil, m.startOp())
+	from, pairs, err := m.transformed(dst, kf, vf)
+	if err != nil {
+		return err
+	}
+	if !dst.multi {
+		seen := NewMap(dst.comp)
+		for i, p := range pairs {
+			if first, ok := seen.Get(p.Key); ok {
+				return &KeyCollisionError{first, from[i], p.Key}
+			}
+			seen.Put(p.Key, from[i])
+		}
+	}
+	return dst.putAll(pairs)
+}
+
+// CopyToSorted is CopyTo for when the caller knows dst orders keys the
+// way the map does and kf keeps that order, so the keys it gives come
+// in ascending order. colliding keys then sit side by side and are
+// found without a lookup, and when the pairs all go after the keys in
+// dst, as they do in an empty map, they are linked in at the end in
+// O(n) like BulkLoadChan, a persister not being told of them. if the
+// keys turn out not to be in order it returns ErrOutOfOrder, leaving
+// dst as it was
+func (m *Map) CopyToSorted(dst *Map, kf func(k interface{}) interface{}, vf func(v interface{}) interface{}) error {
+	defer m.endOp("copytosorted", nil, m.startOp())
+	from, pairs, err := m.transformed(dst, kf, vf)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < len(pairs); i++ {
+		prev, k := pairs[i-1].Key, pairs[i].Key
+		if dst.inOrder(prev, k) {
+			continue
+		}
+		if dst.comp(k, prev) {
+			return ErrOutOfOrder
+		}
+		return &KeyCollisionError{from[i-1], from[i], k}
+	}
+	if dst.appendSorted(pairs) {
+		return nil
+	}
+	return dst.putAll(pairs)
+}
+
+// transformed returns the keys of the map in order, and the pairs they
+// become in dst under kf and vf, normalized as dst would
+func (m *Map) transformed(dst *Map, kf func(k interface{}) interface{}, vf func(v interface{}) interface{}) ([]interface{}, []Entry, error) {
+	pairs := m.Entries()
+	from := make([]interface{}, len(pairs))
+	for i, p := range pairs {
+		from[i] = p.Key
+		if kf != nil {
+			p.Key = kf(p.Key)
+		}
+		if vf != nil {
+			p.Val = vf(p.Val)
+		}
+		p.Key = dst.normal(p.Key)
+		if p.Key == nil {
+			return nil, nil, ErrNilKey
+		}
+		pairs[i] = p
+	}
+	return from, pairs, nil
+}
+
+// putAll puts each of pairs in the map, stopping at the first error
+func (m *Map) putAll(pairs []Entry) error {
+	for _, p := range pairs {
+		if _, err := m.PutE(p.Key, p.Val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendSorted links pairs, which are in order, onto the end of the
+// map in one step under the write lock, returning false without
+// changing anything if they don't all go after the keys in the map
+func (m *Map) appendSorted(pairs []Entry) bool {
+	m.lock()
+	defer m.mutex.Unlock()
+	if len(pairs) == 0 {
+		return true
+	}
+	m.purge()
+	if last := m.elementAt(m.length-1, nil); last != nil && !m.inOrder(last.key, pairs[0].Key) {
+		return false
+	}
+	c := newChain(m.maxLevels)
+	for _, p := range pairs {
+		c.push(m.newElement(p.Key, p.Val, randomLevels(m)))
+	}
+	// the order was checked above, so this can't fail
+	m.splice(c)
+	return true
+}
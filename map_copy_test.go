@@ -0,0 +1,91 @@
+package skiplist
+
+import (
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+type CopySuite struct{}
+
+var _ = Suite(&CopySuite{})
+
+func (s *CopySuite) TestCopyTo(c *C) {
+	m := fillMap(20)
+	dst := NewMap(compareStrings)
+	dst.Put("x", "old")
+	err := m.CopyTo(dst, func(k interface{}) interface{} { return strconv.Itoa(k.(int)) }, func(v interface{}) interface{} { return v.(int) + 1 })
+	c.Assert(err, IsNil)
+	c.Assert(dst.Validate(), IsNil)
+	c.Assert(dst.Len(), Equals, 21)
+	// dst keeps its own order, "10" before "2"
+	c.Assert(dst.Keys()[:4], DeepEquals, []interface{}{"0", "1", "10", "11"})
+	v, _ := dst.Get("7")
+	c.Assert(v, Equals, 15)
+}
+
+func (s *CopySuite) TestCopyToIdentity(c *C) {
+	m := fillMap(50)
+	dst := NewMap(compareInts)
+	c.Assert(m.CopyTo(dst, nil, nil), IsNil)
+	c.Assert(dst.Entries(), DeepEquals, m.Entries())
+	// copying again overwrites with the same pairs
+	c.Assert(m.CopyTo(dst, nil, nil), IsNil)
+	c.Assert(dst.Entries(), DeepEquals, m.Entries())
+}
+
+func (s *CopySuite) TestCopyToCollision(c *C) {
+	m := fillMap(10)
+	dst := NewMap(compareInts)
+	dst.Put(100, 100)
+	err := m.CopyTo(dst, func(k interface{}) interface{} { return k.(int) / 3 }, nil)
+	c.Assert(err, DeepEquals, &KeyCollisionError{0, 1, 0})
+	c.Assert(err, ErrorMatches, "skiplist: keys 0 and 1 both copy to 0")
+	// nothing was copied
+	c.Assert(dst.Keys(), DeepEquals, []interface{}{100})
+
+	err = m.CopyTo(dst, func(k interface{}) interface{} {
+		if k.(int) == 4 {
+			return nil
+		}
+		return k
+	}, nil)
+	c.Assert(err, Equals, ErrNilKey)
+	c.Assert(dst.Len(), Equals, 1)
+
+	// a multimap takes every pair
+	multi := NewMultiMap(compareInts)
+	c.Assert(m.CopyTo(multi, func(k interface{}) interface{} { return k.(int) / 3 }, nil), IsNil)
+	c.Assert(multi.Len(), Equals, 10)
+	c.Assert(multi.Count(1), Equals, 3)
+}
+
+func (s *CopySuite) TestCopyToSorted(c *C) {
+	m := fillMap(1000)
+	double := func(k interface{}) interface{} { return k.(int) * 2 }
+	dst := NewMap(compareInts)
+	c.Assert(m.CopyToSorted(dst, double, nil), IsNil)
+	c.Assert(dst.Validate(), IsNil)
+	c.Assert(dst.Len(), Equals, 1000)
+	v, _ := dst.Get(1998)
+	c.Assert(v, Equals, 1998)
+	// the keys go among those in dst, so they are put one at a time
+	odd := func(k interface{}) interface{} { return k.(int)*2 + 1 }
+	c.Assert(m.CopyToSorted(dst, odd, nil), IsNil)
+	c.Assert(dst.Validate(), IsNil)
+	c.Assert(dst.Len(), Equals, 2000)
+	keys := dst.Keys()
+	for i, k := range keys {
+		c.Assert(k, Equals, i)
+	}
+
+	err := m.CopyToSorted(NewMap(compareInts), func(k interface{}) interface{} { return k.(int) / 2 }, nil)
+	c.Assert(err, DeepEquals, &KeyCollisionError{0, 1, 0})
+	// a kf that doesn't keep the order is caught
+	dst = NewMap(compareInts)
+	err = m.CopyToSorted(dst, func(k interface{}) interface{} { return -k.(int) }, nil)
+	c.Assert(err, Equals, ErrOutOfOrder)
+	c.Assert(dst.Len(), Equals, 0)
+	c.Assert(NewMap(compareInts).CopyToSorted(dst, nil, nil), IsNil)
+	c.Assert(dst.Len(), Equals, 0)
+}
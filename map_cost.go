@@ -0,0 +1,143 @@
+package skiplist
+
+import (
+	"math"
+)
+
+// EvictPolicy picks which pairs a map over its WithMaxCost limit
+// evicts first
+type EvictPolicy int
+
+const (
+	// EvictLowest evicts the pairs with the smallest keys first
+	EvictLowest EvictPolicy = iota
+	// EvictHighest evicts the pairs with the largest keys first
+	EvictHighest
+)
+
+// costs is the running total kept by WithCostFunc and WithMaxCost
+type costs struct {
+	fn      func(k, v interface{}) int64
+	total   int64
+	limit   int64
+	policy  EvictPolicy
+	onEvict func(k, v interface{})
+}
+
+// costs returns the map's costs, setting them up with every pair
+// costing 1 and no limit if no option has yet
+func (m *Map) costs() *costs {
+	if m.cost == nil {
+		m.cost = &costs{
+			fn:    func(k, v interface{}) int64 { return 1 },
+			limit: math.MaxInt64,
+		}
+	}
+	return m.cost
+}
+
+// WithCostFunc keeps a running total of fn over every pair in the map,
+// read by Cost, say the size of each value in bytes. fn is called under
+// the write lock whenever a pair comes or goes, so it should be cheap
+// and must give the same answer for a pair each time
+func WithCostFunc(fn func(k, v interface{}) int64) Option {
+	return func(m *Map) {
+		m.costs().fn = fn
+	}
+}
+
+// WithMaxCost evicts pairs, by the policy set by WithEvictPolicy, after
+// a Put or PutOrMerge leaves the total cost of the map over limit,
+// calling onEvict, if it isn't nil, with each pair evicted once the lock
+// is let go. the pair just put goes last, only once nothing else is
+// left. without WithCostFunc every pair costs 1, making limit a cap on
+// the number of pairs. changes made other ways count toward the total,
+// but nothing is evicted for them until the next Put or PutOrMerge
+func WithMaxCost(limit int64, onEvict func(k, v interface{})) Option {
+	return func(m *Map) {
+		c := m.costs()
+		c.limit, c.onEvict = limit, onEvict
+	}
+}
+
+// WithEvictPolicy sets which pairs WithMaxCost evicts first,
+// EvictLowest if it isn't given
+func WithEvictPolicy(p EvictPolicy) Option {
+	return func(m *Map) {
+		m.costs().policy = p
+	}
+}
+
+// change folds a change into the total, taking out the cost of the
+// old pair and putting in the new one
+func (c *costs) change(op ChangeOp, k, old, new interface{}) {
+	if op != OpInsert {
+		c.total -= c.fn(k, old)
+	}
+	if op != OpDelete {
+		c.total += c.fn(k, new)
+	}
+}
+
+// Cost returns the total cost of the pairs in the map, as kept by
+// WithCostFunc or WithMaxCost, or 0 if the map doesn't keep one
+func (m *Map) Cost() int64 {
+	var ret int64
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if m.cost != nil {
+		ret = m.cost.total
+	}
+	return ret
+}
+
+// evict removes pairs until the map is back within its cost limit,
+// passing over the pairs for keep while there are others, and returns
+// them. the caller must hold the write lock
+func (m *Map) evict(keep interface{}) []Entry {
+	c := m.cost
+	if c == nil || c.total <= c.limit {
+		return nil
+	}
+	var ret []Entry
+	for c.total > c.limit && m.length > 0 {
+		e := m.victim(keep)
+		ret = append(ret, Entry{e.key, e.val})
+		m.removeElement(e)
+	}
+	return ret
+}
+
+// victim returns the next pair to evict by the map's policy, the
+// caller must hold the write lock and the map must not be empty
+func (m *Map) victim(keep interface{}) *mapElement {
+	if m.cost.policy == EvictHighest {
+		e := m.elementAt(m.length-1, nil)
+		// keep is not less than the last pair only if it is for keep
+		if !m.comp(keep, e.key) {
+			var backPointer [maxHeight]*mapElement
+			m.lowerBound(keep, backPointer[:])
+			if prev := m.liveBefore(backPointer[0]); prev != nil {
+				return prev
+			}
+		}
+		return e
+	}
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		if m.comp(keep, e.key) || m.comp(e.key, keep) {
+			return e
+		}
+	}
+	return liveFrom(m.head.next[0])
+}
+
+// evicted tells the map's onEvict about evicted pairs,
+// it is called without the lock held
+func (m *Map) evicted(pairs []Entry) {
+	if len(pairs) == 0 || m.cost.onEvict == nil {
+		return
+	}
+	for _, p := range pairs {
+		m.cost.onEvict(p.Key, p.Val)
+	}
+}
@@ -0,0 +1,102 @@
+package skiplist
+
+import (
+	"math/rand"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type CostSuite struct{}
+
+var _ = Suite(&CostSuite{})
+
+// valueLen costs a pair the length of its string value
+func valueLen(k, v interface{}) int64 {
+	return int64(len(v.(string)))
+}
+
+// recount adds up the cost of every pair the slow way
+func recount(m *Map) int64 {
+	total := int64(0)
+	m.Range(nil, nil, func(k, v interface{}) bool {
+		total += valueLen(k, v)
+		return true
+	})
+	return total
+}
+
+func (s *CostSuite) TestOverwrite(c *C) {
+	m := NewMap(compareInts, WithCostFunc(valueLen))
+	m.Put(1, "aaaa")
+	m.Put(2, "bb")
+	c.Assert(m.Cost(), Equals, int64(6))
+	// growing and shrinking a value moves the total by the difference
+	m.Put(1, "aaaaaaaaaa")
+	c.Assert(m.Cost(), Equals, int64(12))
+	m.Put(2, "")
+	c.Assert(m.Cost(), Equals, int64(10))
+	m.Remove(1)
+	c.Assert(m.Cost(), Equals, int64(0))
+	m.Put(3, "ccc")
+	m.Clear()
+	c.Assert(m.Cost(), Equals, int64(0))
+	c.Assert(NewMap(compareInts).Cost(), Equals, int64(0))
+}
+
+func (s *CostSuite) TestEvictCascade(c *C) {
+	evicted := []int{}
+	m := NewMap(compareInts, WithCostFunc(valueLen), WithMaxCost(100, func(k, v interface{}) {
+		evicted = append(evicted, k.(int))
+	}))
+	for i := 0; i < 10; i++ {
+		m.Put(i, "0123456789")
+	}
+	c.Assert(m.Cost(), Equals, int64(100))
+	c.Assert(evicted, HasLen, 0)
+	// one big value pushes out the smallest keys until it fits
+	m.Put(5, strings.Repeat("x", 75))
+	c.Assert(evicted, DeepEquals, []int{0, 1, 2, 3, 4, 6, 7})
+	c.Assert(m.Keys(), DeepEquals, []interface{}{5, 8, 9})
+	c.Assert(m.Cost(), Equals, int64(95))
+	// a value over the limit on its own goes once nothing else is left
+	evicted = evicted[:0]
+	m.Put(8, strings.Repeat("y", 200))
+	c.Assert(evicted, DeepEquals, []int{5, 9, 8})
+	c.Assert(m.Len(), Equals, 0)
+	c.Assert(m.Cost(), Equals, int64(0))
+}
+
+func (s *CostSuite) TestEvictHighest(c *C) {
+	m := NewMap(compareInts, WithMaxCost(3, nil), WithEvictPolicy(EvictHighest))
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+	}
+	// without a cost function the limit is on the number of pairs
+	c.Assert(m.Keys(), DeepEquals, []interface{}{0, 1, 4})
+	c.Assert(m.Cost(), Equals, int64(3))
+	m.PutOrMerge(2, 2, nil)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{0, 1, 2})
+}
+
+func (s *CostSuite) TestAgainstRecount(c *C) {
+	r := rand.New(rand.NewSource(11))
+	m := NewMap(compareInts, WithCostFunc(valueLen), WithMaxCost(500, nil))
+	for round := 0; round < 3000; round++ {
+		k := r.Intn(100)
+		switch r.Intn(5) {
+		case 0:
+			m.Remove(k)
+		case 1:
+			m.PutOrMerge(k, "m", func(existing, incoming interface{}) interface{} {
+				return existing.(string) + incoming.(string)
+			})
+		case 2:
+			m.PopMin()
+		default:
+			m.Put(k, strings.Repeat("v", r.Intn(40)))
+		}
+		c.Assert(m.Cost(), Equals, recount(m))
+		c.Assert(m.Cost() <= 500, Equals, true)
+	}
+}
@@ -0,0 +1,124 @@
+package skiplist
+
+// MaxSearchDepth returns the largest number of hops between elements
+// that a search could take in the current structure, looking at the
+// path to every key and past the last one. a well balanced map needs
+// around 2*log2(Len) hops, a degenerate one up to Len
+func (m *Map) MaxSearchDepth() int {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	max := 0
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		if hops := m.searchHops(func(next *mapElement) bool { return m.comp(next.key, e.key) }); hops > max {
+			max = hops
+		}
+	}
+	// a key past the end follows every level to its last element
+	if hops := m.searchHops(func(next *mapElement) bool { return true }); hops > max {
+		max = hops
+	}
+	return max
+}
+
+// searchHops counts the hops a search takes, moving along each level
+// while before says the next element comes before the key searched for.
+// the caller must hold the lock
+func (m *Map) searchHops(before func(next *mapElement) bool) int {
+	hops := 0
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && before(next); next = prev.next[level] {
+			prev = next
+			hops++
+		}
+	}
+	return hops
+}
+
+// GetWithHops is Get that also returns how many times the lookup
+// compared k with a key on its way down the levels, which is around
+// 2*log2(Len) in a well balanced map and up to Len in a degenerate
+// one. it always walks the list, even in a map WithHashIndex, so the
+// count says what the structure costs
+func (m *Map) GetWithHops(k interface{}) (interface{}, bool, int) {
+	defer m.endOp("getwithhops", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, false, 0
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	hops := 0
+	less := func(a, b interface{}) bool {
+		hops++
+		return m.comp(a, b)
+	}
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && less(next.key, k); next = prev.next[level] {
+			prev = next
+		}
+	}
+	e := prev.next[0]
+	if e == nil || less(k, e.key) || e.deleted {
+		return nil, false, hops
+	}
+	return e.val, true, hops
+}
+
+// MapStats describes the shape of a map, see Stats
+type MapStats struct {
+	// Length is the number of pairs
+	Length int
+	// HeightInUse is the number of levels holding any elements
+	HeightInUse int
+	// MaxLevels is the most levels an element can have
+	MaxLevels int
+	// LevelCounts holds the number of elements at each level in use
+	LevelCounts []int
+}
+
+// Stats returns the shape of the map, gathered in one pass
+// under the lock
+func (m *Map) Stats() MapStats {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	s := MapStats{Length: m.length, MaxLevels: m.maxLevels, LevelCounts: []int{}}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		for len(s.LevelCounts) < len(e.next) {
+			s.LevelCounts = append(s.LevelCounts, 0)
+		}
+		for level := range e.next {
+			s.LevelCounts[level]++
+		}
+	}
+	s.HeightInUse = len(s.LevelCounts)
+	return s
+}
+
+// Height returns the number of levels holding any elements
+func (m *Map) Height() int {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	height := 0
+	for height < m.maxLevels && m.head.next[height] != nil {
+		height++
+	}
+	return height
+}
+
+// NodeCount returns the number of elements reachable along level 0,
+// counted one by one rather than read from the length the map keeps,
+// tombstones included. in a map without tombstones the two should
+// always agree, a difference means elements have been lost or linked
+// in twice. in one made WithTombstones it is Len plus the tombstones
+// not yet purged
+func (m *Map) NodeCount() int {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	n := 0
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		n++
+	}
+	return n
+}
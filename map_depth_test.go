@@ -0,0 +1,324 @@
+package skiplist
+
+import (
+	"math/rand/v2"
+
+	. "gopkg.in/check.v1"
+)
+
+type DepthSuite struct{}
+
+var _ = Suite(&DepthSuite{})
+
+// flatSource always returns 0, so every element gets a single level
+type flatSource struct{}
+
+func (flatSource) Uint64() uint64 { return 0 }
+
+func (s *DepthSuite) TestMaxSearchDepthEmpty(c *C) {
+	c.Assert(NewMap(compareInts).MaxSearchDepth(), Equals, 0)
+}
+
+func (s *DepthSuite) TestMaxSearchDepthBalanced(c *C) {
+	m := fillMapRand(4096)
+	depth := m.MaxSearchDepth()
+	c.Assert(depth > 0, Equals, true)
+	c.Assert(depth < 100, Equals, true, Commentf("depth %d", depth))
+}
+
+func (s *DepthSuite) TestMaxSearchDepthDegenerate(c *C) {
+	m := NewMap(compareInts)
+	m.rnd.r = rand.New(flatSource{})
+	for i := 0; i < 4096; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.MaxSearchDepth(), Equals, 4096)
+	c.Assert(m.MaxSearchDepth() > fillMapRand(4096).MaxSearchDepth()*10, Equals, true)
+}
+
+func (s *DepthSuite) TestStats(c *C) {
+	m := fillMapRand(4096)
+	stats := m.Stats()
+	c.Assert(stats.Length, Equals, m.Len())
+	c.Assert(stats.HeightInUse, Equals, m.Height())
+	c.Assert(stats.MaxLevels, Equals, maxHeight)
+	c.Assert(stats.LevelCounts, HasLen, stats.HeightInUse)
+	c.Assert(stats.LevelCounts[0], Equals, 4096)
+	for level := 1; level < stats.HeightInUse; level++ {
+		c.Assert(stats.LevelCounts[level] <= stats.LevelCounts[level-1], Equals, true)
+		c.Assert(stats.LevelCounts[level] > 0, Equals, true)
+	}
+	c.Assert(NewMap(compareInts).Stats(), DeepEquals, MapStats{0, 0, maxHeight, []int{}})
+	c.Assert(NewMap(compareInts).Height(), Equals, 0)
+}
+
+func (s *DepthSuite) TestNodeCount(c *C) {
+	r := rand.New(rand.NewPCG(7, 0))
+	m := NewMap(compareInts)
+	c.Assert(m.NodeCount(), Equals, 0)
+	for round := 0; round < 3000; round++ {
+		k := r.IntN(500)
+		switch r.IntN(10) {
+		case 0:
+			m.PopMin()
+		case 1:
+			m.CompactIf(func(v interface{}) bool { return v.(int)%7 == 0 })
+		case 2, 3, 4:
+			m.Remove(k)
+		default:
+			m.Put(k, r.IntN(100))
+		}
+		if round%1000 == 999 {
+			// recycled elements come back through the pool
+			m.ClearAndRecycle()
+		}
+		c.Assert(m.NodeCount(), Equals, m.length)
+		c.Assert(m.NodeCount(), Equals, m.Len())
+	}
+}
+
+func (s *DepthSuite) TestNodeCountTombstones(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	m.Remove(2)
+	m.Remove(5)
+	// the tombstones are still linked, and counted
+	c.Assert(m.Len(), Equals, 8)
+	c.Assert(m.NodeCount(), Equals, 10)
+	m.Put(2, 2)
+	c.Assert(m.NodeCount(), Equals, 10)
+	c.Assert(m.Purge(), Equals, 1)
+	c.Assert(m.NodeCount(), Equals, m.Len())
+}
+
+func (s *DepthSuite) TestNewMapWithCapacity(c *C) {
+	c.Assert(NewMapWithCapacity(compareInts, 1000000).Stats().MaxLevels, Equals, 20)
+	c.Assert(NewMapWithCapacity(compareInts, 8).Stats().MaxLevels, Equals, 3)
+	c.Assert(NewMapWithCapacity(compareInts, 0).Stats().MaxLevels, Equals, 1)
+	m := NewMapWithCapacity(compareInts, 16)
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Height() <= 4, Equals, true)
+	c.Assert(m.Validate(), IsNil)
+	v, _ := m.Get(500)
+	c.Assert(v, Equals, 500)
+
+	// splitting and joining again mixes heights
+	left, right := m.Split(300)
+	joined := Concat(left, NewMap(compareInts))
+	c.Assert(joined.Len(), Equals, 300)
+	joined = Concat(joined, right)
+	c.Assert(joined.Len(), Equals, 1000)
+	c.Assert(joined.Validate(), IsNil)
+	c.Assert(Concat(NewMapWithCapacity(compareInts, 4), fillMap(10)).Validate(), IsNil)
+}
+
+func (s *DepthSuite) TestShrinkLevels(c *C) {
+	m := NewMap(compareInts)
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	tall := m.Height()
+	for i := 0; i < 10000; i += 2 {
+		m.Remove(i)
+	}
+	for i := 1; i < 9990; i += 2 {
+		m.Remove(i)
+	}
+	c.Assert(m.Len(), Equals, 5)
+	m.ShrinkLevels()
+	c.Assert(m.Stats().MaxLevels, Equals, 3)
+	c.Assert(m.Height() <= 3, Equals, true)
+	c.Assert(m.Height() < tall, Equals, true)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{9991, 9993, 9995, 9997, 9999})
+	v, _ := m.Get(9995)
+	c.Assert(v, Equals, 9995)
+	c.Assert(m.LastN(2), DeepEquals, []Entry{{9997, 9997}, {9999, 9999}})
+	m.Put(0, 0)
+	c.Assert(m.Validate(), IsNil)
+
+	empty := NewMap(compareInts)
+	empty.ShrinkLevels()
+	c.Assert(empty.Stats().MaxLevels, Equals, 1)
+	empty.Put(1, 1)
+	c.Assert(empty.Validate(), IsNil)
+}
+
+func (s *DepthSuite) TestShrinkToFit(c *C) {
+	r := rand.New(rand.NewPCG(7, 7))
+	m := NewMap(compareInts)
+	for i := 0; i < 10000; i++ {
+		m.Put(i, i)
+	}
+	// remove 99% of the pairs, keeping every hundredth
+	for i := 0; i < 10000; i++ {
+		if i%100 != 0 {
+			m.Remove(i)
+		}
+	}
+	c.Assert(m.Len(), Equals, 100)
+	m.ShrinkToFit()
+	c.Assert(m.Stats().MaxLevels, Equals, 7)
+	c.Assert(m.Height() <= 7, Equals, true)
+	c.Assert(m.Validate(), IsNil)
+	for i := 0; i < 100; i++ {
+		v, ok := m.Get(i * 100)
+		c.Assert(ok, Equals, true)
+		c.Assert(v, Equals, i*100)
+	}
+	for i := 0; i < 2000; i++ {
+		k := r.IntN(20000)
+		if r.IntN(4) == 0 {
+			m.Remove(k)
+		} else {
+			m.Put(k, k)
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+
+	// a map with one pair needs one level, shrinking again changes nothing
+	m = NewMapWithCapacity(compareInts, 8)
+	m.Put(1, 1)
+	m.ShrinkToFit()
+	c.Assert(m.Stats().MaxLevels, Equals, 1)
+	m.ShrinkToFit()
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *DepthSuite) TestAllocElement(c *C) {
+	for levels := 1; levels <= 8; levels++ {
+		e := allocElement(levels, levels*2, levels)
+		c.Assert(e.key, Equals, levels)
+		c.Assert(e.val, Equals, levels*2)
+		c.Assert(e.version, Equals, uint64(1))
+		c.Assert(e.next, HasLen, levels)
+		c.Assert(e.span, HasLen, levels)
+		c.Assert(cap(e.next), Equals, levels)
+		c.Assert(cap(e.span), Equals, levels)
+	}
+	// shrinking towers and recycling keep working with inline links
+	m := fillMapRand(5000)
+	m.ShrinkToFit()
+	m.ClearAndRecycle()
+	for i := 0; i < 5000; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+// splitLinks moves the links of e into slices of their own, the
+// layout elements had before short towers were allocated in one block
+func splitLinks(e *mapElement) {
+	e.next = append([]*mapElement(nil), e.next...)
+	e.span = append([]int(nil), e.span...)
+}
+
+// benchmarkGetLayout times random Gets on a map filled in random
+// order, its links split out as each pair is put if split is true
+func benchmarkGetLayout(c *C, split bool) {
+	const n = 1000000
+	r := rand.New(rand.NewPCG(3, 3))
+	m := NewMap(compareInts)
+	for _, k := range r.Perm(n) {
+		m.Put(k, k)
+		if split {
+			splitLinks(m.find(k, nil))
+		}
+	}
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		m.Get(r.IntN(n))
+	}
+}
+
+func (s *DepthSuite) BenchmarkGetInlineLinks1000000(c *C) {
+	benchmarkGetLayout(c, false)
+}
+
+func (s *DepthSuite) BenchmarkGetSplitLinks1000000(c *C) {
+	benchmarkGetLayout(c, true)
+}
+
+func (s *DepthSuite) TestGetWithHops(c *C) {
+	m := fillMap(4096)
+	total, most := 0, 0
+	for i := 0; i < 4096; i++ {
+		v, ok, hops := m.GetWithHops(i)
+		c.Assert(ok, Equals, true)
+		c.Assert(v, Equals, i*2)
+		total += hops
+		if hops > most {
+			most = hops
+		}
+	}
+	// log2(4096) is 12
+	c.Assert(total/4096 < 4*12, Equals, true, Commentf("average %d", total/4096))
+	c.Assert(most < 100, Equals, true, Commentf("most %d", most))
+	_, ok, hops := m.GetWithHops(5000)
+	c.Assert(ok, Equals, false)
+	c.Assert(hops > 0, Equals, true)
+	_, ok, hops = m.GetWithHops(nil)
+	c.Assert(ok, Equals, false)
+	c.Assert(hops, Equals, 0)
+
+	flat := NewMap(compareInts)
+	flat.rnd.r = rand.New(flatSource{})
+	for i := 0; i < 4096; i++ {
+		flat.Put(i, i)
+	}
+	// one level is a linked list: every key before, then the key itself
+	v, ok, hops := flat.GetWithHops(4000)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 4000)
+	c.Assert(hops, Equals, 4002)
+	_, _, hops = NewMap(compareInts).GetWithHops(1)
+	c.Assert(hops, Equals, 0)
+}
+
+// a shrunk map gets its levels back as it grows, so lookups stay
+// logarithmic rather than walking a list
+func (s *DepthSuite) TestShrunkMapRegrows(c *C) {
+	for _, shrink := range []func(m *Map){(*Map).ShrinkLevels, (*Map).ShrinkToFit} {
+		m := NewMap(compareInts)
+		m.Put(0, 0)
+		shrink(m)
+		c.Assert(m.Stats().MaxLevels, Equals, 1)
+		for i := 1; i < 10000; i++ {
+			m.Put(i, i)
+		}
+		c.Assert(m.Stats().MaxLevels, Equals, levelsFor(10000))
+		c.Assert(m.Validate(), IsNil)
+		hops := 0
+		for i := 0; i < 10000; i += 10 {
+			_, ok, h := m.GetWithHops(i)
+			c.Assert(ok, Equals, true)
+			hops += h
+		}
+		// around 2*log2(10000) per lookup, not thousands
+		c.Assert(hops/1000 < 100, Equals, true, Commentf("%d hops per lookup", hops/1000))
+	}
+
+	// a map made for few pairs doesn't grow past what it was made with
+	m := NewMapWithCapacity(compareInts, 8)
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Stats().MaxLevels, Equals, 3)
+
+	// pairs added in bulk count too
+	m = NewMap(compareInts)
+	m.ShrinkLevels()
+	keys := make([]interface{}, 1000)
+	for i := range keys {
+		keys[i] = i
+	}
+	c.Assert(m.ParallelBulkLoad(keys, keys, 2), IsNil)
+	c.Assert(m.Stats().MaxLevels, Equals, levelsFor(1000))
+	c.Assert(m.Height() > 1, Equals, true)
+	c.Assert(m.Validate(), IsNil)
+}
@@ -0,0 +1,57 @@
+package skiplist
+
+// DiffResult holds the differences Diff finds between two maps, each
+// group in key order
+type DiffResult struct {
+	// OnlyHere holds the pairs whose keys are only in the receiver
+	OnlyHere []Entry
+	// OnlyThere holds the pairs whose keys are only in the other map
+	OnlyThere []Entry
+	// Changed holds the keys in both maps with different values
+	Changed []ValueChange
+}
+
+// ValueChange is a key whose value differs between two maps
+type ValueChange struct {
+	Key   interface{}
+	Here  interface{}
+	There interface{}
+}
+
+// Diff compares the map with other, which must be ordered the same
+// way, walking both once side by side. valueEq decides if the values
+// for a key in both maps are the same. in multimaps the pairs for a
+// key are matched up in order. it holds both read locks, taken in
+// address order like Concat's, so valueEq must not use either map
+func (m *Map) Diff(other *Map, valueEq func(a, b interface{}) bool) DiffResult {
+	defer m.endOp("diff", nil, m.startOp())
+	ret := DiffResult{[]Entry{}, []Entry{}, []ValueChange{}}
+	first, second := inAddressOrder(m, other)
+	firstLocked := first.rlock()
+	defer first.runlock(firstLocked)
+	secondLocked := false
+	if second != first {
+		secondLocked = second.rlock()
+	}
+	defer second.runlock(secondLocked)
+	id, otherID := m.callback.enter(), other.callback.enter()
+	defer m.callback.leave(id)
+	defer other.callback.leave(otherID)
+	a, b := liveFrom(m.head.next[0]), liveFrom(other.head.next[0])
+	for a != nil || b != nil {
+		switch {
+		case b == nil || a != nil && m.comp(a.key, b.key):
+			ret.OnlyHere = append(ret.OnlyHere, Entry{a.key, a.val})
+			a = liveFrom(a.next[0])
+		case a == nil || m.comp(b.key, a.key):
+			ret.OnlyThere = append(ret.OnlyThere, Entry{b.key, b.val})
+			b = liveFrom(b.next[0])
+		default:
+			if !valueEq(a.val, b.val) {
+				ret.Changed = append(ret.Changed, ValueChange{a.key, a.val, b.val})
+			}
+			a, b = liveFrom(a.next[0]), liveFrom(b.next[0])
+		}
+	}
+	return ret
+}
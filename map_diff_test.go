@@ -0,0 +1,83 @@
+package skiplist
+
+import (
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DiffSuite struct{}
+
+var _ = Suite(&DiffSuite{})
+
+func equalValues(a, b interface{}) bool { return a == b }
+
+func (s *DiffSuite) TestIdentical(c *C) {
+	a, b := fillMapRand(500), fillMapRand(500)
+	d := a.Diff(b, equalValues)
+	c.Assert(d, DeepEquals, DiffResult{[]Entry{}, []Entry{}, []ValueChange{}})
+	c.Assert(a.Diff(a, equalValues), DeepEquals, d)
+	c.Assert(NewMap(compareInts).Diff(NewMap(compareInts), equalValues), DeepEquals, d)
+}
+
+func (s *DiffSuite) TestDisjoint(c *C) {
+	a, b := NewMap(compareInts), NewMap(compareInts)
+	for i := 0; i < 10; i++ {
+		a.Put(i*2, i)
+		b.Put(i*2+1, i)
+	}
+	d := a.Diff(b, equalValues)
+	c.Assert(d.OnlyHere, DeepEquals, a.Entries())
+	c.Assert(d.OnlyThere, DeepEquals, b.Entries())
+	c.Assert(d.Changed, HasLen, 0)
+	d = a.Diff(NewMap(compareInts), equalValues)
+	c.Assert(d.OnlyHere, DeepEquals, a.Entries())
+	c.Assert(d.OnlyThere, HasLen, 0)
+}
+
+func (s *DiffSuite) TestChanges(c *C) {
+	a, b := fillMap(100), fillMap(100)
+	b.Put(50, -1)
+	b.Remove(0)
+	b.Put(100, 1)
+	a.Put(-1, 0)
+	d := a.Diff(b, equalValues)
+	c.Assert(d.OnlyHere, DeepEquals, []Entry{{-1, 0}, {0, 0}})
+	c.Assert(d.OnlyThere, DeepEquals, []Entry{{100, 1}})
+	c.Assert(d.Changed, DeepEquals, []ValueChange{{50, 100, -1}})
+	d = b.Diff(a, equalValues)
+	c.Assert(d.OnlyThere, DeepEquals, []Entry{{-1, 0}, {0, 0}})
+	c.Assert(d.Changed, DeepEquals, []ValueChange{{50, -1, 100}})
+}
+
+// lowFirst checks that both(a, b) and both(b, a) each lock the map
+// at the lower address first, so that with writers waiting on both
+// maps they can't deadlock. it holds the lower map's write lock while
+// they start, and neither may hold the higher map's lock meanwhile
+func lowFirst(c *C, both func(x, y *Map)) {
+	a, b := fillMap(20), fillMap(20)
+	low, high := inAddressOrder(a, b)
+	low.mutex.Lock()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*Map{{a, b}, {b, a}} {
+		wg.Add(1)
+		go func(x, y *Map) {
+			defer wg.Done()
+			both(x, y)
+		}(pair[0], pair[1])
+	}
+	// give both calls time to get to the lock they wait on
+	time.Sleep(50 * time.Millisecond)
+	free := high.mutex.TryLock()
+	if free {
+		high.mutex.Unlock()
+	}
+	low.mutex.Unlock()
+	wg.Wait()
+	c.Assert(free, Equals, true)
+}
+
+func (s *DiffSuite) TestOppositeOrders(c *C) {
+	lowFirst(c, func(x, y *Map) { x.Diff(y, equalValues) })
+}
@@ -0,0 +1,64 @@
+package skiplist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dotLimit is the most pairs WriteDOT will draw, past a few hundred
+// Graphviz output is unreadable anyway
+const dotLimit = 1000
+
+// ErrTooLarge is returned by WriteDOT for a map with too many pairs
+var ErrTooLarge = errors.New("skiplist: map too large to draw")
+
+// dotEscape escapes the characters that mean something in a record label
+var dotEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `{`, `\{`, `}`, `\}`, `|`, `\|`, `<`, `\<`, `>`, `\>`)
+
+// WriteDOT writes the structure of the map to w as a Graphviz DOT
+// graph, with the elements left to right in key order, each drawn as
+// a tower of its levels, and an edge for every link at every level.
+// keyLabel gives the text shown for each key. it is only meant for
+// small maps, returning ErrTooLarge for more than a thousand pairs.
+// keyLabel is called under the read lock, so it must not use the map
+func (m *Map) WriteDOT(w io.Writer, keyLabel func(k interface{}) string) error {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	if m.length > dotLimit {
+		return ErrTooLarge
+	}
+	height := 0
+	for height < m.maxLevels && m.head.next[height] != nil {
+		height++
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph skiplist {\n\trankdir=LR;\n\tnode [shape=record];\n")
+	tower := func(name string, levels int, label string) {
+		fmt.Fprintf(bw, "\t%s [label=\"{", name)
+		for level := levels - 1; level >= 0; level-- {
+			fmt.Fprintf(bw, "<l%d>|", level)
+		}
+		fmt.Fprintf(bw, "%s}\"];\n", dotEscape.Replace(label))
+	}
+	tower("head", height, "head")
+	// name the elements by their position
+	names := make(map[*mapElement]string, m.length)
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		names[e] = fmt.Sprintf("e%d", len(names))
+		tower(names[e], len(e.next), keyLabel(e.key))
+	}
+	for level := 0; level < height; level++ {
+		prev := "head"
+		for e := m.head.next[level]; e != nil; e = e.next[level] {
+			fmt.Fprintf(bw, "\t%s:l%d -> %s:l%d;\n", prev, level, names[e], level)
+			prev = names[e]
+		}
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,69 @@
+package skiplist
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand/v2"
+
+	. "gopkg.in/check.v1"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type DOTSuite struct{}
+
+var _ = Suite(&DOTSuite{})
+
+// levelSource makes a map's elements take the given numbers of
+// levels in turn
+type levelSource struct {
+	levels []int
+	i      int
+}
+
+func (s *levelSource) Uint64() uint64 {
+	l := s.levels[s.i%len(s.levels)]
+	s.i++
+	// randomLevels takes the floor of log2(1/(1-f)),
+	// and Float64 uses the low 53 bits
+	return uint64((1 - 0.75*math.Pow(2, -float64(l))) * (1 << 53))
+}
+
+func (s *DOTSuite) TestLevelSource(c *C) {
+	m := NewMap(compareInts)
+	m.rnd.r = rand.New(&levelSource{levels: []int{1, 2, 3, 7}})
+	for _, want := range []int{1, 2, 3, 7, 1} {
+		c.Assert(randomLevels(m), Equals, want)
+	}
+}
+
+func (s *DOTSuite) TestWriteDOT(c *C) {
+	m := NewMap(compareStrings)
+	m.rnd.r = rand.New(&levelSource{levels: []int{1, 3, 1, 2, 1, 1}})
+	for _, k := range []string{"a", "b", "c", "d", "e", "{x|y}"} {
+		m.Put(k, nil)
+	}
+	var buf bytes.Buffer
+	c.Assert(m.WriteDOT(&buf, func(k interface{}) string { return k.(string) }), IsNil)
+	if *updateGolden {
+		c.Assert(ioutil.WriteFile("testdata/map.dot", buf.Bytes(), 0644), IsNil)
+	}
+	golden, err := ioutil.ReadFile("testdata/map.dot")
+	c.Assert(err, IsNil)
+	c.Assert(buf.String(), Equals, string(golden))
+}
+
+func (s *DOTSuite) TestWriteDOTEmpty(c *C) {
+	var buf bytes.Buffer
+	c.Assert(NewMap(compareInts).WriteDOT(&buf, nil), IsNil)
+	c.Assert(buf.String(), Equals, "digraph skiplist {\n\trankdir=LR;\n\tnode [shape=record];\n\thead [label=\"{head}\"];\n}\n")
+}
+
+func (s *DOTSuite) TestWriteDOTTooLarge(c *C) {
+	var buf bytes.Buffer
+	c.Assert(fillMap(dotLimit+1).WriteDOT(&buf, func(k interface{}) string { return fmt.Sprint(k) }), Equals, ErrTooLarge)
+	c.Assert(buf.Len(), Equals, 0)
+}
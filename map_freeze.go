@@ -0,0 +1,72 @@
+package skiplist
+
+import (
+	"unsafe"
+)
+
+// Freeze makes the map read only for good: from then on anything that
+// would change it panics, and reads no longer take the lock. freezing
+// takes the write lock, so every change made before it is seen by
+// reads made after it
+func (m *Map) Freeze() {
+	m.checkMade()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.frozen.Store(true)
+}
+
+// Frozen returns true if the map has been frozen
+func (m *Map) Frozen() bool {
+	return m.frozen.Load()
+}
+
+// checkMade panics if the map was not made by a constructor. a zero
+// Map has no comparison function, so unlike OrderedMap it can't set
+// itself up, and would otherwise fail on a nil pointer
+func (m *Map) checkMade() {
+	if m.mutex == nil {
+		panic("skiplist: Map used without NewMap, its zero value is not usable")
+	}
+}
+
+// lock takes the write lock, panicking if the map is frozen. frozen
+// is checked with the lock held, so a writer that was waiting for it
+// while the map was frozen can't slip in a change
+func (m *Map) lock() {
+	m.checkMade()
+	m.callback.check()
+	m.mutex.Lock()
+	if m.frozen.Load() {
+		m.mutex.Unlock()
+		panic("skiplist: change to a frozen map")
+	}
+}
+
+// rlock takes the read lock unless the map is frozen, returning
+// whether it did so that runlock can let it go
+func (m *Map) rlock() bool {
+	m.checkMade()
+	if m.frozen.Load() {
+		return false
+	}
+	m.callback.check()
+	m.mutex.RLock()
+	return true
+}
+
+// runlock lets go of the read lock if rlock took it
+func (m *Map) runlock(locked bool) {
+	if locked {
+		m.mutex.RUnlock()
+	}
+}
+
+// inAddressOrder returns a and b lowest address first, the order to
+// take the locks of two maps in, so that calls locking the same maps
+// in opposite orders can't deadlock
+func inAddressOrder(a, b *Map) (first, second *Map) {
+	if uintptr(unsafe.Pointer(b)) < uintptr(unsafe.Pointer(a)) {
+		return b, a
+	}
+	return a, b
+}
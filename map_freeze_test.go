@@ -0,0 +1,100 @@
+package skiplist
+
+import (
+	"runtime"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type FreezeSuite struct{}
+
+var _ = Suite(&FreezeSuite{})
+
+func (s *FreezeSuite) TestFreeze(c *C) {
+	m := fillMap(100)
+	c.Assert(m.Frozen(), Equals, false)
+	m.Freeze()
+	c.Assert(m.Frozen(), Equals, true)
+	v, ok := m.Get(10)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 20)
+	c.Assert(m.Len(), Equals, 100)
+	c.Assert(m.Entries(), DeepEquals, fillMap(100).Entries())
+	k, _, _ := m.GetByRank(99)
+	c.Assert(k, Equals, 99)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *FreezeSuite) TestFrozenWritesPanic(c *C) {
+	m := fillMap(10)
+	m.Freeze()
+	for _, fn := range []func(){
+		func() { m.Put(1, 1) },
+		func() { m.Put(100, 1) },
+		func() { m.Remove(1) },
+		func() { m.Clear() },
+		func() { m.PopMinN(1) },
+		func() { m.Split(5) },
+		func() { Concat(NewMap(compareInts), m) },
+		func() { m.Transaction(func(tx *Txn) {}) },
+	} {
+		c.Assert(fn, PanicMatches, "skiplist: change to a frozen map")
+	}
+	c.Assert(m.Entries(), DeepEquals, fillMap(10).Entries())
+	// the lock was let go each time
+	m.mutex.Lock()
+	m.mutex.Unlock()
+}
+
+func (s *FreezeSuite) TestFreezeConcurrentReads(c *C) {
+	m := NewMap(compareInts)
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	m.Freeze()
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			for i := 0; i < 1000; i++ {
+				if v, ok := m.Get(i); !ok || v != i {
+					c.Errorf("get %d: %v %v", i, v, ok)
+				}
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkGetFrozen shares c.N Gets among a goroutine per CPU,
+// where the read lock's shared counter costs the most
+func benchmarkGetFrozen(c *C, freeze bool) {
+	c.StopTimer()
+	m := fillMap(1000)
+	if freeze {
+		m.Freeze()
+	}
+	procs := runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	c.StartTimer()
+	for g := 0; g < procs; g++ {
+		wg.Add(1)
+		go func(g int) {
+			for i := g; i < c.N; i += procs {
+				m.Get(i % 1000)
+			}
+			wg.Done()
+		}(g)
+	}
+	wg.Wait()
+}
+
+func (s *FreezeSuite) BenchmarkGet(c *C) {
+	benchmarkGetFrozen(c, false)
+}
+
+func (s *FreezeSuite) BenchmarkGetFrozen(c *C) {
+	benchmarkGetFrozen(c, true)
+}
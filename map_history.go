@@ -0,0 +1,87 @@
+package skiplist
+
+import (
+	"time"
+)
+
+// Versioned is a value written to a key and when it was written,
+// as returned by History
+type Versioned struct {
+	Val  interface{}
+	Time time.Time
+}
+
+// valueRing holds the last few values written to a pair, the oldest
+// being overwritten once it is full. next is where the next value goes
+type valueRing struct {
+	vals []Versioned
+	next int
+}
+
+// WithHistory makes every pair remember the last n values written to
+// it and when, to be read back with History. it costs nothing per pair
+// when it isn't given
+func WithHistory(n int) Option {
+	return func(m *Map) {
+		m.history = n
+	}
+}
+
+// add writes v into the ring, which holds at most n values
+func (r *valueRing) add(v Versioned, n int) {
+	if len(r.vals) < n {
+		r.vals = append(r.vals, v)
+		return
+	}
+	r.vals[r.next] = v
+	r.next = (r.next + 1) % n
+}
+
+// newestFirst returns a copy of the values in the ring, newest first
+func (r *valueRing) newestFirst() []Versioned {
+	n := len(r.vals)
+	ret := make([]Versioned, n)
+	for i := range ret {
+		ret[i] = r.vals[(r.next-1-i+2*n)%n]
+	}
+	return ret
+}
+
+// remember records the value of e in its history if the map keeps
+// one, the caller must hold the write lock
+func (m *Map) remember(e *mapElement) {
+	if m.history <= 0 {
+		return
+	}
+	if e.past == nil {
+		e.past = &valueRing{vals: make([]Versioned, 0, m.history)}
+	}
+	e.past.add(Versioned{e.val, time.Now()}, m.history)
+}
+
+// changed records a new value written to e over an old one, the
+// caller must hold the write lock
+func (m *Map) changed(e *mapElement) {
+	e.version++
+	m.remember(e)
+}
+
+// History returns the values last written to the pair for a key, up
+// to the number given to WithHistory, newest first, so the first is the
+// value the key holds now. removing a key forgets its history. it
+// returns an empty slice if the key isn't in the map or the map keeps
+// no history. in a multimap it is the history of the first pair for k
+func (m *Map) History(k interface{}) []Versioned {
+	defer m.endOp("history", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return []Versioned{}
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := m.find(k, nil)
+	if e == nil || e.past == nil {
+		return []Versioned{}
+	}
+	return e.past.newestFirst()
+}
@@ -0,0 +1,69 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type HistorySuite struct{}
+
+var _ = Suite(&HistorySuite{})
+
+// historyVals returns the values History gives for k, checking
+// their times go back from newest to oldest
+func historyVals(c *C, m *Map, k interface{}) []interface{} {
+	ret := []interface{}{}
+	h := m.History(k)
+	for i, v := range h {
+		if i > 0 {
+			c.Assert(v.Time.After(h[i-1].Time), Equals, false)
+		}
+		ret = append(ret, v.Val)
+	}
+	return ret
+}
+
+func (s *HistorySuite) TestRing(c *C) {
+	m := NewMap(compareInts, WithHistory(3))
+	c.Assert(historyVals(c, m, 1), HasLen, 0)
+	m.Put(1, "a")
+	c.Assert(historyVals(c, m, 1), DeepEquals, []interface{}{"a"})
+	m.Put(1, "b")
+	m.Put(1, "c")
+	c.Assert(historyVals(c, m, 1), DeepEquals, []interface{}{"c", "b", "a"})
+	// only the last three are kept
+	for _, v := range []string{"d", "e", "f", "g"} {
+		m.Put(1, v)
+	}
+	c.Assert(historyVals(c, m, 1), DeepEquals, []interface{}{"g", "f", "e"})
+	m.Put(2, "x")
+	c.Assert(historyVals(c, m, 2), DeepEquals, []interface{}{"x"})
+	// removing forgets it all
+	m.Remove(1)
+	c.Assert(historyVals(c, m, 1), HasLen, 0)
+	m.Put(1, "h")
+	c.Assert(historyVals(c, m, 1), DeepEquals, []interface{}{"h"})
+	// and without the option there is none
+	plain := NewMap(compareInts)
+	plain.Put(1, "a")
+	c.Assert(historyVals(c, plain, 1), HasLen, 0)
+}
+
+func (s *HistorySuite) TestOtherWrites(c *C) {
+	sm := NewSyncMap(compareInts, WithHistory(4), WithTombstones())
+	m := sm.Map()
+	sm.Store(1, 10)
+	c.Assert(sm.CompareAndSwap(1, 10, 11), Equals, true)
+	c.Assert(sm.CompareAndSwap(1, 10, 12), Equals, false)
+	m.PutOrMerge(1, 5, func(existing, incoming interface{}) interface{} {
+		return existing.(int) + incoming.(int)
+	})
+	m.ReplaceIf(func(k, v interface{}) bool { return true }, func(k, old interface{}) interface{} { return old.(int) * 2 })
+	c.Assert(historyVals(c, m, 1), DeepEquals, []interface{}{32, 16, 11, 10})
+	sm.Store(2, 20)
+	m.SwapValues(1, 2)
+	c.Assert(historyVals(c, m, 2), DeepEquals, []interface{}{32, 20})
+	// a tombstone forgets its history too
+	sm.Delete(2)
+	sm.Store(2, 21)
+	c.Assert(historyVals(c, m, 2), DeepEquals, []interface{}{21})
+}
@@ -0,0 +1,74 @@
+package skiplist
+
+// hashIndex maps keys to their elements for constant time Gets,
+// it is kept in step with the list under the map's write lock
+type hashIndex struct {
+	hash    func(k interface{}) uint64
+	eq      func(a, b interface{}) bool
+	buckets map[uint64][]*mapElement
+}
+
+// WithHashIndex keeps a hash index of the keys alongside the list,
+// so Get becomes a hash lookup rather than a search, while ordered
+// operations keep using the list. hash and eq must agree with each
+// other and with the comparator: keys equal by the comparator must be
+// equal by eq and have the same hash. it has no effect on a multimap
+func WithHashIndex(hash func(k interface{}) uint64, eq func(a, b interface{}) bool) Option {
+	return func(m *Map) {
+		m.index = &hashIndex{hash, eq, map[uint64][]*mapElement{}}
+	}
+}
+
+func (ix *hashIndex) get(k interface{}) *mapElement {
+	for _, e := range ix.buckets[ix.hash(k)] {
+		if ix.eq(k, e.key) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (ix *hashIndex) add(e *mapElement) {
+	h := ix.hash(e.key)
+	ix.buckets[h] = append(ix.buckets[h], e)
+}
+
+func (ix *hashIndex) remove(k interface{}) {
+	h := ix.hash(k)
+	bucket := ix.buckets[h]
+	for i, e := range bucket {
+		if ix.eq(k, e.key) {
+			if len(bucket) == 1 {
+				delete(ix.buckets, h)
+			} else {
+				bucket[i] = bucket[len(bucket)-1]
+				bucket[len(bucket)-1] = nil
+				ix.buckets[h] = bucket[:len(bucket)-1]
+			}
+			return
+		}
+	}
+}
+
+func (ix *hashIndex) clear() {
+	ix.buckets = map[uint64][]*mapElement{}
+}
+
+// added records a new element in the index and its history and
+// tells any watchers, the caller must hold the write lock
+func (m *Map) added(e *mapElement) {
+	m.remember(e)
+	if m.index != nil && !m.multi {
+		m.index.add(e)
+	}
+	m.notify(OpInsert, e.key, nil, e.val)
+}
+
+// removed drops a removed pair from the index and tells any
+// watchers, the caller must hold the write lock
+func (m *Map) removed(k, v interface{}) {
+	if m.index != nil && !m.multi {
+		m.index.remove(k)
+	}
+	m.notify(OpDelete, k, v, nil)
+}
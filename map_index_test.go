@@ -0,0 +1,64 @@
+package skiplist
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type IndexSuite struct{}
+
+var _ = Suite(&IndexSuite{})
+
+// hashInt is a deliberately poor hash so that buckets collide
+func hashInt(k interface{}) uint64 { return uint64(k.(int) % 97) }
+
+func (s *IndexSuite) TestHashIndexGet(c *C) {
+	m := NewMap(compareInts, WithHashIndex(hashInt, equalInts))
+	for i := 0; i < 1000; i++ {
+		m.Put(i, i*2)
+	}
+	for i := 0; i < 1000; i++ {
+		x, ok := m.Get(i)
+		c.Assert(ok, Equals, true)
+		c.Assert(x, Equals, i*2)
+	}
+	_, ok := m.Get(1000)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *IndexSuite) TestHashIndexRandom(c *C) {
+	m := NewMap(compareInts, WithHashIndex(hashInt, equalInts))
+	ref := NewMap(compareInts)
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 20000; i++ {
+		k := r.Intn(500)
+		switch r.Intn(10) {
+		case 0:
+			m.PopMinN(3)
+			ref.PopMinN(3)
+		case 1:
+			m.PopMaxN(2)
+			ref.PopMaxN(2)
+		case 2, 3, 4:
+			c.Assert(m.Remove(k), Equals, ref.Remove(k))
+		case 5:
+			c.Assert(m.ReKey(k, k+1), Equals, ref.ReKey(k, k+1))
+		default:
+			c.Assert(m.Put(k, i), Equals, ref.Put(k, i))
+		}
+		k = r.Intn(500)
+		x, ok := m.Get(k)
+		y, refOk := ref.Get(k)
+		c.Assert(ok, Equals, refOk)
+		c.Assert(x, Equals, y)
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Keys(), DeepEquals, ref.Keys())
+	m.Put(1, 1)
+	m.Clear()
+	_, ok := m.Get(1)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.index.buckets, HasLen, 0)
+}
@@ -0,0 +1,128 @@
+package skiplist
+
+import (
+	"sync"
+)
+
+// Iterator walks a map in key order without holding the lock between
+// steps, so the map can change under it. it never returns a pair
+// twice: if the map changes it carries on after the last key it
+// returned, which in a multimap skips the rest of that key's pairs.
+// Stale tells whether that has happened
+type Iterator struct {
+	m       *Map
+	e       *mapElement
+	key     interface{}
+	val     interface{}
+	started bool
+	// version is the map's version when the iterator was made,
+	// seen its version when e was last read
+	version uint64
+	seen    uint64
+}
+
+// iteratorPool holds iterators given back by Release
+var iteratorPool = sync.Pool{New: func() interface{} { return new(Iterator) }}
+
+// Iterator returns an iterator positioned before the first pair. it
+// comes from a pool, and giving it back with Release once done saves
+// allocating one next time
+func (m *Map) Iterator() *Iterator {
+	it := iteratorPool.Get().(*Iterator)
+	it.Reset(m)
+	return it
+}
+
+// Reset moves the iterator back before the first pair of m, which
+// need not be the map it was iterating, as if it had just been made
+func (it *Iterator) Reset(m *Map) {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	*it = Iterator{m: m, version: m.version, seen: m.version}
+}
+
+// Release gives the iterator back to the pool Iterator draws from.
+// it must not be used afterwards, not even to Reset
+func (it *Iterator) Release() {
+	*it = Iterator{}
+	iteratorPool.Put(it)
+}
+
+// Next moves the iterator to the next pair, returning true
+// if there is one, false at the end of the map
+func (it *Iterator) Next() bool {
+	m := it.m
+	locked := m.rlock()
+	defer m.runlock(locked)
+	switch {
+	case !it.started:
+		it.e = m.head.next[0]
+		it.started = true
+	case it.e == nil:
+	case it.seen != m.version:
+		// e may no longer be in the map
+		it.e = m.upperBound(it.key, nil)
+	default:
+		it.e = it.e.next[0]
+	}
+	it.e = liveFrom(it.e)
+	it.seen = m.version
+	if it.e != nil {
+		it.key, it.val = it.e.key, it.e.val
+	} else {
+		it.key, it.val = nil, nil
+	}
+	return it.e != nil
+}
+
+// Key returns the key of the pair the iterator is at
+func (it *Iterator) Key() interface{} {
+	return it.key
+}
+
+// Val returns the value of the pair the iterator is at
+func (it *Iterator) Val() interface{} {
+	return it.val
+}
+
+// Stale returns true if the map has changed since the iterator was
+// made, so the pairs it returns may not all have been in the map at
+// the same time
+func (it *Iterator) Stale() bool {
+	locked := it.m.rlock()
+	defer it.m.runlock(locked)
+	return it.m.version != it.version
+}
+
+// CopyRange puts into dst every pair from the one from is at up to but
+// not including the one to is at, in key order. from and to must be
+// iterators over the same map, it panics otherwise. a from that hasn't
+// started copies from the first pair and a to that has run off the end
+// copies to the last, while a from at the end or a to that hasn't
+// started copies nothing. the pairs are found by the keys the iterators
+// are at, so in a multimap every pair for from's key is copied. they
+// are read under the source's read lock and put after it is let go, so
+// dst may be the source map
+func CopyRange(dst *Map, from, to *Iterator) {
+	if from.m != to.m {
+		panic("skiplist: CopyRange iterators are over different maps")
+	}
+	if from.started && from.e == nil || !to.started {
+		return
+	}
+	var lo, hi interface{}
+	if from.started {
+		lo = from.key
+	}
+	if to.e != nil {
+		hi = to.key
+	}
+	pairs := []Entry{}
+	from.m.Range(lo, hi, func(k, v interface{}) bool {
+		pairs = append(pairs, Entry{k, v})
+		return true
+	})
+	for _, p := range pairs {
+		dst.Put(p.Key, p.Val)
+	}
+}
@@ -0,0 +1,169 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type IteratorSuite struct{}
+
+var _ = Suite(&IteratorSuite{})
+
+func (s *IteratorSuite) TestIterate(c *C) {
+	m := fillMap(100)
+	it := m.Iterator()
+	n := 0
+	for it.Next() {
+		c.Assert(it.Key(), Equals, n)
+		c.Assert(it.Val(), Equals, n*2)
+		n++
+	}
+	c.Assert(n, Equals, 100)
+	c.Assert(it.Next(), Equals, false)
+	c.Assert(it.Key(), IsNil)
+	c.Assert(it.Stale(), Equals, false)
+	c.Assert(NewMap(compareInts).Iterator().Next(), Equals, false)
+}
+
+func (s *IteratorSuite) TestStale(c *C) {
+	m := fillMap(10)
+	it := m.Iterator()
+	it.Next()
+	it.Next()
+	c.Assert(it.Stale(), Equals, false)
+	m.Get(5)
+	c.Assert(it.Stale(), Equals, false)
+	// removing the pair the iterator is at doesn't lose its place
+	m.Remove(1)
+	c.Assert(it.Stale(), Equals, true)
+	m.Put(-1, 0)
+	m.Put(5, 0)
+	keys := []interface{}{}
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	c.Assert(keys, DeepEquals, []interface{}{2, 3, 4, 5, 6, 7, 8, 9})
+	c.Assert(it.Stale(), Equals, true)
+	c.Assert(m.Iterator().Stale(), Equals, false)
+}
+
+func (s *IteratorSuite) TestStaleFrozen(c *C) {
+	m := fillMap(10)
+	it := m.Iterator()
+	it.Next()
+	m.Freeze()
+	// reads of a frozen map take no lock, so Stale doesn't wait on it
+	m.mutex.Lock()
+	c.Assert(it.Stale(), Equals, false)
+	m.mutex.Unlock()
+}
+
+func (s *IteratorSuite) TestStaleAfterClear(c *C) {
+	m := fillMap(10)
+	it := m.Iterator()
+	it.Next()
+	m.ClearAndRecycle()
+	c.Assert(it.Stale(), Equals, true)
+	m.Put(100, 1)
+	c.Assert(it.Next(), Equals, true)
+	c.Assert(it.Key(), Equals, 100)
+	c.Assert(it.Next(), Equals, false)
+}
+
+func (s *IteratorSuite) TestReset(c *C) {
+	m := fillMap(3)
+	it := m.Iterator()
+	for it.Next() {
+	}
+	m.Put(10, 0)
+	c.Assert(it.Stale(), Equals, true)
+	it.Reset(m)
+	c.Assert(it.Stale(), Equals, false)
+	keys := []interface{}{}
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	c.Assert(keys, DeepEquals, []interface{}{0, 1, 2, 10})
+	other := NewMap(compareStrings)
+	other.Put("a", 1)
+	it.Reset(other)
+	c.Assert(it.Next(), Equals, true)
+	c.Assert(it.Key(), Equals, "a")
+	it.Release()
+	it = m.Iterator()
+	c.Assert(it.Next(), Equals, true)
+	c.Assert(it.Key(), Equals, 0)
+	it.Release()
+}
+
+func iterateFirst(c *C, it *Iterator) {
+	if !it.Next() || it.Key() != 0 {
+		c.Fatal("bad iterator")
+	}
+}
+
+// advance moves it on n pairs
+func advance(it *Iterator, n int) *Iterator {
+	for i := 0; i < n; i++ {
+		it.Next()
+	}
+	return it
+}
+
+func (s *IteratorSuite) TestCopyRange(c *C) {
+	m := fillMap(100)
+	// from at 20, to at 30
+	from, to := advance(m.Iterator(), 21), advance(m.Iterator(), 31)
+	dst := NewMap(compareInts)
+	dst.Put(500, 0)
+	CopyRange(dst, from, to)
+	want := []interface{}{}
+	for i := 20; i < 30; i++ {
+		want = append(want, i)
+	}
+	c.Assert(dst.Keys(), DeepEquals, append(want, 500))
+	v, _ := dst.Get(25)
+	c.Assert(v, Equals, 50)
+	// the iterators are left where they were
+	c.Assert(from.Key(), Equals, 20)
+	c.Assert(to.Key(), Equals, 30)
+
+	// an unstarted from copies from the start, a finished to to the end
+	dst = NewMap(compareInts)
+	CopyRange(dst, m.Iterator(), to)
+	c.Assert(dst.Len(), Equals, 30)
+	dst = NewMap(compareInts)
+	CopyRange(dst, advance(m.Iterator(), 91), advance(m.Iterator(), 101))
+	c.Assert(dst.Len(), Equals, 10)
+	dst = NewMap(compareInts)
+	CopyRange(dst, from, m.Iterator())
+	CopyRange(dst, advance(m.Iterator(), 101), advance(m.Iterator(), 101))
+	CopyRange(dst, to, from)
+	c.Assert(dst.Len(), Equals, 0)
+
+	c.Assert(func() { CopyRange(dst, from, fillMap(10).Iterator()) }, PanicMatches, ".*different maps")
+}
+
+func (s *IteratorSuite) BenchmarkIteratorNew(c *C) {
+	m := fillMap(100)
+	for i := 0; i < c.N; i++ {
+		iterateFirst(c, m.Iterator())
+	}
+}
+
+func (s *IteratorSuite) BenchmarkIteratorRelease(c *C) {
+	m := fillMap(100)
+	for i := 0; i < c.N; i++ {
+		it := m.Iterator()
+		iterateFirst(c, it)
+		it.Release()
+	}
+}
+
+func (s *IteratorSuite) BenchmarkIteratorReset(c *C) {
+	m := fillMap(100)
+	it := m.Iterator()
+	for i := 0; i < c.N; i++ {
+		it.Reset(m)
+		iterateFirst(c, it)
+	}
+}
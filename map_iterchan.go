@@ -0,0 +1,99 @@
+package skiplist
+
+import (
+	"context"
+)
+
+// iterChanBatch is how many pairs IterChan copies per lock
+const iterChanBatch = 64
+
+// IterChan streams the pairs of the map in key order on a channel
+// from its own goroutine, closing the channel when the map is exhausted
+// or ctx is done. the goroutine copies a batch of pairs under the read
+// lock and sends them with the lock released, resuming after the last
+// key sent, so writers are never held up by a slow consumer. the stream
+// is weakly consistent: keys arrive in increasing order, each at most
+// once, but changes made during the stream may or may not be seen. ctx
+// is how a consumer gives up on the stream: one that may stop reading
+// before the channel is closed must cancel ctx when it does, or the
+// goroutine is left blocked sending for good
+func (m *Map) IterChan(ctx context.Context, buffer int) <-chan Entry {
+	ch := make(chan Entry, buffer)
+	go func() {
+		defer close(ch)
+		var last interface{}
+		batch := make([]Entry, 0, iterChanBatch)
+		for {
+			batch = m.nextBatch(batch[:0], last, iterChanBatch)
+			if len(batch) == 0 {
+				return
+			}
+			for _, e := range batch {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = batch[len(batch)-1].Key
+		}
+	}()
+	return ch
+}
+
+// ChanChunked streams the pairs of the map in key order on a channel
+// in chunks of chunkSize, from its own goroutine, closing the channel
+// when the map is exhausted or ctx is done. each chunk is copied under
+// the read lock, which is let go before the chunk is sent, so writers
+// make progress between chunks however slow the consumer is, and no
+// more than a chunk or two of pairs are held in memory at once. chunks
+// in a multimap can be longer, to hold every pair for their last key.
+// the stream is weakly consistent like IterChan's: keys arrive in
+// increasing order, each at most once, but changes made during the
+// stream may or may not be seen. each chunk is the consumer's to keep.
+// a consumer that may stop reading early must cancel ctx when it does,
+// as for IterChan. it panics if chunkSize is not positive
+func (m *Map) ChanChunked(ctx context.Context, chunkSize int) <-chan []Entry {
+	if chunkSize <= 0 {
+		panic("skiplist: chunk size must be positive")
+	}
+	ch := make(chan []Entry)
+	go func() {
+		defer close(ch)
+		var last interface{}
+		for {
+			chunk := m.nextBatch(make([]Entry, 0, chunkSize), last, chunkSize)
+			if len(chunk) == 0 {
+				return
+			}
+			select {
+			case ch <- chunk:
+			case <-ctx.Done():
+				return
+			}
+			last = chunk[len(chunk)-1].Key
+		}
+	}()
+	return ch
+}
+
+// nextBatch appends about size pairs with keys after last to batch,
+// a nil last meaning from the start. a batch always holds every pair
+// for its last key, so resuming after it skips nothing in a multimap
+func (m *Map) nextBatch(batch []Entry, last interface{}, size int) []Entry {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	var e *mapElement
+	if last == nil {
+		e = m.head.next[0]
+	} else {
+		e = m.upperBound(last, nil)
+	}
+	for e = liveFrom(e); e != nil; e = liveFrom(e.next[0]) {
+		if len(batch) >= size && m.comp(batch[len(batch)-1].Key, e.key) {
+			break
+		}
+		batch = append(batch, Entry{e.key, e.val})
+	}
+	return batch
+}
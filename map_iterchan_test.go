@@ -0,0 +1,123 @@
+package skiplist
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type IterChanSuite struct{}
+
+var _ = Suite(&IterChanSuite{})
+
+// waitGoroutines waits for the number of goroutines to drop to n
+func waitGoroutines(n int) bool {
+	for i := 0; i < 100; i++ {
+		if runtime.NumGoroutine() <= n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func (s *IterChanSuite) TestIterChan(c *C) {
+	m := fillMap(1000)
+	got := []Entry{}
+	for e := range m.IterChan(context.Background(), 10) {
+		got = append(got, e)
+	}
+	c.Assert(got, DeepEquals, sortedEntries(1000))
+	n := 0
+	for range NewMap(compareInts).IterChan(context.Background(), 0) {
+		n++
+	}
+	c.Assert(n, Equals, 0)
+}
+
+func (s *IterChanSuite) TestIterChanMultiMap(c *C) {
+	m := NewMultiMap(compareInts)
+	for i := 0; i < 1000; i++ {
+		m.Put(i%3, i)
+	}
+	got := []Entry{}
+	for e := range m.IterChan(context.Background(), 0) {
+		got = append(got, e)
+	}
+	c.Assert(got, DeepEquals, m.FirstN(1000))
+}
+
+// writers make progress while the consumer holds the stream open
+func (s *IterChanSuite) TestIterChanWeaklyConsistent(c *C) {
+	m := fillMap(1000)
+	ch := m.IterChan(context.Background(), 0)
+	prev := -1
+	for e := range ch {
+		k := e.Key.(int)
+		c.Assert(k > prev, Equals, true)
+		prev = k
+		if k < 1000 && k%100 == 0 {
+			m.Put(k+1000, 0)
+			m.Remove(k + 50)
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *IterChanSuite) TestIterChanAbandoned(c *C) {
+	goroutines := runtime.NumGoroutine()
+	m := fillMap(10000)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := m.IterChan(ctx, 0)
+	for i := 0; i < 10; i++ {
+		<-ch
+	}
+	// walk away without draining, the goroutine is blocked sending
+	cancel()
+	c.Assert(waitGoroutines(goroutines), Equals, true)
+	// the channel is closed once the goroutine has gone
+	for range ch {
+	}
+	m.Put(-1, 0)
+}
+
+func (s *IterChanSuite) TestChanChunked(c *C) {
+	m := fillMap(1000)
+	prev := -1
+	chunks := 0
+	for chunk := range m.ChanChunked(context.Background(), 64) {
+		chunks++
+		// memory held per chunk stays bounded as the map grows
+		c.Assert(len(chunk) <= 64, Equals, true)
+		for _, e := range chunk {
+			k := e.Key.(int)
+			c.Assert(k > prev, Equals, true)
+			prev = k
+			// the lock is free while the consumer works
+			if k < 1000 && k%10 == 0 {
+				m.Put(k+5000, k)
+			}
+		}
+	}
+	// later chunks see some of the keys put while streaming
+	c.Assert(prev >= 5000, Equals, true)
+	c.Assert(chunks > 1000/64, Equals, true)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(func() { m.ChanChunked(context.Background(), 0) }, PanicMatches, ".*chunk size.*")
+}
+
+func (s *IterChanSuite) TestChanChunkedAbandoned(c *C) {
+	goroutines := runtime.NumGoroutine()
+	m := fillMap(1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := m.ChanChunked(ctx, 10)
+	<-ch
+	cancel()
+	c.Assert(waitGoroutines(goroutines), Equals, true)
+	// the channel is closed once the goroutine has gone
+	for range ch {
+	}
+	m.Put(-1, 0)
+}
@@ -0,0 +1,42 @@
+package skiplist
+
+// Join walks a and b, which must be ordered the same way, side by side
+// in one pass, calling onMatch for each key in both maps, onA for each
+// key only in a and onB for each key only in b, in key order. any of
+// the callbacks may be nil to skip those keys. in multimaps the pairs
+// for a key are matched up in order, like Diff. both read locks are
+// held throughout, taken in address order like Concat's, so the
+// callbacks must not use either map
+func Join(a, b *Map, onMatch func(k, va, vb interface{}), onA func(k, va interface{}), onB func(k, vb interface{})) {
+	first, second := inAddressOrder(a, b)
+	firstLocked := first.rlock()
+	defer first.runlock(firstLocked)
+	secondLocked := false
+	if second != first {
+		secondLocked = second.rlock()
+	}
+	defer second.runlock(secondLocked)
+	aID, bID := a.callback.enter(), b.callback.enter()
+	defer a.callback.leave(aID)
+	defer b.callback.leave(bID)
+	x, y := liveFrom(a.head.next[0]), liveFrom(b.head.next[0])
+	for x != nil || y != nil {
+		switch {
+		case y == nil || x != nil && a.comp(x.key, y.key):
+			if onA != nil {
+				onA(x.key, x.val)
+			}
+			x = liveFrom(x.next[0])
+		case x == nil || a.comp(y.key, x.key):
+			if onB != nil {
+				onB(y.key, y.val)
+			}
+			y = liveFrom(y.next[0])
+		default:
+			if onMatch != nil {
+				onMatch(x.key, x.val, y.val)
+			}
+			x, y = liveFrom(x.next[0]), liveFrom(y.next[0])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type JoinSuite struct{}
+
+var _ = Suite(&JoinSuite{})
+
+// joinRow is a row of a join, a nil side meaning the key was missing
+type joinRow struct {
+	Key  interface{}
+	A, B interface{}
+}
+
+func joinMaps() (*Map, *Map) {
+	a, b := NewMap(compareInts), NewMap(compareInts)
+	for _, k := range []int{1, 3, 4, 7, 9} {
+		a.Put(k, k*10)
+	}
+	for _, k := range []int{0, 3, 5, 7, 10} {
+		b.Put(k, k*100)
+	}
+	return a, b
+}
+
+func (s *JoinSuite) TestInnerJoin(c *C) {
+	a, b := joinMaps()
+	rows := []joinRow{}
+	Join(a, b, func(k, va, vb interface{}) {
+		rows = append(rows, joinRow{k, va, vb})
+	}, nil, nil)
+	c.Assert(rows, DeepEquals, []joinRow{{3, 30, 300}, {7, 70, 700}})
+}
+
+func (s *JoinSuite) TestFullOuterJoin(c *C) {
+	a, b := joinMaps()
+	rows := []joinRow{}
+	Join(a, b, func(k, va, vb interface{}) {
+		rows = append(rows, joinRow{k, va, vb})
+	}, func(k, va interface{}) {
+		rows = append(rows, joinRow{k, va, nil})
+	}, func(k, vb interface{}) {
+		rows = append(rows, joinRow{k, nil, vb})
+	})
+	c.Assert(rows, DeepEquals, []joinRow{
+		{0, nil, 0}, {1, 10, nil}, {3, 30, 300}, {4, 40, nil},
+		{5, nil, 500}, {7, 70, 700}, {9, 90, nil}, {10, nil, 1000},
+	})
+
+	// a map joined with itself matches every key
+	n := 0
+	Join(a, a, func(k, va, vb interface{}) { n++ }, nil, nil)
+	c.Assert(n, Equals, a.Len())
+	c.Assert(func() {
+		Join(a, b, nil, func(k, va interface{}) { b.Put(k, va) }, nil)
+	}, PanicMatches, "skiplist: reentrant call during callback")
+}
+
+func (s *JoinSuite) TestOppositeOrders(c *C) {
+	lowFirst(c, func(x, y *Map) { Join(x, y, nil, nil, nil) })
+}
@@ -0,0 +1,55 @@
+package skiplist
+
+// Change is a change recorded in a map's journal, numbered by Seq
+type Change struct {
+	Seq uint64
+	ChangeEvent
+}
+
+// journal keeps the latest changes to a map in a ring, the change
+// numbered seq being at buf[(seq-1)%len(buf)]
+type journal struct {
+	buf []Change
+	seq uint64
+}
+
+// WithJournal numbers every change to the map, counting from 1, and
+// keeps the latest size of them so ChangesSince can hand them out.
+// it panics if size is not positive
+func WithJournal(size int) Option {
+	if size <= 0 {
+		panic("skiplist: journal size must be positive")
+	}
+	return func(m *Map) {
+		m.journal = &journal{buf: make([]Change, size)}
+	}
+}
+
+func (j *journal) record(op ChangeOp, k, old, new interface{}) {
+	j.seq++
+	j.buf[(j.seq-1)%uint64(len(j.buf))] = Change{j.seq, ChangeEvent{op, k, old, new}}
+}
+
+// ChangesSince returns the changes numbered after seq in order and
+// the number of the latest change, so passing that back next time
+// picks up where this call left off. it returns false if the journal
+// no longer holds every change after seq, or if seq is from the
+// future, and the caller must copy the whole map instead. a map
+// without a journal always returns false
+func (m *Map) ChangesSince(seq uint64) ([]Change, uint64, bool) {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	j := m.journal
+	if j == nil {
+		return nil, 0, false
+	}
+	size, latest := uint64(len(j.buf)), j.seq
+	if seq > latest || latest-seq > size {
+		return nil, latest, false
+	}
+	ret := make([]Change, 0, latest-seq)
+	for s := seq + 1; s <= latest; s++ {
+		ret = append(ret, j.buf[(s-1)%size])
+	}
+	return ret, latest, true
+}
@@ -0,0 +1,97 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type JournalSuite struct{}
+
+var _ = Suite(&JournalSuite{})
+
+func (s *JournalSuite) TestChangesSince(c *C) {
+	m := NewMap(compareInts, WithJournal(10))
+	changes, seq, ok := m.ChangesSince(0)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, Equals, uint64(0))
+	c.Assert(changes, HasLen, 0)
+	m.Put(1, "a")
+	m.Put(2, "b")
+	m.Put(1, "c")
+	m.Remove(2)
+	m.Remove(3)
+	changes, seq, ok = m.ChangesSince(0)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, Equals, uint64(4))
+	c.Assert(changes, DeepEquals, []Change{
+		{1, ChangeEvent{OpInsert, 1, nil, "a"}},
+		{2, ChangeEvent{OpInsert, 2, nil, "b"}},
+		{3, ChangeEvent{OpUpdate, 1, "a", "c"}},
+		{4, ChangeEvent{OpDelete, 2, "b", nil}},
+	})
+	changes, _, ok = m.ChangesSince(3)
+	c.Assert(ok, Equals, true)
+	c.Assert(changes, HasLen, 1)
+	c.Assert(changes[0].Seq, Equals, uint64(4))
+	changes, _, ok = m.ChangesSince(4)
+	c.Assert(ok, Equals, true)
+	c.Assert(changes, HasLen, 0)
+	_, _, ok = m.ChangesSince(5)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *JournalSuite) TestWraparound(c *C) {
+	m := NewMap(compareInts, WithJournal(10))
+	for i := 0; i < 25; i++ {
+		m.Put(i, i)
+	}
+	_, seq, ok := m.ChangesSince(14)
+	c.Assert(ok, Equals, false)
+	c.Assert(seq, Equals, uint64(25))
+	changes, _, ok := m.ChangesSince(15)
+	c.Assert(ok, Equals, true)
+	c.Assert(changes, HasLen, 10)
+	for i, ch := range changes {
+		c.Assert(ch.Seq, Equals, uint64(16+i))
+		c.Assert(ch.Key, Equals, 15+i)
+	}
+	// a replica applying the changes keeps up with the map
+	replica := NewMap(compareInts)
+	for _, e := range m.Entries() {
+		replica.Put(e.Key, e.Val)
+	}
+	for i := 0; i < 8; i++ {
+		m.Put(i*3, -i)
+		m.Remove(i * 5)
+		changes, seq, ok = m.ChangesSince(seq)
+		c.Assert(ok, Equals, true)
+		for _, ch := range changes {
+			if ch.Op == OpDelete {
+				replica.Remove(ch.Key)
+			} else {
+				replica.Put(ch.Key, ch.New)
+			}
+		}
+	}
+	c.Assert(replica.Entries(), DeepEquals, m.Entries())
+}
+
+func (s *JournalSuite) TestBulkChangesJournaled(c *C) {
+	m := NewMap(compareInts, WithJournal(100))
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+	}
+	m.Clear()
+	m.Put(1, 1)
+	m.Put(2, 2)
+	m.PopMinN(1)
+	changes, seq, ok := m.ChangesSince(5)
+	c.Assert(ok, Equals, true)
+	c.Assert(seq, Equals, uint64(13))
+	for i := 0; i < 5; i++ {
+		c.Assert(changes[i].ChangeEvent, DeepEquals, ChangeEvent{OpDelete, i, i, nil})
+	}
+	c.Assert(changes[7].ChangeEvent, DeepEquals, ChangeEvent{OpDelete, 1, 1, nil})
+	c.Assert(func() { WithJournal(0) }, PanicMatches, "skiplist: journal size must be positive")
+	_, _, ok = NewMap(compareInts).ChangesSince(0)
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,54 @@
+package skiplist
+
+// PutOrMerge puts v in the map for k if k isn't there, otherwise it
+// replaces the existing value with merge(existing, v). it searches once
+// and holds the write lock throughout, so concurrent PutOrMerges of the
+// same key each see the others' results and none are lost. merge must
+// not use the map. returns true if it merged, false if it inserted.
+// in a multimap the first pair for k is merged into.
+// it panics if k is nil
+func (m *Map) PutOrMerge(k, v interface{}, merge func(existing, incoming interface{}) interface{}) bool {
+	defer m.endOp("putormerge", k, m.startOp())
+	k = m.normal(k)
+	checkKey(k)
+	merged, v, evicted, hooks := m.mergeLocked(k, v, merge)
+	op := "put"
+	if merged {
+		op = "overwrite"
+	}
+	for _, fn := range hooks {
+		fn(op, k, v)
+	}
+	m.evicted(evicted)
+	return merged
+}
+
+// mergeLocked does the part of PutOrMerge done under the write lock,
+// returning whether it merged, the value left in the map and what is
+// needed once the lock is let go
+func (m *Map) mergeLocked(k, v interface{}, merge func(existing, incoming interface{}) interface{}) (bool, interface{}, []Entry, []func(op string, k, v interface{})) {
+	m.lock()
+	defer m.mutex.Unlock()
+	var backPointer [maxHeight]*mapElement
+	e := m.find(k, backPointer[:])
+	if e != nil {
+		v = m.merged(e.val, v, merge)
+		old := e.val
+		e.val = v
+		m.changed(e)
+		m.notify(OpUpdate, k, old, v)
+	} else if b := m.buried(k, backPointer[:]); b != nil {
+		m.revive(b, v, backPointer[:])
+	} else {
+		// with no pairs for k, backPointer is its place even in a multimap
+		m.link(m.newElement(k, v, randomLevels(m)), backPointer[:])
+	}
+	return e != nil, v, m.evict(k), m.onChange
+}
+
+// merged calls merge as a callback, the caller must hold the write lock
+func (m *Map) merged(existing, incoming interface{}, merge func(existing, incoming interface{}) interface{}) interface{} {
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	return merge(existing, incoming)
+}
@@ -0,0 +1,57 @@
+package skiplist
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type MergeSuite struct{}
+
+var _ = Suite(&MergeSuite{})
+
+func addInts(existing, incoming interface{}) interface{} {
+	return existing.(int) + incoming.(int)
+}
+
+func (s *MergeSuite) TestPutOrMerge(c *C) {
+	m := NewMap(compareStrings)
+	c.Assert(m.PutOrMerge("a", 1, addInts), Equals, false)
+	c.Assert(m.PutOrMerge("a", 2, addInts), Equals, true)
+	c.Assert(m.PutOrMerge("b", 5, addInts), Equals, false)
+	v, _ := m.Get("a")
+	c.Assert(v, Equals, 3)
+	v, _ = m.Get("b")
+	c.Assert(v, Equals, 5)
+	c.Assert(m.Len(), Equals, 2)
+	c.Assert(m.Validate(), IsNil)
+	// merge can build up other kinds of value
+	appendTo := func(existing, incoming interface{}) interface{} {
+		return append(existing.([]string), incoming.([]string)...)
+	}
+	m.PutOrMerge("list", []string{"x"}, appendTo)
+	m.PutOrMerge("list", []string{"y", "z"}, appendTo)
+	v, _ = m.Get("list")
+	c.Assert(v, DeepEquals, []string{"x", "y", "z"})
+}
+
+func (s *MergeSuite) TestConcurrentCounters(c *C) {
+	m := NewMap(compareInts)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.PutOrMerge(i%10, g+1, addInts)
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Assert(m.Len(), Equals, 10)
+	// each key got 100 increments from each goroutine
+	for k := 0; k < 10; k++ {
+		v, _ := m.Get(k)
+		c.Assert(v, Equals, 100*(1+2+3+4+5+6+7+8))
+	}
+}
@@ -0,0 +1,86 @@
+package skiplist
+
+// NewMultiMap creates a new empty map that can hold several
+// values for the same key, it takes a comparison function that
+// should implement Less. pairs with equal keys are kept in the
+// order they were put
+func NewMultiMap(less func(a, b interface{}) bool) *Map {
+	m := NewMap(less)
+	m.multi = true
+	return m
+}
+
+// NewMultiMapStable creates a new empty multimap that keeps pairs
+// with equal keys ordered by value, using tieBreak, rather than in the
+// order they were put, giving a deterministic iteration order.
+// pairs with equal keys and values keep the order they were put
+func NewMultiMapStable(primary, tieBreak func(a, b interface{}) bool) *Map {
+	m := NewMultiMap(primary)
+	m.tieBreak = tieBreak
+	return m
+}
+
+// putMulti inserts a new pair after any existing pairs for k, or
+// with a tie break after those whose values are not greater than v.
+// it returns the new element. the caller must hold the write lock
+func (m *Map) putMulti(k interface{}, v interface{}) *mapElement {
+	backPointer := make([]*mapElement, m.maxLevels)
+	if m.tieBreak == nil {
+		m.upperBound(k, backPointer)
+	} else {
+		prev := m.head
+		for level := m.maxLevels - 1; level >= 0; level-- {
+			e := prev.next[level]
+			for e != nil && (m.comp(e.key, k) || !m.comp(k, e.key) && !m.tieBreak(v, e.val)) {
+				prev = e
+				e = e.next[level]
+			}
+			backPointer[level] = prev
+		}
+	}
+	e := m.newElement(k, v, randomLevels(m))
+	m.link(e, backPointer)
+	return e
+}
+
+// RemoveValue removes the pair with key k whose value matches v
+// according to eq, leaving any other pairs for k in place.
+// returns true if it found and removed, false otherwise. eq is called
+// under the write lock, so it must not use the map
+func (m *Map) RemoveValue(k, v interface{}, eq func(a, b interface{}) bool) bool {
+	defer m.endOp("removevalue", k, m.startOp())
+	k = m.normal(k)
+	m.lock()
+	defer m.mutex.Unlock()
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	backPointer := make([]*mapElement, m.maxLevels)
+	// walk the run of pairs with keys equal to k
+	e := m.lowerBound(k, backPointer)
+	for e != nil && !m.comp(k, e.key) {
+		if eq(e.val, v) {
+			m.unlink(e, backPointer)
+			return true
+		}
+		// the elements passed come before e at each of their levels
+		for level := 0; level < len(e.next); level++ {
+			backPointer[level] = e
+		}
+		e = e.next[0]
+	}
+	return false
+}
+
+// Count returns how many pairs have a key equal to k, which is
+// at most 1 unless the map is a multimap
+func (m *Map) Count(k interface{}) int {
+	defer m.endOp("count", k, m.startOp())
+	k = m.normal(k)
+	locked := m.rlock()
+	defer m.runlock(locked)
+	n := 0
+	for e := liveFrom(m.lowerBound(k, nil)); e != nil && !m.comp(k, e.key); e = liveFrom(e.next[0]) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,139 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type MultiMapSuite struct{}
+
+var _ = Suite(&MultiMapSuite{})
+
+func equalInts(a, b interface{}) bool { return a.(int) == b.(int) }
+
+// values returns the values for k in order by walking level 0
+func values(m *Map, k interface{}) []interface{} {
+	ret := []interface{}{}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		if m.comp(k, e.key) == m.comp(e.key, k) {
+			ret = append(ret, e.val)
+		}
+	}
+	return ret
+}
+
+func (s *MultiMapSuite) TestPutKeepsDuplicates(c *C) {
+	m := NewMultiMap(compareInts)
+	c.Assert(m.Put(1, 10), Equals, false)
+	c.Assert(m.Put(1, 11), Equals, false)
+	c.Assert(m.Put(0, 0), Equals, false)
+	c.Assert(m.Put(1, 12), Equals, false)
+	c.Assert(m.Len(), Equals, 4)
+	c.Assert(values(m, 1), DeepEquals, []interface{}{10, 11, 12})
+	x, ok := m.Get(1)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 10)
+}
+
+func (s *MultiMapSuite) TestRemoveValue(c *C) {
+	m := NewMultiMap(compareInts)
+	for i := 0; i < 100; i++ {
+		m.Put(i%3, i)
+	}
+	c.Assert(m.RemoveValue(1, 49, equalInts), Equals, true)
+	c.Assert(m.RemoveValue(1, 49, equalInts), Equals, false)
+	c.Assert(m.RemoveValue(1, 48, equalInts), Equals, false)
+	c.Assert(m.Len(), Equals, 99)
+	vals := values(m, 1)
+	c.Assert(len(vals), Equals, 32)
+	for _, v := range vals {
+		c.Assert(v, Not(Equals), 49)
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(len(values(m, 0)), Equals, 34)
+	c.Assert(len(values(m, 2)), Equals, 33)
+}
+
+func (s *MultiMapSuite) TestRemoveValueEveryPosition(c *C) {
+	m := NewMultiMap(compareInts)
+	for i := 0; i < 50; i++ {
+		m.Put(7, i)
+	}
+	m.Put(6, -1)
+	m.Put(8, -1)
+	// remove from the middle, the front and the back of the run
+	c.Assert(m.RemoveValue(7, 25, equalInts), Equals, true)
+	c.Assert(m.RemoveValue(7, 0, equalInts), Equals, true)
+	c.Assert(m.RemoveValue(7, 49, equalInts), Equals, true)
+	c.Assert(len(values(m, 7)), Equals, 47)
+	for i := 1; i < 49; i++ {
+		if i != 25 {
+			c.Assert(m.RemoveValue(7, i, equalInts), Equals, true)
+		}
+	}
+	c.Assert(m.Len(), Equals, 2)
+	c.Assert(m.Validate(), IsNil)
+	_, ok := m.Get(7)
+	c.Assert(ok, Equals, false)
+	x, _ := m.Get(8)
+	c.Assert(x, Equals, -1)
+}
+
+func (s *MultiMapSuite) TestCount(c *C) {
+	m := NewMultiMap(compareInts)
+	c.Assert(m.Count(1), Equals, 0)
+	for i := 0; i < 1000; i++ {
+		m.Put(i%10, i)
+	}
+	m.Put(100, 0)
+	for i := 0; i < 10; i++ {
+		c.Assert(m.Count(i), Equals, 100)
+	}
+	c.Assert(m.Count(100), Equals, 1)
+	c.Assert(m.Count(50), Equals, 0)
+	c.Assert(m.Count(-1), Equals, 0)
+	c.Assert(m.Count(101), Equals, 0)
+	m.RemoveValue(5, 505, equalInts)
+	c.Assert(m.Count(5), Equals, 99)
+}
+
+func (s *MultiMapSuite) TestCountSingle(c *C) {
+	m := fillMap(10)
+	c.Assert(m.Count(3), Equals, 1)
+	m.Put(3, 1)
+	c.Assert(m.Count(3), Equals, 1)
+	c.Assert(m.Count(10), Equals, 0)
+}
+
+func (s *MultiMapSuite) TestStableOrdering(c *C) {
+	m := NewMultiMapStable(compareInts, compareStrings)
+	for _, v := range []string{"d", "b", "e", "a", "c", "b"} {
+		m.Put(1, v)
+		m.Put(0, v)
+		m.Put(2, v)
+	}
+	c.Assert(m.Validate(), IsNil)
+	want := []interface{}{"a", "b", "b", "c", "d", "e"}
+	c.Assert(values(m, 0), DeepEquals, want)
+	c.Assert(values(m, 1), DeepEquals, want)
+	c.Assert(values(m, 2), DeepEquals, want)
+	x, _ := m.Get(1)
+	c.Assert(x, Equals, "a")
+	c.Assert(m.Count(1), Equals, 6)
+	m.RemoveValue(1, "a", func(a, b interface{}) bool { return a == b })
+	x, _ = m.Get(1)
+	c.Assert(x, Equals, "b")
+	c.Assert(m.Validate(), IsNil)
+}
+
+// two maps filled in different orders iterate identically
+func (s *MultiMapSuite) TestStableOrderingDeterministic(c *C) {
+	a := NewMultiMapStable(compareInts, compareInts)
+	b := NewMultiMapStable(compareInts, compareInts)
+	for i := 0; i < 500; i++ {
+		a.Put(i%5, i)
+		b.Put((499-i)%5, 499-i)
+	}
+	c.Assert(a.Validate(), IsNil)
+	c.Assert(b.Validate(), IsNil)
+	c.Assert(a.FirstN(500), DeepEquals, b.FirstN(500))
+}
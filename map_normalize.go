@@ -0,0 +1,22 @@
+package skiplist
+
+// WithKeyNormalizer makes the map pass every key it is given through
+// fn before using it, so keys that normalize the same are the same
+// key: Put, Get, Remove, range bounds, Floor, Ceiling and the rest all
+// normalize, and it is the normalized key that is stored and returned
+// by iteration. fn must not return nil for a key, and normalizing a
+// normalized key should give it back unchanged
+func WithKeyNormalizer(fn func(k interface{}) interface{}) Option {
+	return func(m *Map) {
+		m.normalize = fn
+	}
+}
+
+// normal returns k as the map stores it. a nil k stays nil, so it
+// still means an open bound or a missing key
+func (m *Map) normal(k interface{}) interface{} {
+	if m.normalize == nil || k == nil {
+		return k
+	}
+	return m.normalize(k)
+}
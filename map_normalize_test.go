@@ -0,0 +1,61 @@
+package skiplist
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type NormalizeSuite struct{}
+
+var _ = Suite(&NormalizeSuite{})
+
+func trimLower(k interface{}) interface{} {
+	return strings.ToLower(strings.TrimSpace(k.(string)))
+}
+
+func (s *NormalizeSuite) TestPutGetMeet(c *C) {
+	m := NewMap(compareStrings, WithKeyNormalizer(trimLower))
+	c.Assert(m.Put("Foo ", 1), Equals, false)
+	v, ok := m.Get("foo")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 1)
+	c.Assert(m.Put("  FOO", 2), Equals, true)
+	c.Assert(m.Len(), Equals, 1)
+	// the normalized key is the one stored
+	c.Assert(m.Keys(), DeepEquals, []interface{}{"foo"})
+	c.Assert(m.Remove(" fOo"), Equals, true)
+	c.Assert(m.Len(), Equals, 0)
+}
+
+func (s *NormalizeSuite) TestBounds(c *C) {
+	m := NewMap(compareStrings, WithKeyNormalizer(trimLower))
+	for _, k := range []string{"apple", "Banana", " cherry", "DATE "} {
+		m.Put(k, k)
+	}
+	// unnormalized, "B" < "a" and the range would be empty
+	keys := []interface{}{}
+	m.Range(" A", "C", func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	})
+	c.Assert(keys, DeepEquals, []interface{}{"apple", "banana"})
+	c.Assert(m.RangeSnapshot("BANANA", "Date"), HasLen, 3)
+	k, _, ok := m.Floor("CHERRY ")
+	c.Assert(ok, Equals, true)
+	c.Assert(k, Equals, "cherry")
+	k, _, _ = m.Ceiling("Bz")
+	c.Assert(k, Equals, "cherry")
+	keys, _, _ = m.CeilingMany([]interface{}{"A", "B", " c"})
+	c.Assert(keys, DeepEquals, []interface{}{"apple", "banana", "cherry"})
+	c.Assert(m.ReKeyOverwrite("Apple", "Avocado"), Equals, true)
+	_, ok = m.Get("avocado")
+	c.Assert(ok, Equals, true)
+
+	// split halves normalize too
+	left, right := m.Split("C")
+	c.Assert(left.Len(), Equals, 2)
+	right.Put("Elderberry", 0)
+	_, ok = right.Get("elderberry ")
+	c.Assert(ok, Equals, true)
+}
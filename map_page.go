@@ -0,0 +1,246 @@
+package skiplist
+
+// After returns up to limit pairs with keys strictly after k, in
+// ascending order, for paging through a map: pass the last key of one
+// page to get the next. it returns fewer pairs at the end of the map.
+// pairs removed between pages are simply not seen again
+func (m *Map) After(k interface{}, limit int) []Entry {
+	defer m.endOp("after", k, m.startOp())
+	k = m.normal(k)
+	ret := []Entry{}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	for e := liveFrom(m.upperBound(k, nil)); e != nil && len(ret) < limit; e = liveFrom(e.next[0]) {
+		ret = append(ret, Entry{e.key, e.val})
+	}
+	return ret
+}
+
+// Before returns up to limit pairs with keys strictly before k, the
+// ones closest to k, in ascending order, for paging backwards: pass the
+// first key of one page to get the previous. it returns fewer pairs at
+// the start of the map
+func (m *Map) Before(k interface{}, limit int) []Entry {
+	defer m.endOp("before", k, m.startOp())
+	k = m.normal(k)
+	ret := []Entry{}
+	if limit <= 0 {
+		return ret
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	end := m.countLess(k)
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	for i, e := start, m.elementAt(start, nil); i < end; i, e = i+1, liveFrom(e.next[0]) {
+		ret = append(ret, Entry{e.key, e.val})
+	}
+	return ret
+}
+
+// Neighbors returns the pair with the largest key less than k, the
+// pair with key k and the pair with the smallest key greater than k,
+// found in a single search. any of them is nil if there is no such
+// pair. in a multimap exact is the first pair for k
+func (m *Map) Neighbors(k interface{}) (lower, exact, higher *Pair) {
+	defer m.endOp("neighbors", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, nil, nil
+	}
+	var backPointer [maxHeight]*mapElement
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := liveFrom(m.lowerBound(k, backPointer[:]))
+	if prev := m.liveBefore(backPointer[0]); prev != nil {
+		lower = &Pair{prev.key, prev.val}
+	}
+	if e != nil && !m.comp(k, e.key) {
+		exact = &Pair{e.key, e.val}
+		for e != nil && !m.comp(k, e.key) {
+			e = liveFrom(e.next[0])
+		}
+	}
+	if e != nil {
+		higher = &Pair{e.key, e.val}
+	}
+	return lower, exact, higher
+}
+
+// Ceiling returns the pair with the smallest key not less than k,
+// and true if there is one, false otherwise
+func (m *Map) Ceiling(k interface{}) (interface{}, interface{}, bool) {
+	defer m.endOp("ceiling", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, nil, false
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := liveFrom(m.lowerBound(k, nil))
+	if e == nil {
+		return nil, nil, false
+	}
+	return e.key, e.val, true
+}
+
+// Floor returns the pair with the largest key not greater than k,
+// and true if there is one, false otherwise. in a multimap it is
+// the last pair for the key
+func (m *Map) Floor(k interface{}) (interface{}, interface{}, bool) {
+	defer m.endOp("floor", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, nil, false
+	}
+	var backPointer [maxHeight]*mapElement
+	locked := m.rlock()
+	defer m.runlock(locked)
+	m.upperBound(k, backPointer[:])
+	e := m.liveBefore(backPointer[0])
+	if e == nil {
+		return nil, nil, false
+	}
+	return e.key, e.val, true
+}
+
+// Nearest returns the pair whose key is closest to k by dist, choosing
+// between the Floor and Ceiling of k, and true, or false if the map is
+// empty. a key equal to k is always nearest, and when the keys either
+// side are equally far the lower one is chosen. dist is called under
+// the read lock, so it must not use the map
+func (m *Map) Nearest(k interface{}, dist func(a, b interface{}) float64) (key, val interface{}, ok bool) {
+	defer m.endOp("nearest", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, nil, false
+	}
+	var backPointer [maxHeight]*mapElement
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	// one search finds both, the ceiling and the last element before k
+	e := liveFrom(m.lowerBound(k, backPointer[:]))
+	if floor := m.liveBefore(backPointer[0]); e == nil || floor != nil && m.comp(k, e.key) && dist(floor.key, k) <= dist(e.key, k) {
+		e = floor
+	}
+	if e == nil {
+		return nil, nil, false
+	}
+	return e.key, e.val, true
+}
+
+// GetClosestN returns up to n pairs whose keys are closest to k by
+// dist, closest first, fewer if the map holds fewer. one search finds
+// the pairs either side of k, and it works outwards from them taking
+// whichever side is closer, the lower key when they are equally far.
+// the list only links forwards, so the pairs below k it may need are
+// read in ascending order into a buffer first, found by rank. dist is
+// called under the read lock, so it must not use the map
+func (m *Map) GetClosestN(k interface{}, n int, dist func(a, b interface{}) float64) []Entry {
+	defer m.endOp("getclosestn", k, m.startOp())
+	k = m.normal(k)
+	ret := []Entry{}
+	if k == nil || n <= 0 {
+		return ret
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	// pos is the rank of the ceiling of k, prev the element before it
+	pos := 0
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && m.comp(next.key, k); next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+	}
+	left := m.liveRun(pos, n)
+	right := liveFrom(prev.next[0])
+	for len(ret) < n && (len(left) > 0 || right != nil) {
+		if l := len(left) - 1; l >= 0 && (right == nil || dist(left[l].key, k) <= dist(right.key, k)) {
+			ret = append(ret, Entry{left[l].key, left[l].val})
+			left = left[:l]
+		} else {
+			ret = append(ret, Entry{right.key, right.val})
+			right = liveFrom(right.next[0])
+		}
+	}
+	return ret
+}
+
+// liveRun returns up to n live elements before rank end, in
+// ascending order. the caller must hold the lock
+func (m *Map) liveRun(end, n int) []*mapElement {
+	start := end - n
+	if start < 0 {
+		start = 0
+	}
+	ret := make([]*mapElement, 0, end-start)
+	for i, e := start, m.elementAt(start, nil); i < end; i, e = i+1, liveFrom(e.next[0]) {
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+// Interpolate returns the value for k, and true, if k is in the map.
+// otherwise, if k falls between two keys, it returns what interp makes
+// of the pairs either side of it and k, say a value on the straight line
+// between them, and true. it returns false if k is before the first key
+// or after the last. interp is called after the lock is let go
+func (m *Map) Interpolate(k interface{}, interp func(loK, loV, hiK, hiV, probe interface{}) interface{}) (interface{}, bool) {
+	lower, exact, higher := m.Neighbors(k)
+	if exact != nil {
+		return exact.Val, true
+	}
+	if lower == nil || higher == nil {
+		return nil, false
+	}
+	return interp(lower.Key, lower.Val, higher.Key, higher.Val, m.normal(k)), true
+}
+
+// CeilingMany returns what Ceiling would for each of probes, as
+// slices of keys, values and whether there was a ceiling. if probes
+// are in ascending order it sweeps forward along the list from one
+// ceiling to the next, otherwise it searches for each separately
+func (m *Map) CeilingMany(probes []interface{}) ([]interface{}, []interface{}, []bool) {
+	defer m.endOp("ceilingmany", nil, m.startOp())
+	keys := make([]interface{}, len(probes))
+	vals := make([]interface{}, len(probes))
+	found := make([]bool, len(probes))
+	if m.normalize != nil {
+		probes = append([]interface{}(nil), probes...)
+		for i, p := range probes {
+			probes[i] = m.normal(p)
+		}
+	}
+	sorted := true
+	for i := 1; i < len(probes) && sorted; i++ {
+		sorted = probes[i-1] != nil && probes[i] != nil && !m.comp(probes[i], probes[i-1])
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	var e *mapElement
+	for i, p := range probes {
+		if p == nil {
+			continue
+		}
+		if i == 0 || !sorted {
+			e = m.lowerBound(p, nil)
+		} else {
+			for e != nil && m.comp(e.key, p) {
+				e = e.next[0]
+			}
+		}
+		e = liveFrom(e)
+		if e != nil {
+			keys[i], vals[i], found[i] = e.key, e.val, true
+		}
+	}
+	return keys, vals, found
+}
@@ -0,0 +1,274 @@
+package skiplist
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+type PageSuite struct{}
+
+var _ = Suite(&PageSuite{})
+
+func (s *PageSuite) TestAfter(c *C) {
+	m := fillMap(100)
+	ref := sortedEntries(100)
+	c.Assert(m.After(9, 5), DeepEquals, ref[10:15])
+	c.Assert(m.After(-1, 3), DeepEquals, ref[:3])
+	c.Assert(m.After(97, 5), DeepEquals, ref[98:])
+	c.Assert(m.After(99, 5), HasLen, 0)
+	c.Assert(m.After(5, 0), HasLen, 0)
+}
+
+func (s *PageSuite) TestBefore(c *C) {
+	m := fillMap(100)
+	ref := sortedEntries(100)
+	c.Assert(m.Before(15, 5), DeepEquals, ref[10:15])
+	c.Assert(m.Before(1000, 3), DeepEquals, ref[97:])
+	c.Assert(m.Before(2, 5), DeepEquals, ref[:2])
+	c.Assert(m.Before(0, 5), HasLen, 0)
+	c.Assert(m.Before(50, 0), HasLen, 0)
+}
+
+func (s *PageSuite) TestPaginateAll(c *C) {
+	m, keys := fillMapRandKeys(10000, 6)
+	var all []Entry
+	page := m.After(-1, 50)
+	for len(page) > 0 {
+		c.Assert(len(page) <= 50, Equals, true)
+		all = append(all, page...)
+		page = m.After(page[len(page)-1].Key, 50)
+	}
+	c.Assert(len(all), Equals, len(keys))
+	c.Assert(all, DeepEquals, m.FirstN(m.Len()))
+
+	// and backwards
+	var back []Entry
+	page = m.Before(1<<62, 50)
+	for len(page) > 0 {
+		back = append(page, back...)
+		page = m.Before(page[0].Key, 50)
+	}
+	c.Assert(back, DeepEquals, all)
+}
+
+func (s *PageSuite) TestPaginateWithDeletes(c *C) {
+	m := fillMap(10000)
+	done := make(chan bool)
+	served := make(chan int, 10000)
+	// delete keys as soon as they have been served
+	go func() {
+		for k := range served {
+			m.Remove(k)
+		}
+		close(done)
+	}()
+	next := 0
+	page := m.After(-1, 37)
+	for len(page) > 0 {
+		for _, e := range page {
+			c.Assert(e.Key, Equals, next)
+			next++
+			served <- e.Key.(int)
+		}
+		page = m.After(page[len(page)-1].Key, 37)
+	}
+	close(served)
+	<-done
+	c.Assert(next, Equals, 10000)
+	c.Assert(m.Len(), Equals, 0)
+}
+
+func (s *PageSuite) TestNeighborsPresent(c *C) {
+	m := NewMap(compareInts)
+	for _, k := range []int{10, 20, 30} {
+		m.Put(k, k*2)
+	}
+	lower, exact, higher := m.Neighbors(20)
+	c.Assert(*lower, Equals, Pair{10, 20})
+	c.Assert(*exact, Equals, Pair{20, 40})
+	c.Assert(*higher, Equals, Pair{30, 60})
+	lower, exact, higher = m.Neighbors(10)
+	c.Assert(lower, IsNil)
+	c.Assert(*exact, Equals, Pair{10, 20})
+	c.Assert(*higher, Equals, Pair{20, 40})
+	lower, _, higher = m.Neighbors(30)
+	c.Assert(*lower, Equals, Pair{20, 40})
+	c.Assert(higher, IsNil)
+}
+
+func (s *PageSuite) TestNeighborsAbsent(c *C) {
+	m := NewMap(compareInts)
+	for _, k := range []int{10, 20, 30} {
+		m.Put(k, k*2)
+	}
+	lower, exact, higher := m.Neighbors(25)
+	c.Assert(*lower, Equals, Pair{20, 40})
+	c.Assert(exact, IsNil)
+	c.Assert(*higher, Equals, Pair{30, 60})
+	lower, exact, higher = m.Neighbors(5)
+	c.Assert(lower, IsNil)
+	c.Assert(exact, IsNil)
+	c.Assert(*higher, Equals, Pair{10, 20})
+	lower, exact, higher = NewMap(compareInts).Neighbors(5)
+	c.Assert(lower == nil && exact == nil && higher == nil, Equals, true)
+}
+
+func (s *PageSuite) TestNeighborsMulti(c *C) {
+	m := NewMultiMap(compareInts)
+	m.Put(1, "a")
+	m.Put(2, "b")
+	m.Put(2, "c")
+	m.Put(3, "d")
+	lower, exact, higher := m.Neighbors(2)
+	c.Assert(*lower, Equals, Pair{1, "a"})
+	c.Assert(*exact, Equals, Pair{2, "b"})
+	c.Assert(*higher, Equals, Pair{3, "d"})
+}
+
+func (s *PageSuite) TestFloorCeiling(c *C) {
+	m := NewMap(compareInts)
+	for _, k := range []int{10, 20, 30} {
+		m.Put(k, k)
+	}
+	for _, t := range []struct {
+		k, floor, ceiling interface{}
+	}{{5, nil, 10}, {10, 10, 10}, {15, 10, 20}, {30, 30, 30}, {35, 30, nil}} {
+		k, _, ok := m.Floor(t.k)
+		c.Assert(k, Equals, t.floor)
+		c.Assert(ok, Equals, t.floor != nil)
+		k, _, ok = m.Ceiling(t.k)
+		c.Assert(k, Equals, t.ceiling)
+		c.Assert(ok, Equals, t.ceiling != nil)
+	}
+	// they agree with a compiled copy
+	f := m.Compile()
+	for k := 0; k < 40; k++ {
+		k1, _, _ := m.Floor(k)
+		k2, _, _ := f.Floor(k)
+		c.Assert(k1, Equals, k2)
+		k1, _, _ = m.Ceiling(k)
+		k2, _, _ = f.Ceiling(k)
+		c.Assert(k1, Equals, k2)
+	}
+}
+
+func (s *PageSuite) TestCeilingMany(c *C) {
+	m := NewMap(compareInts)
+	for i := 0; i < 1000; i += 10 {
+		m.Put(i, i*2)
+	}
+	for _, probes := range [][]interface{}{
+		{-5, 0, 0, 1, 55, 500, 990, 991, 2000},
+		{500, 3, 999, 10},
+		{},
+	} {
+		keys, vals, found := m.CeilingMany(probes)
+		c.Assert(keys, HasLen, len(probes))
+		for i, p := range probes {
+			k, v, ok := m.Ceiling(p)
+			c.Assert(keys[i], Equals, k)
+			c.Assert(vals[i], Equals, v)
+			c.Assert(found[i], Equals, ok)
+		}
+	}
+}
+
+func (s *PageSuite) TestNearest(c *C) {
+	absDiff := func(a, b interface{}) float64 {
+		return math.Abs(float64(a.(int) - b.(int)))
+	}
+	m := NewMap(compareInts)
+	_, _, ok := m.Nearest(5, absDiff)
+	c.Assert(ok, Equals, false)
+	keys := []int{10, 20, 23, 40}
+	for _, k := range keys {
+		m.Put(k, k*10)
+	}
+	for probe := -5; probe < 50; probe++ {
+		want := keys[0]
+		for _, k := range keys {
+			// strictly closer, so ties keep the lower key
+			if absDiff(k, probe) < absDiff(want, probe) {
+				want = k
+			}
+		}
+		k, v, ok := m.Nearest(probe, absDiff)
+		c.Assert(ok, Equals, true)
+		c.Assert(k, Equals, want, Commentf("probe %d", probe))
+		c.Assert(v, Equals, want*10)
+	}
+	// only one side
+	k, _, _ := m.Nearest(-100, absDiff)
+	c.Assert(k, Equals, 10)
+	k, _, _ = m.Nearest(100, absDiff)
+	c.Assert(k, Equals, 40)
+	// a tie between 10 and 20
+	k, _, _ = m.Nearest(15, absDiff)
+	c.Assert(k, Equals, 10)
+}
+
+func (s *PageSuite) TestInterpolate(c *C) {
+	linear := func(loK, loV, hiK, hiV, probe interface{}) interface{} {
+		t := float64(probe.(int)-loK.(int)) / float64(hiK.(int)-loK.(int))
+		return loV.(float64) + t*(hiV.(float64)-loV.(float64))
+	}
+	m := NewMap(compareInts)
+	_, ok := m.Interpolate(5, linear)
+	c.Assert(ok, Equals, false)
+	m.Put(0, 1.0)
+	m.Put(10, 6.0)
+	m.Put(20, 2.0)
+	for _, tc := range []struct {
+		probe int
+		want  float64
+	}{{0, 1}, {2, 2}, {5, 3.5}, {10, 6}, {15, 4}, {19, 2.4}, {20, 2}} {
+		v, ok := m.Interpolate(tc.probe, linear)
+		c.Assert(ok, Equals, true)
+		c.Assert(math.Abs(v.(float64)-tc.want) < 1e-9, Equals, true, Commentf("probe %d got %v", tc.probe, v))
+	}
+	// outside the keys there is nothing to interpolate between
+	_, ok = m.Interpolate(-1, linear)
+	c.Assert(ok, Equals, false)
+	_, ok = m.Interpolate(21, linear)
+	c.Assert(ok, Equals, false)
+}
+
+// closestN finds what GetClosestN should return the slow way, sorting
+// every pair by distance then key
+func closestN(entries []Entry, k, n int) []Entry {
+	sorted := append([]Entry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		di, dj := math.Abs(float64(sorted[i].Key.(int)-k)), math.Abs(float64(sorted[j].Key.(int)-k))
+		return di < dj
+	})
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+	return sorted[:n]
+}
+
+func (s *PageSuite) TestGetClosestN(c *C) {
+	absDiff := func(a, b interface{}) float64 {
+		return math.Abs(float64(a.(int) - b.(int)))
+	}
+	r := rand.New(rand.NewSource(5))
+	for _, m := range []*Map{NewMap(compareInts), NewMap(compareInts, WithTombstones())} {
+		c.Assert(m.GetClosestN(5, 3, absDiff), HasLen, 0)
+		for i := 0; i < 300; i++ {
+			m.Put(r.Intn(1000), i)
+		}
+		for i := 0; i < 100; i++ {
+			m.Remove(r.Intn(1000))
+		}
+		entries := m.Entries()
+		for _, probe := range []int{-50, 0, 1, 250, 499, 500, 731, 999, 1200} {
+			for _, n := range []int{0, 1, 2, 7, 40, len(entries), len(entries) + 5} {
+				got := m.GetClosestN(probe, n, absDiff)
+				c.Assert(got, DeepEquals, closestN(entries, probe, n), Commentf("probe %d n %d", probe, n))
+			}
+		}
+	}
+}
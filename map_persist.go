@@ -0,0 +1,135 @@
+package skiplist
+
+import (
+	"errors"
+)
+
+// Persister mirrors the changes made to a map somewhere durable,
+// see WithPersister
+type Persister interface {
+	Put(k, v interface{}) error
+	Remove(k interface{}) error
+}
+
+// WithPersister passes every Put and Remove to p as it is made, while
+// the write lock is held. by default p is called after the map has
+// changed, and if it fails the change is undone, so the map only
+// holds what p accepted, though watchers see both the change and its
+// undoing. PutE and RemoveE return its error. changes
+// made any other way, such as in a Transaction, are not passed to p
+func WithPersister(p Persister) Option {
+	return func(m *Map) {
+		m.persister = p
+	}
+}
+
+// WithPersistFirst makes a map with a persister call it before
+// changing the map, which is then left alone if the persister fails.
+// a Remove of a missing key isn't passed on
+func WithPersistFirst() Option {
+	return func(m *Map) {
+		m.preSave = true
+	}
+}
+
+// putPersisted does the work of PutE, the caller must hold the write lock
+func (m *Map) putPersisted(k, v interface{}) (bool, error) {
+	p := m.persister
+	if p == nil {
+		return m.put(k, v), nil
+	}
+	if m.preSave {
+		if err := p.Put(k, v); err != nil {
+			return false, err
+		}
+		return m.put(k, v), nil
+	}
+	if m.multi {
+		e := m.putMulti(k, v)
+		if err := p.Put(k, v); err != nil {
+			m.removeElement(e)
+			return false, err
+		}
+		return false, nil
+	}
+	old, ok := m.get(k)
+	m.put(k, v)
+	if err := p.Put(k, v); err != nil {
+		if ok {
+			m.put(k, old)
+		} else {
+			m.remove(k)
+		}
+		return false, err
+	}
+	return ok, nil
+}
+
+// removePersisted does the work of RemoveE, the caller must hold
+// the write lock
+func (m *Map) removePersisted(k interface{}) (interface{}, bool, error) {
+	p := m.persister
+	if p == nil || k == nil {
+		v, ok := m.remove(k)
+		return v, ok, nil
+	}
+	var backPointer [maxHeight]*mapElement
+	e := m.find(k, backPointer[:])
+	if e == nil {
+		return nil, false, nil
+	}
+	if m.preSave {
+		if err := p.Remove(k); err != nil {
+			return nil, false, err
+		}
+		m.unlink(e, backPointer[:])
+		return e.val, true, nil
+	}
+	m.unlink(e, backPointer[:])
+	if err := p.Remove(k); err != nil {
+		// backPointer still holds e's place
+		m.link(e, backPointer[:])
+		return nil, false, err
+	}
+	return e.val, true, nil
+}
+
+// removeElement unlinks e, which must be in the map, finding its place
+// among any elements with equal keys. the caller must hold the write lock
+func (m *Map) removeElement(e *mapElement) {
+	var backPointer [maxHeight]*mapElement
+	for p := m.lowerBound(e.key, backPointer[:]); p != e; p = p.next[0] {
+		for level := 0; level < len(p.next); level++ {
+			backPointer[level] = p
+		}
+	}
+	m.unlink(e, backPointer[:])
+}
+
+// Op is a change read back from a log by Replay, putting Val under
+// Key, or removing Key if Remove is set
+type Op struct {
+	Remove bool
+	Key    interface{}
+	Val    interface{}
+}
+
+// ErrNilKey is returned by Replay for a change with a nil key
+var ErrNilKey = errors.New("skiplist: nil key")
+
+// Replay builds a new map ordered by less from a log of changes,
+// calling next for each change in turn until it returns false
+func Replay(next func() (op Op, ok bool), less func(a, b interface{}) bool) (*Map, error) {
+	m := NewMap(less)
+	for op, ok := next(); ok; op, ok = next() {
+		if op.Key == nil {
+			return nil, ErrNilKey
+		}
+		if op.Remove {
+			m.remove(op.Key)
+		} else {
+			m.put(op.Key, op.Val)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,113 @@
+package skiplist
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type PersistSuite struct{}
+
+var _ = Suite(&PersistSuite{})
+
+var errRejected = errors.New("rejected")
+
+// logPersister records the changes it accepts as Ops, rejecting
+// changes to keys in reject
+type logPersister struct {
+	log    []Op
+	reject map[interface{}]bool
+}
+
+func (p *logPersister) Put(k, v interface{}) error {
+	if p.reject[k] {
+		return errRejected
+	}
+	p.log = append(p.log, Op{Key: k, Val: v})
+	return nil
+}
+
+func (p *logPersister) Remove(k interface{}) error {
+	if p.reject[k] {
+		return errRejected
+	}
+	p.log = append(p.log, Op{Remove: true, Key: k})
+	return nil
+}
+
+// replay rebuilds a map from what p accepted
+func (p *logPersister) replay(c *C) *Map {
+	i := 0
+	m, err := Replay(func() (Op, bool) {
+		if i == len(p.log) {
+			return Op{}, false
+		}
+		i++
+		return p.log[i-1], true
+	}, compareInts)
+	c.Assert(err, IsNil)
+	return m
+}
+
+func (s *PersistSuite) TestPersister(c *C) {
+	for _, opts := range [][]Option{nil, {WithPersistFirst()}} {
+		p := &logPersister{reject: map[interface{}]bool{}}
+		m := NewMap(compareInts, append(opts, WithPersister(p))...)
+		for i := 0; i < 100; i++ {
+			m.Put(i, i)
+		}
+		p.reject[7] = true
+		p.reject[500] = true
+		ok, err := m.PutE(7, 70)
+		c.Assert(err, Equals, errRejected)
+		c.Assert(ok, Equals, false)
+		_, err = m.PutE(500, 0)
+		c.Assert(err, Equals, errRejected)
+		ok, err = m.RemoveE(7)
+		c.Assert(err, Equals, errRejected)
+		c.Assert(ok, Equals, false)
+		ok, err = m.PutE(8, 80)
+		c.Assert(ok, Equals, true)
+		c.Assert(err, IsNil)
+		ok, err = m.RemoveE(9)
+		c.Assert(ok, Equals, true)
+		c.Assert(err, IsNil)
+		ok, err = m.RemoveE(1000)
+		c.Assert(ok, Equals, false)
+		c.Assert(err, IsNil)
+		c.Assert(m.Validate(), IsNil)
+		v, _ := m.Get(7)
+		c.Assert(v, Equals, 7)
+		_, ok = m.Get(500)
+		c.Assert(ok, Equals, false)
+		c.Assert(m.Entries(), DeepEquals, p.replay(c).Entries())
+	}
+}
+
+func (s *PersistSuite) TestPersisterMulti(c *C) {
+	p := &logPersister{reject: map[interface{}]bool{}}
+	m := NewMultiMap(compareInts)
+	WithPersister(p)(m)
+	m.Put(1, "a")
+	m.Put(2, "b")
+	m.Put(1, "c")
+	p.reject[1] = true
+	_, err := m.PutE(1, "d")
+	c.Assert(err, Equals, errRejected)
+	_, err = m.RemoveE(1)
+	c.Assert(err, Equals, errRejected)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Entries(), DeepEquals, []Entry{{1, "a"}, {1, "c"}, {2, "b"}})
+}
+
+func (s *PersistSuite) TestReplayNilKey(c *C) {
+	done := false
+	_, err := Replay(func() (Op, bool) {
+		if done {
+			return Op{}, false
+		}
+		done = true
+		return Op{}, true
+	}, compareInts)
+	c.Assert(err, Equals, ErrNilKey)
+}
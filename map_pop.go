@@ -0,0 +1,180 @@
+package skiplist
+
+// PopMinN removes up to n pairs with the smallest keys from the
+// map and returns them in ascending order. it takes the write lock
+// once and splices the head of each level once. tombstones among the
+// pairs it removes are unlinked with them
+func (m *Map) PopMinN(n int) []Entry {
+	defer m.endOp("popminn", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	if n > m.length {
+		n = m.length
+	}
+	if n <= 0 {
+		return []Entry{}
+	}
+	ret := make([]Entry, 0, n)
+	// the last removed element at each level points to the new head
+	newHead := make([]*mapElement, m.maxLevels)
+	newSpan := make([]int, m.maxLevels)
+	touched := 0
+	for e := m.head.next[0]; len(ret) < n; e = e.next[0] {
+		if !e.deleted {
+			ret = append(ret, Entry{e.key, e.val})
+		}
+		for level := 0; level < len(e.next); level++ {
+			newHead[level] = e.next[level]
+			// e is at rank len(ret), a tombstone taking no room, so
+			// its next is len(ret)+span from the old head
+			newSpan[level] = len(ret) + e.span[level] - n
+		}
+		if len(e.next) > touched {
+			touched = len(e.next)
+		}
+	}
+	for level := 0; level < m.maxLevels; level++ {
+		if level < touched {
+			m.head.next[level] = newHead[level]
+			m.head.span[level] = newSpan[level]
+		} else if m.head.next[level] != nil {
+			m.head.span[level] -= n
+		}
+	}
+	m.length -= n
+	for _, e := range ret {
+		m.removed(e.Key, e.Val)
+	}
+	return ret
+}
+
+// PopMaxN removes up to n pairs with the largest keys from the
+// map and returns them in descending order, the order repeated
+// pops would give. it takes the write lock once, finds the cut by
+// rank and cuts each level once, tombstones after the cut going
+// with the pairs
+func (m *Map) PopMaxN(n int) []Entry {
+	defer m.endOp("popmaxn", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	if n > m.length {
+		n = m.length
+	}
+	if n <= 0 {
+		return []Entry{}
+	}
+	// find the last element before the cut at each level
+	backPointer := make([]*mapElement, m.maxLevels)
+	e := m.elementAt(m.length-n, backPointer)
+	ret := make([]Entry, n)
+	for i := n - 1; i >= 0; i-- {
+		ret[i] = Entry{e.key, e.val}
+		e = liveFrom(e.next[0])
+	}
+	for level := 0; level < m.maxLevels; level++ {
+		backPointer[level].next[level] = nil
+	}
+	m.length -= n
+	for _, e := range ret {
+		m.removed(e.Key, e.Val)
+	}
+	return ret
+}
+
+// FirstN returns up to n pairs with the smallest keys in ascending
+// order, without removing them
+func (m *Map) FirstN(n int) []Entry {
+	defer m.endOp("firstn", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if n > m.length {
+		n = m.length
+	}
+	if n <= 0 {
+		return []Entry{}
+	}
+	ret := make([]Entry, 0, n)
+	for e := liveFrom(m.head.next[0]); e != nil && len(ret) < n; e = liveFrom(e.next[0]) {
+		ret = append(ret, Entry{e.key, e.val})
+	}
+	return ret
+}
+
+// LastN returns up to n pairs with the largest keys in ascending
+// order, without removing them. it scans forward keeping the last
+// n pairs seen in a ring, so it only allocates for the result
+func (m *Map) LastN(n int) []Entry {
+	defer m.endOp("lastn", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if n > m.length {
+		n = m.length
+	}
+	if n <= 0 {
+		return []Entry{}
+	}
+	ring := make([]Entry, n)
+	i := 0
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		ring[i%n] = Entry{e.key, e.val}
+		i++
+	}
+	// the oldest pair in the ring is the next one to be overwritten
+	start := i % n
+	return append(ring[start:], ring[:start]...)
+}
+
+// MinN returns the keys and values of up to n pairs with the smallest
+// keys in ascending order, without removing them
+func (m *Map) MinN(n int) ([]interface{}, []interface{}) {
+	defer m.endOp("minn", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.selectRange(0, n)
+}
+
+// MaxN returns the keys and values of up to n pairs with the largest
+// keys in ascending order, like LastN, without removing them. it finds
+// the first of them by rank rather than scanning the map
+func (m *Map) MaxN(n int) ([]interface{}, []interface{}) {
+	defer m.endOp("maxn", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if n < 0 {
+		n = 0
+	}
+	keys, vals := m.selectRange(m.length-n, m.length)
+	return keys, vals
+}
+
+// Min returns the pair with the smallest key, and true if the
+// map is not empty, false otherwise. it is the same as First
+func (m *Map) Min() (interface{}, interface{}, bool) {
+	return m.First()
+}
+
+// Max returns the pair with the largest key, and true if the
+// map is not empty, false otherwise. it is the same as Last
+func (m *Map) Max() (interface{}, interface{}, bool) {
+	return m.Last()
+}
+
+// PopMin removes the pair with the smallest key and returns it,
+// and true if the map was not empty, false otherwise
+func (m *Map) PopMin() (interface{}, interface{}, bool) {
+	return popped(m.PopMinN(1))
+}
+
+// PopMax removes the pair with the largest key and returns it,
+// and true if the map was not empty, false otherwise
+func (m *Map) PopMax() (interface{}, interface{}, bool) {
+	return popped(m.PopMaxN(1))
+}
+
+// popped unpacks the result of popping one pair
+func popped(entries []Entry) (interface{}, interface{}, bool) {
+	if len(entries) == 0 {
+		return nil, nil, false
+	}
+	return entries[0].Key, entries[0].Val, true
+}
@@ -0,0 +1,168 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type PopSuite struct{}
+
+var _ = Suite(&PopSuite{})
+
+func (s *PopSuite) TestPopMinN(c *C) {
+	m := fillMap(1000)
+	got := m.PopMinN(10)
+	c.Assert(len(got), Equals, 10)
+	for i, e := range got {
+		c.Assert(e, Equals, Entry{i, i * 2})
+	}
+	c.Assert(m.Len(), Equals, 990)
+	c.Assert(m.Validate(), IsNil)
+	_, ok := m.Get(9)
+	c.Assert(ok, Equals, false)
+	x, ok := m.Get(10)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 20)
+}
+
+func (s *PopSuite) TestPopMaxN(c *C) {
+	m := fillMap(1000)
+	got := m.PopMaxN(10)
+	c.Assert(len(got), Equals, 10)
+	for i, e := range got {
+		c.Assert(e, Equals, Entry{999 - i, (999 - i) * 2})
+	}
+	c.Assert(m.Len(), Equals, 990)
+	c.Assert(m.Validate(), IsNil)
+	_, ok := m.Get(990)
+	c.Assert(ok, Equals, false)
+	m.Put(5000, 1)
+	x, ok := m.Get(5000)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *PopSuite) TestPopZero(c *C) {
+	m := fillMap(10)
+	c.Assert(m.PopMinN(0), HasLen, 0)
+	c.Assert(m.PopMaxN(0), HasLen, 0)
+	c.Assert(m.PopMinN(-1), HasLen, 0)
+	c.Assert(m.Len(), Equals, 10)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *PopSuite) TestPopMoreThanLen(c *C) {
+	m := fillMap(10)
+	c.Assert(m.PopMinN(100), HasLen, 10)
+	c.Assert(m.Len(), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.PopMinN(1), HasLen, 0)
+
+	m = fillMap(10)
+	c.Assert(m.PopMaxN(100), HasLen, 10)
+	c.Assert(m.Len(), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+	m.Put(1, 1)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *PopSuite) TestPopBothEnds(c *C) {
+	m := fillMapRand(5000)
+	for m.Len() > 0 {
+		m.PopMinN(37)
+		c.Assert(m.Validate(), IsNil)
+		m.PopMaxN(53)
+		c.Assert(m.Validate(), IsNil)
+	}
+}
+
+func sortedEntries(n int) []Entry {
+	ret := make([]Entry, n)
+	for i := range ret {
+		ret[i] = Entry{i, i * 2}
+	}
+	return ret
+}
+
+func (s *PopSuite) TestFirstN(c *C) {
+	m := fillMap(100)
+	ref := sortedEntries(100)
+	c.Assert(m.FirstN(0), HasLen, 0)
+	c.Assert(m.FirstN(1), DeepEquals, ref[:1])
+	c.Assert(m.FirstN(20), DeepEquals, ref[:20])
+	c.Assert(m.FirstN(100), DeepEquals, ref)
+	c.Assert(m.FirstN(1000), DeepEquals, ref)
+	c.Assert(m.Len(), Equals, 100)
+	c.Assert(NewMap(compareInts).FirstN(5), HasLen, 0)
+}
+
+func (s *PopSuite) TestLastN(c *C) {
+	m := fillMap(100)
+	ref := sortedEntries(100)
+	c.Assert(m.LastN(0), HasLen, 0)
+	c.Assert(m.LastN(-3), HasLen, 0)
+	c.Assert(m.LastN(1), DeepEquals, ref[99:])
+	c.Assert(m.LastN(20), DeepEquals, ref[80:])
+	c.Assert(m.LastN(30), DeepEquals, ref[70:])
+	c.Assert(m.LastN(100), DeepEquals, ref)
+	c.Assert(m.LastN(1000), DeepEquals, ref)
+	c.Assert(m.Len(), Equals, 100)
+	c.Assert(NewMap(compareInts).LastN(5), HasLen, 0)
+}
+
+func (s *PopSuite) TestMinN(c *C) {
+	m := fillMapRand(100)
+	all := m.Keys()
+	keys, vals := m.MinN(10)
+	c.Assert(keys, DeepEquals, all[:10])
+	c.Assert(vals, HasLen, 10)
+	for i, k := range keys {
+		v, _ := m.Get(k)
+		c.Assert(vals[i], Equals, v)
+	}
+	keys, _ = m.MinN(1000)
+	c.Assert(keys, DeepEquals, all)
+	keys, vals = m.MinN(0)
+	c.Assert(keys, DeepEquals, []interface{}{})
+	c.Assert(vals, DeepEquals, []interface{}{})
+	keys, _ = m.MinN(-1)
+	c.Assert(keys, HasLen, 0)
+	c.Assert(m.Len(), Equals, 100)
+}
+
+func (s *PopSuite) TestMaxN(c *C) {
+	m := fillMapRand(100)
+	all := m.Keys()
+	keys, vals := m.MaxN(10)
+	c.Assert(keys, DeepEquals, all[90:])
+	v, _ := m.Get(all[99])
+	c.Assert(vals[9], Equals, v)
+	keys, _ = m.MaxN(1000)
+	c.Assert(keys, DeepEquals, all)
+	keys, _ = m.MaxN(0)
+	c.Assert(keys, DeepEquals, []interface{}{})
+	keys, _ = m.MaxN(-5)
+	c.Assert(keys, HasLen, 0)
+	keys, _ = NewMap(compareInts).MaxN(3)
+	c.Assert(keys, HasLen, 0)
+}
+
+func (s *PopSuite) TestMinMaxPop(c *C) {
+	m := fillMap(3)
+	k, v, ok := m.Min()
+	c.Assert([]interface{}{k, v, ok}, DeepEquals, []interface{}{0, 0, true})
+	k, v, ok = m.Max()
+	c.Assert([]interface{}{k, v, ok}, DeepEquals, []interface{}{2, 4, true})
+	k, _, _ = m.PopMax()
+	c.Assert(k, Equals, 2)
+	k, _, _ = m.PopMin()
+	c.Assert(k, Equals, 0)
+	k, _, _ = m.PopMin()
+	c.Assert(k, Equals, 1)
+	_, _, ok = m.PopMin()
+	c.Assert(ok, Equals, false)
+	_, _, ok = m.PopMax()
+	c.Assert(ok, Equals, false)
+	_, _, ok = m.Min()
+	c.Assert(ok, Equals, false)
+}
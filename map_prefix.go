@@ -0,0 +1,116 @@
+package skiplist
+
+import (
+	"errors"
+)
+
+// ErrNotByteOrdered is returned by PrefixRange for a map whose keys
+// are not in byte order, where the keys with a prefix may not all be
+// together. Range with bounds that suit the order can be used instead
+var ErrNotByteOrdered = errors.New("skiplist: map is not in byte order")
+
+// MatchPrefix returns the pairs whose keys match partial, in order,
+// for looking up composite keys by their leading fields. matches
+// compares a stored key with partial, returning a negative number if
+// the key sorts before every key matching partial, 0 if it matches and
+// a positive number if it sorts after them. the matching keys must be
+// together in the map's order, as they are when partial is a prefix of
+// the key, so the search finds the first in O(log n) and walks the rest.
+// matches is called under the read lock, so it must not use the map
+func (m *Map) MatchPrefix(partial interface{}, matches func(stored, partial interface{}) int) []Pair {
+	defer m.endOp("matchprefix", partial, m.startOp())
+	ret := []Pair{}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for e := prev.next[level]; e != nil && matches(e.key, partial) < 0; e = e.next[level] {
+			prev = e
+		}
+	}
+	for e := liveFrom(prev.next[0]); e != nil && matches(e.key, partial) == 0; e = liveFrom(e.next[0]) {
+		ret = append(ret, Pair{e.key, e.val})
+	}
+	return ret
+}
+
+// byteOrderSamples are strings on which orders that aren't byte order,
+// ignoring case, collating or descending, disagree with it
+var byteOrderSamples = []string{"", "\x00", "A", "B", "Z", "a", "aB", "ab", "b", "e", "z", "é", "\xff"}
+
+// byteOrdered returns true if the map orders keys made from strings by
+// key in byte order, trying it on byteOrderSamples. a comparator that
+// can't take such keys isn't. the answer is kept in byteOrder[kind]
+func (m *Map) byteOrdered(kind int, key func(s string) interface{}) (ret bool) {
+	switch m.byteOrder[kind].Load() {
+	case 1:
+		return true
+	case 2:
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			ret = false
+		}
+		if ret {
+			m.byteOrder[kind].Store(1)
+		} else {
+			m.byteOrder[kind].Store(2)
+		}
+	}()
+	for _, a := range byteOrderSamples {
+		for _, b := range byteOrderSamples {
+			if m.comp(key(a), key(b)) != (a < b) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// prefixEnd returns the smallest byte string greater than every string
+// starting with prefix, or nil if there is none, when prefix is empty
+// or all 0xff bytes
+func prefixEnd(prefix []byte) []byte {
+	end := len(prefix)
+	for end > 0 && prefix[end-1] == 0xff {
+		end--
+	}
+	if end == 0 {
+		return nil
+	}
+	ret := append([]byte(nil), prefix[:end]...)
+	ret[end-1]++
+	return ret
+}
+
+// PrefixRange calls fn for every pair with a string key starting with
+// prefix in key order, stopping early if fn returns false, like Range
+// with bounds at either end of the prefix. it returns ErrNotByteOrdered,
+// visiting nothing, if the map's keys are not in byte order
+func (m *Map) PrefixRange(prefix string, fn func(k, v interface{}) bool) error {
+	if !m.byteOrdered(0, func(s string) interface{} { return s }) {
+		return ErrNotByteOrdered
+	}
+	var to interface{}
+	if end := prefixEnd([]byte(prefix)); end != nil {
+		to = string(end)
+	}
+	m.Range(prefix, to, fn)
+	return nil
+}
+
+// PrefixRangeBytes is PrefixRange for a map with []byte keys
+func (m *Map) PrefixRangeBytes(prefix []byte, fn func(k, v interface{}) bool) error {
+	if !m.byteOrdered(1, func(s string) interface{} { return []byte(s) }) {
+		return ErrNotByteOrdered
+	}
+	var to interface{}
+	if end := prefixEnd(prefix); end != nil {
+		to = end
+	}
+	m.Range(prefix, to, fn)
+	return nil
+}
@@ -0,0 +1,124 @@
+package skiplist
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type PrefixSuite struct{}
+
+var _ = Suite(&PrefixSuite{})
+
+// item is a composite key ordered by category then id
+type item struct {
+	category string
+	id       int
+}
+
+func itemLess(a, b interface{}) bool {
+	x, y := a.(item), b.(item)
+	if x.category != y.category {
+		return x.category < y.category
+	}
+	return x.id < y.id
+}
+
+// byCategory matches items by their category alone
+func byCategory(stored, partial interface{}) int {
+	return strings.Compare(stored.(item).category, partial.(string))
+}
+
+func (s *PrefixSuite) TestMatchCategory(c *C) {
+	m := NewMap(itemLess)
+	for i := 0; i < 300; i++ {
+		m.Put(item{[]string{"books", "games", "music"}[i%3], i}, i)
+	}
+	books := m.MatchPrefix("books", byCategory)
+	c.Assert(books, HasLen, 100)
+	for i, p := range books {
+		c.Assert(p.Key, Equals, item{"books", i * 3})
+	}
+	music := m.MatchPrefix("music", byCategory)
+	c.Assert(music, HasLen, 100)
+	c.Assert(music[99].Val, Equals, 299)
+	c.Assert(m.MatchPrefix("film", byCategory), DeepEquals, []Pair{})
+	c.Assert(m.MatchPrefix("zines", byCategory), DeepEquals, []Pair{})
+	c.Assert(NewMap(itemLess).MatchPrefix("books", byCategory), DeepEquals, []Pair{})
+}
+
+func (s *PrefixSuite) TestMatchTuplePrefix(c *C) {
+	m := tenantMap()
+	// a tuple matches when partial is a prefix of it
+	prefix := func(stored, partial interface{}) int {
+		t := stored.(Tuple)[:len(partial.(Tuple))]
+		switch {
+		case m.comp(t, partial):
+			return -1
+		case m.comp(partial, t):
+			return 1
+		}
+		return 0
+	}
+	globex := m.MatchPrefix(Tuple{"globex"}, prefix)
+	c.Assert(globex, HasLen, 5)
+	c.Assert(globex[0].Key, DeepEquals, Tuple{"globex", int64(100)})
+	c.Assert(m.MatchPrefix(Tuple{"globex", int64(300)}, prefix), HasLen, 1)
+}
+
+func prefixKeys(c *C, m *Map, prefix string) []interface{} {
+	keys := []interface{}{}
+	c.Assert(m.PrefixRange(prefix, func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return true
+	}), IsNil)
+	return keys
+}
+
+func (s *PrefixSuite) TestPrefixRange(c *C) {
+	m := NewMap(compareStrings)
+	for _, k := range []string{"aa", "ab", "abc", "b", "ba", "bz", "c", "zz", "zz\xff", "zz\xff\xff", "\xff", "\xff\xff"} {
+		m.Put(k, k)
+	}
+	c.Assert(prefixKeys(c, m, "a"), DeepEquals, []interface{}{"aa", "ab", "abc"})
+	c.Assert(prefixKeys(c, m, "ab"), DeepEquals, []interface{}{"ab", "abc"})
+	c.Assert(prefixKeys(c, m, "b"), DeepEquals, []interface{}{"b", "ba", "bz"})
+	c.Assert(prefixKeys(c, m, "bb"), DeepEquals, []interface{}{})
+	c.Assert(prefixKeys(c, m, "zz"), DeepEquals, []interface{}{"zz", "zz\xff", "zz\xff\xff"})
+	// the bound for a prefix ending in 0xff carries into the byte before
+	c.Assert(prefixKeys(c, m, "zz\xff"), DeepEquals, []interface{}{"zz\xff", "zz\xff\xff"})
+	// with only 0xff bytes there is no bound, the scan runs to the end
+	c.Assert(prefixKeys(c, m, "\xff"), DeepEquals, []interface{}{"\xff", "\xff\xff"})
+	c.Assert(prefixKeys(c, m, ""), HasLen, m.Len())
+	n := 0
+	m.PrefixRange("a", func(k, v interface{}) bool {
+		n++
+		return false
+	})
+	c.Assert(n, Equals, 1)
+}
+
+func (s *PrefixSuite) TestPrefixRangeBytes(c *C) {
+	m := NewMap(func(a, b interface{}) bool { return string(a.([]byte)) < string(b.([]byte)) })
+	for _, k := range []string{"k1", "k2", "k\xff", "k\xff\x00", "l"} {
+		m.Put([]byte(k), k)
+	}
+	vals := []interface{}{}
+	c.Assert(m.PrefixRangeBytes([]byte("k\xff"), func(k, v interface{}) bool {
+		vals = append(vals, v)
+		return true
+	}), IsNil)
+	c.Assert(vals, DeepEquals, []interface{}{"k\xff", "k\xff\x00"})
+	// a string map isn't a []byte map
+	c.Assert(NewMap(compareStrings).PrefixRangeBytes([]byte("k"), nil), Equals, ErrNotByteOrdered)
+}
+
+func (s *PrefixSuite) TestPrefixRangeRefused(c *C) {
+	for _, m := range []*Map{NewMap(FoldLess), NewMap(CaseInsensitiveLess), NewDescendingMap(compareStrings)} {
+		m.Put("apple", 1)
+		c.Assert(m.PrefixRange("a", func(k, v interface{}) bool {
+			c.Fail()
+			return true
+		}), Equals, ErrNotByteOrdered)
+	}
+}
@@ -0,0 +1,158 @@
+package skiplist
+
+import (
+	"context"
+)
+
+// Range calls fn for every pair with from <= key < to in key order,
+// stopping early if fn returns false. a nil from starts at the first
+// key and a nil to runs to the end of the map. the read lock is held
+// throughout, so fn must not use the map, doing so panics
+func (m *Map) Range(from, to interface{}, fn func(k, v interface{}) bool) {
+	defer m.endOp("range", from, m.startOp())
+	from, to = m.normal(from), m.normal(to)
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	m.scan(from, to, fn)
+}
+
+// scan does the work of Range, the caller must hold the lock
+func (m *Map) scan(from, to interface{}, fn func(k, v interface{}) bool) {
+	for e := m.rangeStart(from); e != nil && m.inRange(e, to); e = liveFrom(e.next[0]) {
+		if !fn(e.key, e.val) {
+			break
+		}
+	}
+}
+
+// rangeStart returns the first live element not less than from,
+// a nil from meaning the first element. the caller must hold the lock
+func (m *Map) rangeStart(from interface{}) *mapElement {
+	if from == nil {
+		return liveFrom(m.head.next[0])
+	}
+	return liveFrom(m.lowerBound(from, nil))
+}
+
+// inRange returns true if e is before to, a nil to meaning the end
+func (m *Map) inRange(e *mapElement, to interface{}) bool {
+	return to == nil || m.comp(e.key, to)
+}
+
+// rangeCheckEvery is how many pairs RangeCtx visits between
+// looking at its context
+const rangeCheckEvery = 64
+
+// RangeCtx is Range for long scans that may be abandoned: every few
+// pairs it checks ctx, and once ctx is done it releases the lock and
+// returns ctx's error, having visited only part of the range. it
+// returns nil if the scan finishes or fn stops it
+func (m *Map) RangeCtx(ctx context.Context, from, to interface{}, fn func(k, v interface{}) bool) error {
+	defer m.endOp("rangectx", from, m.startOp())
+	from, to = m.normal(from), m.normal(to)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	n := 0
+	for e := m.rangeStart(from); e != nil && m.inRange(e, to); e = liveFrom(e.next[0]) {
+		n++
+		if n%rangeCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if !fn(e.key, e.val) {
+			break
+		}
+	}
+	return nil
+}
+
+// AppendKeys appends every key in the map to dst in order and returns
+// the extended slice, allocating only if dst runs out of capacity
+func (m *Map) AppendKeys(dst []interface{}) []interface{} {
+	defer m.endOp("appendkeys", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		dst = append(dst, e.key)
+	}
+	return dst
+}
+
+// AppendRange appends the pairs Range would visit for from and to
+// to dst in order and returns the extended slice, allocating only if
+// dst runs out of capacity
+func (m *Map) AppendRange(dst []Entry, from, to interface{}) []Entry {
+	defer m.endOp("appendrange", from, m.startOp())
+	from, to = m.normal(from), m.normal(to)
+	locked := m.rlock()
+	defer m.runlock(locked)
+	for e := m.rangeStart(from); e != nil && m.inRange(e, to); e = liveFrom(e.next[0]) {
+		dst = append(dst, Entry{e.key, e.val})
+	}
+	return dst
+}
+
+// Entries returns all the pairs in the map in order
+func (m *Map) Entries() []Entry {
+	defer m.endOp("entries", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	ret := make([]Entry, 0, m.length)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		ret = append(ret, Entry{e.key, e.val})
+	}
+	return ret
+}
+
+// Pair is another name for Entry
+type Pair = Entry
+
+// RangeSnapshot copies the pairs with lo <= key <= hi into a slice in
+// key order, holding the read lock only while it copies, so the pairs
+// can be worked on without holding up writers. a nil lo or hi leaves
+// that end of the range open
+func (m *Map) RangeSnapshot(lo, hi interface{}) []Pair {
+	defer m.endOp("rangesnapshot", lo, m.startOp())
+	lo, hi = m.normal(lo), m.normal(hi)
+	ret := []Pair{}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	for e := m.rangeStart(lo); e != nil && (hi == nil || !m.comp(hi, e.key)); e = liveFrom(e.next[0]) {
+		ret = append(ret, Pair{e.key, e.val})
+	}
+	return ret
+}
+
+// Find returns the first pair in key order that pred returns true for,
+// and true, or false if there is none. it stops at the first match
+func (m *Map) Find(pred func(k, v interface{}) bool) (Entry, bool) {
+	return m.FindInRange(nil, nil, pred)
+}
+
+// FindInRange is Find over the pairs Range would visit for from and to,
+// with from <= key < to and a nil bound leaving that end open. the read
+// lock is held throughout, so pred must not use the map
+func (m *Map) FindInRange(from, to interface{}, pred func(k, v interface{}) bool) (Entry, bool) {
+	defer m.endOp("findinrange", from, m.startOp())
+	from, to = m.normal(from), m.normal(to)
+	ret, ok := Entry{}, false
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	for e := m.rangeStart(from); e != nil && m.inRange(e, to); e = liveFrom(e.next[0]) {
+		if pred(e.key, e.val) {
+			ret, ok = Entry{e.key, e.val}, true
+			break
+		}
+	}
+	return ret, ok
+}
@@ -0,0 +1,184 @@
+package skiplist
+
+import (
+	"context"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+type RangeSuite struct{}
+
+var _ = Suite(&RangeSuite{})
+
+// collect returns the pairs Range visits
+func collect(m *Map, from, to interface{}) []Entry {
+	ret := []Entry{}
+	m.Range(from, to, func(k, v interface{}) bool {
+		ret = append(ret, Entry{k, v})
+		return true
+	})
+	return ret
+}
+
+func (s *RangeSuite) TestRange(c *C) {
+	m := fillMap(100)
+	ref := sortedEntries(100)
+	c.Assert(collect(m, 10, 20), DeepEquals, ref[10:20])
+	c.Assert(collect(m, nil, 5), DeepEquals, ref[:5])
+	c.Assert(collect(m, 95, nil), DeepEquals, ref[95:])
+	c.Assert(collect(m, nil, nil), DeepEquals, ref)
+	c.Assert(collect(m, -10, 1000), DeepEquals, ref)
+	c.Assert(collect(m, 20, 20), HasLen, 0)
+	c.Assert(collect(m, 30, 20), HasLen, 0)
+	c.Assert(collect(NewMap(compareInts), nil, nil), HasLen, 0)
+}
+
+func (s *RangeSuite) TestRangeBoundsBetweenKeys(c *C) {
+	m := NewMap(compareInts)
+	for i := 0; i < 100; i += 10 {
+		m.Put(i, i)
+	}
+	c.Assert(collect(m, 15, 45), DeepEquals, []Entry{{20, 20}, {30, 30}, {40, 40}})
+}
+
+func (s *RangeSuite) TestRangeStop(c *C) {
+	m := fillMap(100)
+	n := 0
+	m.Range(nil, nil, func(k, v interface{}) bool {
+		n++
+		return n < 7
+	})
+	c.Assert(n, Equals, 7)
+}
+
+func (s *RangeSuite) TestRangeCtx(c *C) {
+	m := fillMap(1000)
+	got := []Entry{}
+	err := m.RangeCtx(context.Background(), 10, 500, func(k, v interface{}) bool {
+		got = append(got, Entry{k, v})
+		return true
+	})
+	c.Assert(err, IsNil)
+	c.Assert(got, DeepEquals, collect(m, 10, 500))
+}
+
+func (s *RangeSuite) TestRangeCtxCancel(c *C) {
+	m := fillMap(100000)
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err := m.RangeCtx(ctx, nil, nil, func(k, v interface{}) bool {
+		n++
+		if n == 1000 {
+			cancel()
+		}
+		return true
+	})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(n >= 1000 && n < 1000+rangeCheckEvery, Equals, true, Commentf("visited %d", n))
+	// the lock was released
+	c.Assert(m.mutex.TryLock(), Equals, true)
+	m.mutex.Unlock()
+	m.Put(-1, -1)
+}
+
+func (s *RangeSuite) TestRangeCtxAlreadyDone(c *C) {
+	m := fillMap(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := m.RangeCtx(ctx, nil, nil, func(k, v interface{}) bool {
+		c.Fatal("should not be called")
+		return true
+	})
+	c.Assert(err, Equals, context.Canceled)
+}
+
+func (s *RangeSuite) TestAppendKeys(c *C) {
+	m := fillMap(100)
+	c.Assert(m.AppendKeys(nil), DeepEquals, m.Keys())
+	dst := []interface{}{"x"}
+	c.Assert(m.AppendKeys(dst)[1:], DeepEquals, m.Keys())
+	c.Assert(m.AppendKeys(dst)[0], Equals, "x")
+	buf := make([]interface{}, 0, 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = m.AppendKeys(buf[:0])
+	})
+	c.Assert(allocs, Equals, 0.0)
+	c.Assert(buf, HasLen, 100)
+}
+
+func (s *RangeSuite) TestAppendRange(c *C) {
+	m := fillMap(100)
+	for _, r := range [][2]interface{}{{10, 20}, {nil, 5}, {95, nil}, {nil, nil}, {20, 20}, {-5, 1000}} {
+		c.Assert(m.AppendRange([]Entry{}, r[0], r[1]), DeepEquals, collect(m, r[0], r[1]))
+	}
+	dst := []Entry{{"x", "y"}}
+	c.Assert(m.AppendRange(dst, 3, 5), DeepEquals, []Entry{{"x", "y"}, {3, 6}, {4, 8}})
+	buf := make([]Entry, 0, 100)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = m.AppendRange(buf[:0], 10, 90)
+	})
+	c.Assert(allocs, Equals, 0.0)
+	c.Assert(buf, HasLen, 80)
+}
+
+func (s *RangeSuite) TestEntries(c *C) {
+	c.Assert(fillMap(100).Entries(), DeepEquals, sortedEntries(100))
+	c.Assert(NewMap(compareInts).Entries(), HasLen, 0)
+}
+
+func (s *RangeSuite) TestRangeSnapshot(c *C) {
+	m := fillMap(100)
+	snap := m.RangeSnapshot(10, 20)
+	c.Assert(snap, HasLen, 11)
+	c.Assert(snap[0], Equals, Pair{10, 20})
+	c.Assert(snap[10], Equals, Pair{20, 40})
+	// later changes don't reach the snapshot
+	m.Put(15, -1)
+	m.Remove(10)
+	m.Put(21, 0)
+	c.Assert(snap, DeepEquals, fillMap(100).RangeSnapshot(10, 20))
+	c.Assert(m.RangeSnapshot(nil, 1), DeepEquals, []Pair{{0, 0}, {1, 2}})
+	c.Assert(m.RangeSnapshot(98, nil), DeepEquals, []Pair{{98, 196}, {99, 198}})
+	c.Assert(m.RangeSnapshot(50, 40), DeepEquals, []Pair{})
+	c.Assert(m.RangeSnapshot(nil, nil), DeepEquals, m.Entries())
+}
+
+func (s *RangeSuite) TestFind(c *C) {
+	m := fillMap(100)
+	visited := 0
+	e, ok := m.Find(func(k, v interface{}) bool {
+		visited++
+		return true
+	})
+	c.Assert(ok, Equals, true)
+	c.Assert(e.Key, Equals, 0)
+	c.Assert(visited, Equals, 1)
+	e, ok = m.Find(func(k, v interface{}) bool { return k.(int) == 99 })
+	c.Assert(ok, Equals, true)
+	c.Assert(e.Key, Equals, 99)
+	_, ok = m.Find(func(k, v interface{}) bool { return k.(int) > 99 })
+	c.Assert(ok, Equals, false)
+	_, ok = NewMap(compareInts).Find(func(k, v interface{}) bool { return true })
+	c.Assert(ok, Equals, false)
+}
+
+func (s *RangeSuite) TestFindInRange(c *C) {
+	m := fillMap(100)
+	even := func(k, v interface{}) bool { return k.(int)%2 == 0 }
+	e, ok := m.FindInRange(31, 40, even)
+	c.Assert(ok, Equals, true)
+	c.Assert(e.Key, Equals, 32)
+	// from is inclusive
+	e, _ = m.FindInRange(30, 40, even)
+	c.Assert(e.Key, Equals, 30)
+	// to is exclusive
+	_, ok = m.FindInRange(31, 32, even)
+	c.Assert(ok, Equals, false)
+	e, _ = m.FindInRange(nil, 10, func(k, v interface{}) bool { return k.(int) > 5 })
+	c.Assert(e.Key, Equals, 6)
+	e, _ = m.FindInRange(95, nil, func(k, v interface{}) bool { return k.(int)%7 == 0 })
+	c.Assert(e.Key, Equals, 98)
+	_, ok = m.FindInRange(50, 40, even)
+	c.Assert(ok, Equals, false)
+}
@@ -0,0 +1,222 @@
+package skiplist
+
+import (
+	"math"
+)
+
+// elementAt returns the element at the zero based position i, using
+// the spans to skip along the levels, or nil if i is out of range.
+// tombstones take no room in the spans, so it is always a live pair.
+// if backPointer is not nil it is filled with the last element
+// before position i at each level (the head when nothing comes before it)
+func (m *Map) elementAt(i int, backPointer []*mapElement) *mapElement {
+	if i < 0 {
+		return nil
+	}
+	// pos is the one based rank of prev, the head being rank 0
+	pos := 0
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && pos+prev.span[level] <= i; next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+		if backPointer != nil {
+			backPointer[level] = prev
+		}
+	}
+	return prev.next[0]
+}
+
+// GetByRank returns the pair at the zero based position i in key
+// order, and true if i is in range, false otherwise
+func (m *Map) GetByRank(i int) (interface{}, interface{}, bool) {
+	defer m.endOp("getbyrank", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := m.elementAt(i, nil)
+	if e == nil {
+		return nil, nil, false
+	}
+	return e.key, e.val, true
+}
+
+// Rank returns how many pairs have keys less than k, which is the zero
+// based position of k when it is in the map, as GetByRank counts it, or
+// the position it would take if it were put. a key before every pair,
+// or any key in an empty map, gives 0, and a key after every pair gives
+// the length of the map
+func (m *Map) Rank(k interface{}) int {
+	defer m.endOp("rank", k, m.startOp())
+	k = m.normal(k)
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.countLess(k)
+}
+
+// Quantile returns the pair at rank floor(q*(Len-1)), so 0 gives the
+// smallest key, 0.5 the median and 1 the largest. q outside [0, 1]
+// is rejected, returning false, as is an empty map
+func (m *Map) Quantile(q float64) (interface{}, interface{}, bool) {
+	defer m.endOp("quantile", nil, m.startOp())
+	if math.IsNaN(q) || q < 0 || q > 1 {
+		return nil, nil, false
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if m.length == 0 {
+		return nil, nil, false
+	}
+	e := m.elementAt(int(math.Floor(q*float64(m.length-1))), nil)
+	return e.key, e.val, true
+}
+
+// SelectRange returns the keys and values at the zero based positions
+// from up to but not including to. the positions are clamped to the
+// map, so out of range parts are left out
+func (m *Map) SelectRange(from, to int) ([]interface{}, []interface{}) {
+	defer m.endOp("selectrange", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.selectRange(from, to)
+}
+
+// selectRange does the work of SelectRange, the caller must hold the lock
+func (m *Map) selectRange(from, to int) ([]interface{}, []interface{}) {
+	if from < 0 {
+		from = 0
+	}
+	if to > m.length {
+		to = m.length
+	}
+	if from >= to {
+		return []interface{}{}, []interface{}{}
+	}
+	keys := make([]interface{}, 0, to-from)
+	vals := make([]interface{}, 0, to-from)
+	for i, e := from, m.elementAt(from, nil); i < to; i, e = i+1, liveFrom(e.next[0]) {
+		keys = append(keys, e.key)
+		vals = append(vals, e.val)
+	}
+	return keys, vals
+}
+
+// countLess returns how many live pairs have keys less than k,
+// using the spans. the caller must hold the lock
+func (m *Map) countLess(k interface{}) int {
+	pos := 0
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && m.comp(next.key, k); next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+	}
+	return pos
+}
+
+// rankBounds turns start and stop, inclusive positions that count back
+// from the end when negative, into a zero based from and exclusive to
+// clamped to the map. the caller must hold the lock
+func (m *Map) rankBounds(start, stop int) (int, int) {
+	if start < 0 {
+		start += m.length
+	}
+	if stop < 0 {
+		stop += m.length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= m.length {
+		stop = m.length - 1
+	}
+	return start, stop + 1
+}
+
+// RemoveByRank removes the pair at the zero based position i in key
+// order and returns it, and true if i is in range, false otherwise
+func (m *Map) RemoveByRank(i int) (Entry, bool) {
+	defer m.endOp("removebyrank", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	var backPointer [maxHeight]*mapElement
+	e := m.elementAt(i, backPointer[:])
+	if e == nil {
+		return Entry{}, false
+	}
+	m.unlink(e, backPointer[:])
+	return Entry{e.key, e.val}, true
+}
+
+// GetByRankRange returns the pairs at positions start through stop,
+// both included, in key order. like a redis ZRANGE negative positions
+// count back from the end, -1 being the last pair, and positions past
+// either end are clamped
+func (m *Map) GetByRankRange(start, stop int) []Entry {
+	defer m.endOp("getbyrankrange", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	from, to := m.rankBounds(start, stop)
+	ret := []Entry{}
+	if from < to {
+		ret = make([]Entry, 0, to-from)
+		for i, e := from, m.elementAt(from, nil); i < to; i, e = i+1, liveFrom(e.next[0]) {
+			ret = append(ret, Entry{e.key, e.val})
+		}
+	}
+	return ret
+}
+
+// RemoveByRankRange removes the pairs at positions start through stop,
+// read as GetByRankRange reads them, and returns how many it removed.
+// tombstones between them are unlinked too
+func (m *Map) RemoveByRankRange(start, stop int) int {
+	defer m.endOp("removebyrankrange", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	from, to := m.rankBounds(start, stop)
+	n := 0
+	if from < to {
+		// backPointer stays before the next element as each is unlinked
+		var backPointer [maxHeight]*mapElement
+		e := m.elementAt(from, backPointer[:])
+		for n < to-from {
+			next := e.next[0]
+			if !e.deleted {
+				n++
+			}
+			m.unlink(e, backPointer[:])
+			e = next
+		}
+	}
+	return n
+}
+
+// SelectMany returns the keys and values at each of the zero based
+// positions in ranks. it finds the first position by rank and walks
+// level 0 from there to the next, so ranks in ascending order are
+// quickest, a rank before the one just found being searched for again.
+// a position out of range gives a nil key and value
+func (m *Map) SelectMany(ranks []int) ([]interface{}, []interface{}) {
+	defer m.endOp("selectmany", nil, m.startOp())
+	keys := make([]interface{}, len(ranks))
+	vals := make([]interface{}, len(ranks))
+	locked := m.rlock()
+	defer m.runlock(locked)
+	var e *mapElement
+	pos := 0
+	for i, r := range ranks {
+		if r < 0 || r >= m.length {
+			continue
+		}
+		if e == nil || r < pos {
+			e, pos = m.elementAt(r, nil), r
+		}
+		for ; pos < r; pos++ {
+			e = liveFrom(e.next[0])
+		}
+		keys[i], vals[i] = e.key, e.val
+	}
+	return keys, vals
+}
@@ -0,0 +1,241 @@
+package skiplist
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+type RankSuite struct{}
+
+var _ = Suite(&RankSuite{})
+
+// fillMapRandKeys fills a map with n random keys, returning them sorted
+func fillMapRandKeys(n int, seed int64) (*Map, []int) {
+	m := NewMap(compareInts)
+	r := rand.New(rand.NewSource(seed))
+	for m.Len() < n {
+		k := r.Intn(n * 10)
+		m.Put(k, k*2)
+	}
+	keys := []int{}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		keys = append(keys, e.key.(int))
+	}
+	sort.Ints(keys)
+	return m, keys
+}
+
+func (s *RankSuite) TestGetByRank(c *C) {
+	m, keys := fillMapRandKeys(1000, 1)
+	for i, k := range keys {
+		x, v, ok := m.GetByRank(i)
+		c.Assert(ok, Equals, true)
+		c.Assert(x, Equals, k)
+		c.Assert(v, Equals, k*2)
+	}
+	_, _, ok := m.GetByRank(-1)
+	c.Assert(ok, Equals, false)
+	_, _, ok = m.GetByRank(1000)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *RankSuite) TestSpansAfterRemoves(c *C) {
+	m, keys := fillMapRandKeys(2000, 2)
+	r := rand.New(rand.NewSource(3))
+	for len(keys) > 0 {
+		i := r.Intn(len(keys))
+		c.Assert(m.Remove(keys[i]), Equals, true)
+		keys = append(keys[:i], keys[i+1:]...)
+		if len(keys)%97 == 0 {
+			c.Assert(m.Validate(), IsNil)
+			for j := 0; j < len(keys); j += 13 {
+				x, _, _ := m.GetByRank(j)
+				c.Assert(x, Equals, keys[j])
+			}
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *RankSuite) TestQuantile(c *C) {
+	m, keys := fillMapRandKeys(999, 4)
+	for _, q := range []float64{0, 0.01, 0.25, 0.5, 0.75, 0.99, 1} {
+		k, v, ok := m.Quantile(q)
+		c.Assert(ok, Equals, true)
+		want := keys[int(math.Floor(q*float64(len(keys)-1)))]
+		c.Assert(k, Equals, want)
+		c.Assert(v, Equals, want*2)
+	}
+	k, _, _ := m.Quantile(0.5)
+	c.Assert(k, Equals, keys[499])
+}
+
+func (s *RankSuite) TestQuantileOneElement(c *C) {
+	m := NewMap(compareInts)
+	m.Put(7, 14)
+	for _, q := range []float64{0, 0.5, 1} {
+		k, v, ok := m.Quantile(q)
+		c.Assert(ok, Equals, true)
+		c.Assert(k, Equals, 7)
+		c.Assert(v, Equals, 14)
+	}
+}
+
+func (s *RankSuite) TestQuantileRejected(c *C) {
+	m := fillMap(10)
+	for _, q := range []float64{-0.1, 1.1, math.NaN(), math.Inf(1)} {
+		_, _, ok := m.Quantile(q)
+		c.Assert(ok, Equals, false)
+	}
+	_, _, ok := NewMap(compareInts).Quantile(0.5)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *RankSuite) TestSelectRange(c *C) {
+	m, _ := fillMapRandKeys(500, 5)
+	all := m.Keys()
+	for _, r := range [][2]int{{0, 10}, {100, 150}, {490, 500}, {0, 500}, {250, 251}} {
+		keys, vals := m.SelectRange(r[0], r[1])
+		c.Assert(keys, DeepEquals, all[r[0]:r[1]])
+		for i, k := range keys {
+			c.Assert(vals[i], Equals, k.(int)*2)
+		}
+	}
+}
+
+func (s *RankSuite) TestSelectRangeClamped(c *C) {
+	m := fillMap(10)
+	all := m.Keys()
+	keys, _ := m.SelectRange(-5, 3)
+	c.Assert(keys, DeepEquals, all[:3])
+	keys, _ = m.SelectRange(7, 100)
+	c.Assert(keys, DeepEquals, all[7:])
+	keys, vals := m.SelectRange(5, 5)
+	c.Assert(keys, HasLen, 0)
+	c.Assert(vals, HasLen, 0)
+	keys, _ = m.SelectRange(8, 2)
+	c.Assert(keys, HasLen, 0)
+	keys, _ = m.SelectRange(20, 30)
+	c.Assert(keys, HasLen, 0)
+}
+
+func (s *RankSuite) TestGetByRankRange(c *C) {
+	m, _ := fillMapRandKeys(200, 7)
+	all := m.Entries()
+	c.Assert(m.GetByRankRange(0, -1), DeepEquals, all)
+	c.Assert(m.GetByRankRange(10, 19), DeepEquals, all[10:20])
+	c.Assert(m.GetByRankRange(-10, -1), DeepEquals, all[190:])
+	c.Assert(m.GetByRankRange(-500, 2), DeepEquals, all[:3])
+	c.Assert(m.GetByRankRange(195, 1000), DeepEquals, all[195:])
+	c.Assert(m.GetByRankRange(5, 5), DeepEquals, all[5:6])
+	c.Assert(m.GetByRankRange(6, 5), DeepEquals, []Entry{})
+	c.Assert(m.GetByRankRange(200, 300), DeepEquals, []Entry{})
+	c.Assert(m.GetByRankRange(-1, -2), DeepEquals, []Entry{})
+	c.Assert(NewMap(compareInts).GetByRankRange(0, -1), DeepEquals, []Entry{})
+}
+
+func (s *RankSuite) TestRemoveByRankRange(c *C) {
+	for _, r := range [][2]int{{0, -1}, {10, 19}, {-10, -1}, {-500, 2}, {195, 1000}, {5, 5}, {6, 5}, {0, 0}, {-1, -1}} {
+		m, _ := fillMapRandKeys(200, 7)
+		all := m.Entries()
+		want := append([]Entry{}, all...)
+		removed := m.GetByRankRange(r[0], r[1])
+		for i, e := range all {
+			if len(removed) > 0 && e.Key == removed[0].Key {
+				want = append(want[:i], want[i+len(removed):]...)
+			}
+		}
+		c.Assert(m.RemoveByRankRange(r[0], r[1]), Equals, len(removed))
+		c.Assert(m.Validate(), IsNil)
+		c.Assert(m.Entries(), DeepEquals, want)
+	}
+}
+
+func (s *RankSuite) TestSelectMany(c *C) {
+	m, _ := fillMapRandKeys(1001, 3)
+	ranks := []int{0, 500, 500, 1000}
+	keys, vals := m.SelectMany(ranks)
+	for i, r := range ranks {
+		k, v, _ := m.GetByRank(r)
+		c.Assert(keys[i], Equals, k)
+		c.Assert(vals[i], Equals, v)
+	}
+	keys, vals = m.SelectMany([]int{-1, 3, 1001})
+	c.Assert(keys[0], IsNil)
+	c.Assert(vals[2], IsNil)
+	k, _, _ := m.GetByRank(3)
+	c.Assert(keys[1], Equals, k)
+	keys, _ = m.SelectMany(nil)
+	c.Assert(keys, HasLen, 0)
+}
+
+func (s *RankSuite) TestSelectManyUnsorted(c *C) {
+	m, _ := fillMapRandKeys(1001, 3)
+	ranks := []int{700, 2, 999, 2, -5, 0, 700, 1000, 1}
+	keys, vals := m.SelectMany(ranks)
+	for i, r := range ranks {
+		k, v, _ := m.GetByRank(r)
+		c.Assert(keys[i], Equals, k, Commentf("rank %d", r))
+		c.Assert(vals[i], Equals, v, Commentf("rank %d", r))
+	}
+}
+
+func (s *RankSuite) TestRemoveByRank(c *C) {
+	m, _ := fillMapRandKeys(500, 11)
+	ref := m.Entries()
+	r := rand.New(rand.NewSource(5))
+	for len(ref) > 0 {
+		i := r.Intn(len(ref))
+		e, ok := m.RemoveByRank(i)
+		c.Assert(ok, Equals, true)
+		c.Assert(e, DeepEquals, ref[i])
+		ref = append(ref[:i], ref[i+1:]...)
+		if len(ref)%50 == 0 {
+			c.Assert(m.Validate(), IsNil)
+			c.Assert(m.Entries(), DeepEquals, ref)
+		}
+	}
+	_, ok := m.RemoveByRank(0)
+	c.Assert(ok, Equals, false)
+	m = fillMap(3)
+	for _, i := range []int{-1, 3, 100} {
+		_, ok = m.RemoveByRank(i)
+		c.Assert(ok, Equals, false)
+	}
+	c.Assert(m.Len(), Equals, 3)
+}
+
+func (s *RankSuite) TestRank(c *C) {
+	c.Assert(NewMap(compareInts).Rank(5), Equals, 0)
+	m, keys := fillMapRandKeys(1000, 2)
+	n := len(keys)
+	// below the first key, at the first, at the last and past the last
+	c.Assert(m.Rank(keys[0]-1), Equals, 0)
+	c.Assert(m.Rank(keys[0]), Equals, 0)
+	c.Assert(m.Rank(keys[n-1]), Equals, n-1)
+	c.Assert(m.Rank(keys[n-1]+1), Equals, n)
+	c.Assert(m.Rank(math.MaxInt), Equals, n)
+	c.Assert(m.Rank(math.MinInt), Equals, 0)
+	for i, k := range keys {
+		c.Assert(m.Rank(k), Equals, i)
+		x, _, _ := m.GetByRank(m.Rank(k))
+		c.Assert(x, Equals, k)
+	}
+	// a key between two others takes the place of the larger
+	for i := 1; i < n; i++ {
+		if keys[i]-keys[i-1] > 1 {
+			c.Assert(m.Rank(keys[i]-1), Equals, i)
+		}
+	}
+}
+
+func (s *RankSuite) TestRankOneElement(c *C) {
+	m := NewMap(compareInts)
+	m.Put(10, 10)
+	c.Assert(m.Rank(9), Equals, 0)
+	c.Assert(m.Rank(10), Equals, 0)
+	c.Assert(m.Rank(11), Equals, 1)
+}
@@ -0,0 +1,99 @@
+package skiplist
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// callbacks keeps track of the goroutines running a callback while
+// holding a map's lock. the mutex is not reentrant, so a call back into
+// the map from one of them would hang; lock and rlock panic instead
+type callbacks struct {
+	running atomic.Int32
+	mutex   sync.Mutex
+	ids     map[uint64]int
+}
+
+// goid returns the id of the calling goroutine, read from the first
+// line of its stack trace, "goroutine 123 [running]:"
+func goid() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// enter marks the calling goroutine as running a callback,
+// returning its id for leave
+func (cb *callbacks) enter() uint64 {
+	id := goid()
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.ids == nil {
+		cb.ids = map[uint64]int{}
+	}
+	cb.ids[id]++
+	cb.running.Add(1)
+	return id
+}
+
+// leave undoes enter once the callback returns
+func (cb *callbacks) leave(id uint64) {
+	cb.running.Add(-1)
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.ids[id]--; cb.ids[id] == 0 {
+		delete(cb.ids, id)
+	}
+}
+
+// check panics if the calling goroutine is running a callback. while
+// no callback is running anywhere it is a single atomic load, so the
+// stack is only read when a call might be reentrant
+func (cb *callbacks) check() {
+	if cb.running.Load() == 0 {
+		return
+	}
+	if cb.count(goid()) > 0 {
+		panic("skiplist: reentrant call during callback")
+	}
+}
+
+// count returns how many callbacks the goroutine id is running
+func (cb *callbacks) count(id uint64) int {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	return cb.ids[id]
+}
+
+// ForEach calls fn for every pair in key order. the read lock is held
+// throughout, and fn calling back into the map panics rather than
+// deadlocking
+func (m *Map) ForEach(fn func(k, v interface{})) {
+	m.Range(nil, nil, func(k, v interface{}) bool {
+		fn(k, v)
+		return true
+	})
+}
+
+// ForEachKey calls fn for every key in order, stopping early if fn
+// returns false, for callers with no use for the values. like ForEach,
+// fn calling back into the map panics
+func (m *Map) ForEachKey(fn func(k interface{}) bool) {
+	defer m.endOp("foreachkey", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		if !fn(e.key) {
+			break
+		}
+	}
+}
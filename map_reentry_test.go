@@ -0,0 +1,136 @@
+package skiplist
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type ReentrySuite struct{}
+
+var _ = Suite(&ReentrySuite{})
+
+const reentrantPanic = "skiplist: reentrant call during callback"
+
+func (s *ReentrySuite) TestForEachPut(c *C) {
+	m := fillMap(10)
+	n := 0
+	m.ForEach(func(k, v interface{}) {
+		c.Assert(k, Equals, n)
+		n++
+	})
+	c.Assert(n, Equals, 10)
+	c.Assert(func() {
+		m.ForEach(func(k, v interface{}) { m.Put(k.(int)+100, v) })
+	}, PanicMatches, reentrantPanic)
+}
+
+func (s *ReentrySuite) TestForEachKey(c *C) {
+	m, keys := fillMapRandKeys(200, 7)
+	got := []int{}
+	m.ForEachKey(func(k interface{}) bool {
+		got = append(got, k.(int))
+		return true
+	})
+	c.Assert(got, DeepEquals, keys)
+	got = got[:0]
+	m.ForEachKey(func(k interface{}) bool {
+		got = append(got, k.(int))
+		return len(got) < 3
+	})
+	c.Assert(got, DeepEquals, keys[:3])
+	c.Assert(func() {
+		m.ForEachKey(func(k interface{}) bool { return m.Remove(k) })
+	}, PanicMatches, reentrantPanic)
+}
+
+func (s *ReentrySuite) TestOtherCallbacks(c *C) {
+	m := NewMap(compareInts)
+	m.Put(1, 1)
+	c.Assert(func() {
+		m.Transaction(func(tx *Txn) { m.Get(1) })
+	}, PanicMatches, reentrantPanic)
+	// the panic let the lock go, so the map can still be used
+	c.Assert(func() {
+		m.WithRLock(func(view ReadView) { m.Len() })
+	}, PanicMatches, reentrantPanic)
+	c.Assert(func() {
+		m.ReplaceIf(func(k, v interface{}) bool { return m.Remove(k) }, nil)
+	}, PanicMatches, reentrantPanic)
+	c.Assert(m.Put(1, 2), Equals, true)
+	c.Assert(m.Len(), Equals, 1)
+}
+
+func (s *ReentrySuite) TestOtherGoroutinesWait(c *C) {
+	m := fillMap(10)
+	var wg sync.WaitGroup
+	started := make(chan bool)
+	m.Transaction(func(tx *Txn) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started <- true
+			// another goroutine isn't reentrant, it waits its turn
+			m.Put(100, 100)
+		}()
+		<-started
+		tx.Put(50, 50)
+	})
+	wg.Wait()
+	c.Assert(m.Len(), Equals, 12)
+	// nesting a callback on another map is fine
+	other := fillMap(3)
+	m.ForEach(func(k, v interface{}) {
+		other.Range(nil, nil, func(k, v interface{}) bool { return true })
+	})
+	other.Put(5, 5)
+	c.Assert(other.Len(), Equals, 4)
+}
+
+func (s *ReentrySuite) TestCallbacksUnderLock(c *C) {
+	m := NewMultiMap(compareInts)
+	for i := 0; i < 10; i += 2 {
+		m.Put(i, i)
+	}
+	other := fillMap(5)
+	dist := func(a, b interface{}) float64 {
+		m.Len()
+		return float64(a.(int) - b.(int))
+	}
+	calls := map[string]func(){
+		"RemoveValue": func() {
+			m.RemoveValue(2, 2, func(a, b interface{}) bool { return m.Len() > 0 })
+		},
+		"Diff": func() {
+			m.Diff(other, func(a, b interface{}) bool { return m.Len() > 0 })
+		},
+		"Diff other": func() {
+			m.Diff(other, func(a, b interface{}) bool { return other.Len() > 0 })
+		},
+		"Nearest":     func() { m.Nearest(3, dist) },
+		"GetClosestN": func() { m.GetClosestN(3, 3, dist) },
+		"MatchPrefix": func() {
+			m.MatchPrefix(2, func(stored, partial interface{}) int { return m.Len() })
+		},
+		"Checksum": func() {
+			m.Checksum(func(v interface{}) []byte { return encodeJSON(m.Len()) })
+		},
+		"Fingerprint": func() {
+			m.Fingerprint(func(h io.Writer, k, v interface{}) { m.Len() })
+		},
+		"WriteDOT": func() {
+			m.WriteDOT(io.Discard, func(k interface{}) string { return fmt.Sprint(m.Len()) })
+		},
+	}
+	for what, call := range calls {
+		c.Assert(call, PanicMatches, reentrantPanic, Commentf(what))
+		// the panic let the lock go
+		c.Assert(m.Put(9, 9), Equals, false, Commentf(what))
+		c.Assert(other.Put(9, 9), Equals, false, Commentf(what))
+		m.RemoveValue(9, 9, equalValues)
+		other.Remove(9)
+	}
+	c.Assert(m.Len(), Equals, 5)
+}
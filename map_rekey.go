@@ -0,0 +1,56 @@
+package skiplist
+
+// ReKey moves the value stored under oldKey to newKey in one step,
+// so no reader sees the value under both keys or under neither.
+// it returns ErrKeyNotFound if oldKey is missing and ErrKeyExists if
+// newKey is already in the map, changing nothing in either case, see
+// ReKeyOverwrite for a move that overwrites newKey instead. a
+// newKey equal to oldKey by the comparator just replaces the stored
+// key. in a multimap the first pair for oldKey is moved and newKey
+// may already exist, the pair goes after any others for it.
+// it panics if newKey is nil
+func (m *Map) ReKey(oldKey, newKey interface{}) error {
+	defer m.endOp("rekey", oldKey, m.startOp())
+	oldKey, newKey = m.normal(oldKey), m.normal(newKey)
+	checkKey(newKey)
+	if oldKey == nil {
+		return ErrKeyNotFound
+	}
+	m.lock()
+	defer m.mutex.Unlock()
+	e := m.find(oldKey, nil)
+	if e == nil {
+		return ErrKeyNotFound
+	}
+	if m.comp(oldKey, newKey) == m.comp(newKey, oldKey) {
+		e.key = newKey
+		m.notify(OpUpdate, newKey, e.val, e.val)
+		return nil
+	}
+	if _, ok := m.get(newKey); ok && !m.multi {
+		return ErrKeyExists
+	}
+	m.remove(oldKey)
+	m.put(newKey, e.val)
+	return nil
+}
+
+// ReKeyOverwrite moves the value stored under oldKey to newKey in one
+// step like ReKey, but a pair already under newKey is overwritten
+// rather than being an error. it returns true if oldKey was in the map,
+// false otherwise, changing nothing. it panics if newKey is nil
+func (m *Map) ReKeyOverwrite(oldKey, newKey interface{}) bool {
+	defer m.endOp("rekeyoverwrite", oldKey, m.startOp())
+	oldKey, newKey = m.normal(oldKey), m.normal(newKey)
+	checkKey(newKey)
+	if oldKey == nil {
+		return false
+	}
+	m.lock()
+	defer m.mutex.Unlock()
+	v, ok := m.remove(oldKey)
+	if ok {
+		m.put(newKey, v)
+	}
+	return ok
+}
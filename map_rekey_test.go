@@ -0,0 +1,104 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ReKeySuite struct{}
+
+var _ = Suite(&ReKeySuite{})
+
+func (s *ReKeySuite) TestReKey(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKey(3, 30), IsNil)
+	_, ok := m.Get(3)
+	c.Assert(ok, Equals, false)
+	x, ok := m.Get(30)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 6)
+	c.Assert(m.Len(), Equals, 10)
+	c.Assert(m.Validate(), IsNil)
+	k, _, _ := m.GetByRank(9)
+	c.Assert(k, Equals, 30)
+}
+
+func (s *ReKeySuite) TestReKeyMissing(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKey(11, 12), Equals, ErrKeyNotFound)
+	c.Assert(m.Len(), Equals, 10)
+	_, ok := m.Get(12)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *ReKeySuite) TestReKeyCollision(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKey(3, 4), Equals, ErrKeyExists)
+	x, _ := m.Get(3)
+	c.Assert(x, Equals, 6)
+	x, _ = m.Get(4)
+	c.Assert(x, Equals, 8)
+	c.Assert(m.Len(), Equals, 10)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ReKeySuite) TestReKeySamePosition(c *C) {
+	m := NewMap(compareInts)
+	m.Put(10, "a")
+	m.Put(20, "b")
+	m.Put(30, "c")
+	c.Assert(m.ReKey(20, 25), IsNil)
+	c.Assert(m.Validate(), IsNil)
+	k, v, _ := m.GetByRank(1)
+	c.Assert(k, Equals, 25)
+	c.Assert(v, Equals, "b")
+	c.Assert(m.ReKey(25, 25), IsNil)
+	x, _ := m.Get(25)
+	c.Assert(x, Equals, "b")
+	c.Assert(m.Len(), Equals, 3)
+}
+
+func (s *ReKeySuite) TestReKeyMultiMap(c *C) {
+	m := NewMultiMap(compareInts)
+	m.Put(1, "a")
+	m.Put(1, "b")
+	m.Put(2, "c")
+	c.Assert(m.ReKey(1, 2), IsNil)
+	c.Assert(values(m, 1), DeepEquals, []interface{}{"b"})
+	c.Assert(values(m, 2), DeepEquals, []interface{}{"c", "a"})
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ReKeySuite) TestReKeyOverwriteToNew(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKeyOverwrite(3, 30), Equals, true)
+	_, ok := m.Get(3)
+	c.Assert(ok, Equals, false)
+	v, ok := m.Get(30)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 6)
+	c.Assert(m.Len(), Equals, 10)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ReKeySuite) TestReKeyOverwriteOverExisting(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKeyOverwrite(3, 7), Equals, true)
+	v, _ := m.Get(7)
+	c.Assert(v, Equals, 6)
+	c.Assert(m.Len(), Equals, 9)
+	c.Assert(m.ReKeyOverwrite(7, 7), Equals, true)
+	v, _ = m.Get(7)
+	c.Assert(v, Equals, 6)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ReKeySuite) TestReKeyOverwriteMissing(c *C) {
+	m := fillMap(10)
+	c.Assert(m.ReKeyOverwrite(30, 3), Equals, false)
+	c.Assert(m.ReKeyOverwrite(nil, 3), Equals, false)
+	v, _ := m.Get(3)
+	c.Assert(v, Equals, 6)
+	c.Assert(m.Len(), Equals, 10)
+	c.Assert(func() { m.ReKeyOverwrite(3, nil) }, PanicMatches, "skiplist: nil key")
+	c.Assert(m.Len(), Equals, 10)
+}
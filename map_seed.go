@@ -0,0 +1,83 @@
+package skiplist
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// splitMix is a math/rand/v2 source for choosing levels. each value is
+// the SplitMix64 mix of an atomic counter, so it needs no lock: writers
+// drawing levels at the same time each get their own step of the
+// sequence, and never wait on each other to get it
+type splitMix struct {
+	state atomic.Uint64
+}
+
+func newSplitMix(seed uint64) *splitMix {
+	s := &splitMix{}
+	s.state.Store(seed)
+	return s
+}
+
+// Uint64 returns the next value in the sequence
+func (s *splitMix) Uint64() uint64 {
+	z := s.state.Add(0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// WithSeed seeds the source the map chooses levels from, so maps
+// built with the same seed and the same sequence of changes have the
+// same shape. every map gets its own source, seeded the same by default
+func WithSeed(seed uint64) Option {
+	return func(m *Map) {
+		m.rnd = newLevelDraw(seed)
+	}
+}
+
+// defaultSeed seeds the level source of a list not given WithSeed
+const defaultSeed = 123123
+
+// levelDraw draws the heights of new elements. Map, WeightedMap and
+// IntervalMap each have their own, so no two lists share a sequence
+type levelDraw struct {
+	src *splitMix
+	r   *rand.Rand
+}
+
+func newLevelDraw(seed uint64) levelDraw {
+	src := newSplitMix(seed)
+	return levelDraw{src, rand.New(src)}
+}
+
+// draw returns a height for a new element, 1 half the time, 2 a
+// quarter of the time and so on, but at most max
+func (ls levelDraw) draw(max int) int {
+	level := int(math.Log(1.0-ls.r.Float64()) / math.Log(1.0-0.5))
+	if level >= max {
+		level = max
+	}
+	if level == 0 {
+		level++
+	}
+	return level
+}
+
+// Reseed starts the source the map chooses levels from over from seed,
+// as if the map had been made WithSeed(uint64(seed)), say every so
+// often so an adversary who has worked out the sequence can't keep
+// using it. it takes an int64 like rand.Seed, so a seed from
+// time.Now().UnixNano() can be passed as it is. the elements already in
+// the map keep their levels, only those put later draw from the new
+// sequence. it changes no pairs, so a frozen map can be reseeded too.
+// it takes the write lock, and moves the source on atomically so bulk
+// loads drawing levels without the lock see either the old sequence or
+// the new one
+func (m *Map) Reseed(seed int64) {
+	m.checkMade()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.rnd.src.state.Store(uint64(seed))
+}
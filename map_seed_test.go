@@ -0,0 +1,97 @@
+package skiplist
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type SeedSuite struct{}
+
+var _ = Suite(&SeedSuite{})
+
+// shape returns the number of levels of each element in order
+func shape(m *Map) []int {
+	ret := []int{}
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		ret = append(ret, len(e.next))
+	}
+	return ret
+}
+
+func (s *SeedSuite) TestWithSeed(c *C) {
+	a := NewMap(compareInts, WithSeed(99))
+	b := NewMap(compareInts, WithSeed(99))
+	d := NewMap(compareInts, WithSeed(100))
+	for i := 0; i < 200; i++ {
+		a.Put(i, i)
+		b.Put(i, i)
+		d.Put(i, i)
+	}
+	c.Assert(shape(a), DeepEquals, shape(b))
+	c.Assert(shape(a), Not(DeepEquals), shape(d))
+	c.Assert(d.Validate(), IsNil)
+}
+
+func (s *SeedSuite) TestConcurrentPuts(c *C) {
+	m := NewMap(compareInts, WithSeed(1))
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				m.Put(g*1000+i, i)
+				// levels are drawn without the lock too, run
+				// with -race to check the source is safe for it
+				randomLevels(m)
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Assert(m.Len(), Equals, 4000)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *SeedSuite) TestReseed(c *C) {
+	m := NewMap(compareInts, WithSeed(99))
+	for i := 0; i < 200; i++ {
+		m.Put(i, i)
+	}
+	before := shape(m)
+	m.Reseed(7)
+	for i := 200; i < 400; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 400)
+	for i := 0; i < 400; i++ {
+		v, ok := m.Get(i)
+		c.Assert(ok, Equals, true)
+		c.Assert(v, Equals, i)
+	}
+	after := shape(m)
+	// the elements already there keep their levels
+	c.Assert(after[:200], DeepEquals, before)
+	// the new ones follow the new seed, not the old sequence
+	fresh := NewMap(compareInts, WithSeed(7))
+	old := NewMap(compareInts, WithSeed(99))
+	for i := 0; i < 400; i++ {
+		old.Put(i, i)
+		if i >= 200 {
+			fresh.Put(i, i)
+		}
+	}
+	c.Assert(after[200:], DeepEquals, shape(fresh))
+	c.Assert(after[200:], Not(DeepEquals), shape(old)[200:])
+	c.Assert(shape(old)[:200], DeepEquals, before)
+}
+
+func (s *SeedSuite) TestReseedFrozen(c *C) {
+	m := NewMap(compareInts, WithSeed(1))
+	m.Put(1, 1)
+	m.Freeze()
+	m.Reseed(-5)
+	c.Assert(m.rnd.src.state.Load(), Equals, uint64(1<<64-5))
+	c.Assert(m.Len(), Equals, 1)
+}
@@ -0,0 +1,87 @@
+package skiplist
+
+// ShrinkLevels lowers the most levels an element can have to what the
+// map needs at its current length, as NewMapWithCapacity would choose,
+// and relinks every pair with a newly drawn height under that ceiling,
+// trimming the head to match. it is for a map that once held far more
+// pairs than it does now, and takes the write lock for an O(n) pass.
+// if the map grows again it gets its levels back as it needs them, up
+// to as many as it was made with, see regrow
+func (m *Map) ShrinkLevels() {
+	defer m.endOp("shrinklevels", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	m.purge()
+	levels := levelsFor(m.length)
+	if levels > m.maxLevels {
+		levels = m.maxLevels
+	}
+	m.maxLevels = levels
+	c := newChain(levels)
+	for e := m.head.next[0]; e != nil; {
+		next := e.next[0]
+		height := randomLevels(m)
+		if cap(e.next) < height {
+			e.next = make([]*mapElement, height)
+			e.span = make([]int, height)
+		}
+		e.next, e.span = e.next[:height], e.span[:height]
+		for level := range e.next {
+			e.next[level], e.span[level] = nil, 0
+		}
+		c.push(e)
+		e = next
+	}
+	m.head.next, m.head.span = c.head, c.headSpan
+}
+
+// ShrinkToFit lowers the most levels an element can have to what the
+// map needs at its current length, as ShrinkLevels does, but keeps the
+// heights elements already have, cutting down only the towers taller
+// than the new ceiling and trimming the head to match. those towers are
+// just the ones linked at the first level cut, so it walks far fewer
+// than n elements, but a map left with few tall towers searches slower
+// than ShrinkLevels would leave it. it takes the write lock and is never
+// done on its own, so the caller picks when to pay for it. levels come
+// back as the map grows, as they do after ShrinkLevels
+func (m *Map) ShrinkToFit() {
+	defer m.endOp("shrinktofit", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	m.purge()
+	levels := levelsFor(m.length)
+	if levels >= m.maxLevels {
+		return
+	}
+	// the head is cut down with the towers
+	for e := m.head; e != nil; {
+		next := e.next[levels]
+		cut(e, levels)
+		e = next
+	}
+	m.maxLevels = levels
+}
+
+// regrow gives a map that was shrunk the levels n pairs need, as
+// levelsFor reckons it, when it has fewer, up to the levels it was made
+// with. the new levels start empty and fill as pairs are put, so
+// searches get back to O(log n) as it grows. the caller must hold the
+// write lock
+func (m *Map) regrow(n int) {
+	if m.maxLevels >= m.ceiling {
+		return
+	}
+	for levels := min(levelsFor(n), m.ceiling); m.maxLevels < levels; m.maxLevels++ {
+		m.head.next = append(m.head.next, nil)
+		m.head.span = append(m.head.span, 0)
+	}
+}
+
+// cut lowers e to levels levels, clearing the links it drops so they
+// don't keep other elements alive
+func cut(e *mapElement, levels int) {
+	for level := levels; level < len(e.next); level++ {
+		e.next[level], e.span[level] = nil, 0
+	}
+	e.next, e.span = e.next[:levels], e.span[:levels]
+}
@@ -0,0 +1,52 @@
+package skiplist
+
+import (
+	"time"
+)
+
+// slowHook is the hook set by WithSlowOpHook
+type slowHook struct {
+	threshold time.Duration
+	fn        func(op string, key interface{}, d time.Duration)
+}
+
+// WithSlowOpHook times each public operation that reads or changes
+// the pairs of the map, calling fn with the operation, its key and how
+// long it took whenever that is longer than threshold. the operation
+// is the method's name in lower case, "put", "floor", "popminn" and so
+// on, and the key is the one it was called with, the start of the range
+// for a range, or nil for an operation that doesn't take one. the time
+// includes waiting for the lock. fn is called after the lock is let go,
+// so it may use the map. a method that only fills in another's
+// arguments, like PopMin for PopMinN, or is made of others, like
+// GetOrCompute of Get and Put, is reported as those. methods that set
+// up or inspect the map, such as Stats, Validate and Watch, aren't
+// timed, nor are Transaction, WithLock and WithRLock, whose time is
+// spent in the caller's own code
+func WithSlowOpHook(threshold time.Duration, fn func(op string, key interface{}, d time.Duration)) Option {
+	return func(m *Map) {
+		m.slow = &slowHook{threshold, fn}
+	}
+}
+
+// startOp returns the time an operation starts if there is a slow
+// operation hook, and the zero time otherwise
+func (m *Map) startOp() time.Time {
+	if m.slow == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// endOp calls the slow operation hook if the operation that began
+// at start took too long. the caller must not hold the lock, an
+// operation that takes it defers endOp before locking so it runs
+// once the lock is let go
+func (m *Map) endOp(op string, k interface{}, start time.Time) {
+	if m.slow == nil {
+		return
+	}
+	if d := time.Since(start); d > m.slow.threshold {
+		m.slow.fn(op, k, d)
+	}
+}
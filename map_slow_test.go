@@ -0,0 +1,74 @@
+package skiplist
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SlowSuite struct{}
+
+var _ = Suite(&SlowSuite{})
+
+type slowOp struct {
+	op  string
+	key interface{}
+}
+
+func (s *SlowSuite) TestSlowOpHook(c *C) {
+	var slow []slowOp
+	// comparisons against key 13 take a while
+	less := func(a, b interface{}) bool {
+		if a == 13 || b == 13 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return a.(int) < b.(int)
+	}
+	var m *Map
+	m = NewMap(less, WithSlowOpHook(4*time.Millisecond, func(op string, k interface{}, d time.Duration) {
+		c.Assert(d > 4*time.Millisecond, Equals, true)
+		// the lock is free again
+		m.Len()
+		slow = append(slow, slowOp{op, k})
+	}))
+	m.Put(1, 1)
+	m.Get(1)
+	m.Remove(1)
+	m.Put(12, 12)
+	c.Assert(slow, HasLen, 0)
+	m.Put(13, 13)
+	m.Get(13)
+	m.Remove(13)
+	c.Assert(slow, DeepEquals, []slowOp{{"put", 13}, {"get", 13}, {"remove", 13}})
+}
+
+func (s *SlowSuite) TestNoHook(c *C) {
+	m := NewMap(compareInts)
+	c.Assert(m.startOp().IsZero(), Equals, true)
+	m.Put(1, 1)
+	m.Remove(1)
+}
+
+func (s *SlowSuite) TestEveryOperation(c *C) {
+	var ops []string
+	slowLess := func(a, b interface{}) bool {
+		time.Sleep(time.Millisecond)
+		return a.(int) < b.(int)
+	}
+	m := NewMap(slowLess, WithSlowOpHook(time.Nanosecond, func(op string, k interface{}, d time.Duration) {
+		ops = append(ops, op)
+	}))
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+	}
+	ops = nil
+	m.Floor(2)
+	m.Range(1, 3, func(k, v interface{}) bool { return true })
+	m.ForEach(func(k, v interface{}) {})
+	m.PutOrMerge(2, 1, func(a, b interface{}) interface{} { return a.(int) + b.(int) })
+	m.BulkUpdateRange(0, 2, func(k, v interface{}) (interface{}, bool) { return v, true })
+	m.PopMin()
+	m.Entries()
+	m.GetOrCompute(7, func() (interface{}, error) { return 7, nil })
+	c.Assert(ops, DeepEquals, []string{"floor", "range", "range", "putormerge", "bulkupdaterange", "popminn", "entries", "get", "get", "put"})
+}
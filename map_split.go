@@ -0,0 +1,233 @@
+package skiplist
+
+// emptyLike creates a new empty map ordered like m, with the same
+// mode, levels and options. what m keeps about its own pairs starts
+// over empty: a fresh index, a cost total and fingerprint of 0, and a
+// journal of the same size with no changes in it. its write-ahead log,
+// persister, watchers and OnChange callbacks stay with m, and the new
+// map isn't frozen. the caller must hold the lock
+func (m *Map) emptyLike() *Map {
+	ret := NewMap(m.comp)
+	ret.maxLevels, ret.ceiling = m.maxLevels, m.ceiling
+	ret.head = newHead(m.maxLevels)
+	ret.multi = m.multi
+	ret.tieBreak = m.tieBreak
+	ret.slow = m.slow
+	ret.normalize = m.normalize
+	ret.compare = m.compare
+	ret.tombstone = m.tombstone
+	ret.history = m.history
+	ret.checkComp = m.checkComp
+	if m.arena != nil {
+		ret.arena = newArena(m.arena.chunk)
+	}
+	if m.index != nil {
+		ret.index = &hashIndex{m.index.hash, m.index.eq, map[uint64][]*mapElement{}}
+	}
+	if m.cost != nil {
+		c := *m.cost
+		c.total = 0
+		ret.cost = &c
+	}
+	if m.digest != nil {
+		ret.digest = &fingerprint{hashKV: m.digest.hashKV}
+	}
+	if m.journal != nil {
+		ret.journal = &journal{buf: make([]Change, len(m.journal.buf))}
+	}
+	return ret
+}
+
+// sumFrom returns the cost and fingerprint of the pairs from e to the
+// end of its list, by m's cost function and hash, for whichever of
+// them m keeps
+func (m *Map) sumFrom(e *mapElement) (cost int64, sum uint64) {
+	for ; e != nil; e = e.next[0] {
+		if m.cost != nil {
+			cost += m.cost.fn(e.key, e.val)
+		}
+		if m.digest != nil {
+			sum ^= m.digest.pair(e.key, e.val)
+		}
+	}
+	return cost, sum
+}
+
+// totals returns the cost total and fingerprint m keeps, 0 for either
+// it doesn't
+func (m *Map) totals() (cost int64, sum uint64) {
+	if m.cost != nil {
+		cost = m.cost.total
+	}
+	if m.digest != nil {
+		sum = m.digest.sum
+	}
+	return cost, sum
+}
+
+// addTotals adds to the cost total and fingerprint m keeps, for pairs
+// linked into it without a change being made
+func (m *Map) addTotals(cost int64, sum uint64) {
+	if m.cost != nil {
+		m.cost.total += cost
+	}
+	if m.digest != nil {
+		m.digest.sum ^= sum
+	}
+}
+
+// Split moves the pairs of the map into two new maps ordered the same
+// way, left getting the keys less than k and right the rest, leaving
+// the original empty. the levels are cut at k rather than the pairs
+// being put again, so it only walks the elements to rebuild a hash index
+// or tell watchers of the original that the pairs have gone, and the
+// smaller half to share out a cost total or fingerprint. both halves
+// take the options of the original, as emptyLike describes, their
+// journals starting over empty
+func (m *Map) Split(k interface{}) (left, right *Map) {
+	defer m.endOp("split", k, m.startOp())
+	k = m.normal(k)
+	m.lock()
+	defer m.mutex.Unlock()
+	m.purge()
+	left, right = m.emptyLike(), m.emptyLike()
+	// find the last element before k at each level and its rank,
+	// the elements after it at each level go right
+	pos := 0
+	rightRank := make([]int, m.maxLevels)
+	backPointer := make([]*mapElement, m.maxLevels)
+	prev := m.head
+	for level := m.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && m.comp(next.key, k); next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+		backPointer[level] = prev
+		rightRank[level] = pos + prev.span[level]
+	}
+	// the levels are only cut once the search is done, so a panic
+	// in the comparator leaves the map as it was
+	for level, prev := range backPointer {
+		right.head.next[level] = prev.next[level]
+		if prev != m.head {
+			left.head.next[level] = m.head.next[level]
+			left.head.span[level] = m.head.span[level]
+			prev.next[level] = nil
+		}
+	}
+	// pos is now the number of elements going left
+	for level := 0; level < m.maxLevels; level++ {
+		if right.head.next[level] != nil {
+			right.head.span[level] = rightRank[level] - pos
+		}
+	}
+	left.length = pos
+	right.length = m.length - pos
+	if m.cost != nil || m.digest != nil {
+		// total the smaller half, the other has the rest
+		small, large := left, right
+		if left.length > right.length {
+			small, large = right, left
+		}
+		cost, sum := m.sumFrom(small.head.next[0])
+		small.addTotals(cost, sum)
+		large.addTotals(-cost, sum)
+		large.addTotals(m.totals())
+	}
+	if m.watched() || m.index != nil {
+		for _, half := range []*Map{left, right} {
+			for e := half.head.next[0]; e != nil; e = e.next[0] {
+				m.notify(OpDelete, e.key, e.val, nil)
+				if half.index != nil {
+					half.index.add(e)
+				}
+			}
+		}
+	}
+	m.reset()
+	return left, right
+}
+
+// Concat joins two maps ordered the same way into a new one, when
+// every key in left is less than every key in right, leaving both of
+// them empty. it links the end of each level of left to right rather
+// than putting the pairs again, walking the elements only to rebuild
+// a hash index or tell watchers of left and right that the pairs have
+// gone, and right's to add them to a cost total or fingerprint. the new
+// map takes the options of left, as emptyLike describes, and as many
+// levels as the taller of the two. it panics if the maps overlap or
+// either is frozen
+func Concat(left, right *Map) *Map {
+	defer left.endOp("concat", nil, left.startOp())
+	if left == right {
+		panic("skiplist: Concat of a map with itself")
+	}
+	// the locks are taken in address order, so Concats of the same
+	// maps in opposite orders can't deadlock
+	first, second := inAddressOrder(left, right)
+	first.lock()
+	defer first.mutex.Unlock()
+	second.lock()
+	defer second.mutex.Unlock()
+	left.purge()
+	right.purge()
+	ret := left.emptyLike()
+	if right.maxLevels > ret.maxLevels {
+		ret.maxLevels, ret.ceiling = right.maxLevels, max(ret.ceiling, right.ceiling)
+		ret.head = newHead(ret.maxLevels)
+	}
+	// find the last element at each level of left and its rank
+	// ret may have more levels than left, which end at its head
+	last := make([]*mapElement, ret.maxLevels)
+	lastRank := make([]int, ret.maxLevels)
+	pos := 0
+	prev := left.head
+	for level := left.maxLevels - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil; next = prev.next[level] {
+			pos += prev.span[level]
+			prev = next
+		}
+		last[level] = prev
+		lastRank[level] = pos
+	}
+	if prev != left.head && right.head.next[0] != nil && !left.comp(prev.key, right.head.next[0].key) {
+		panic("skiplist: Concat of overlapping maps")
+	}
+	// links from left's head come from ret's instead
+	for level := range last {
+		if last[level] == nil || last[level] == left.head {
+			last[level] = ret.head
+		}
+	}
+	copy(ret.head.next, left.head.next)
+	copy(ret.head.span, left.head.span)
+	for level := 0; level < right.maxLevels && level < ret.maxLevels; level++ {
+		if right.head.next[level] == nil {
+			continue
+		}
+		last[level].next[level] = right.head.next[level]
+		last[level].span[level] = left.length - lastRank[level] + right.head.span[level]
+	}
+	ret.length = left.length + right.length
+	if ret.cost != nil || ret.digest != nil {
+		ret.addTotals(left.totals())
+		ret.addTotals(ret.sumFrom(right.head.next[0]))
+	}
+	if left.watched() || right.watched() || ret.index != nil {
+		n := 0
+		for e := ret.head.next[0]; e != nil; e = e.next[0] {
+			if n < left.length {
+				left.notify(OpDelete, e.key, e.val, nil)
+			} else {
+				right.notify(OpDelete, e.key, e.val, nil)
+			}
+			if ret.index != nil {
+				ret.index.add(e)
+			}
+			n++
+		}
+	}
+	left.reset()
+	right.reset()
+	return ret
+}
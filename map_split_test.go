@@ -0,0 +1,235 @@
+package skiplist
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SplitSuite struct{}
+
+var _ = Suite(&SplitSuite{})
+
+func (s *SplitSuite) TestSplit(c *C) {
+	for _, at := range []int{-5, 0, 1, 500, 999, 1000, 5000} {
+		m := fillMap(1000)
+		left, right := m.Split(at)
+		c.Assert(m.Len(), Equals, 0)
+		c.Assert(m.Validate(), IsNil)
+		c.Assert(left.Validate(), IsNil)
+		c.Assert(right.Validate(), IsNil)
+		split := at
+		if split < 0 {
+			split = 0
+		}
+		if split > 1000 {
+			split = 1000
+		}
+		ref := sortedEntries(1000)
+		c.Assert(left.Entries(), DeepEquals, ref[:split])
+		c.Assert(right.Entries(), DeepEquals, ref[split:])
+		// both halves keep working
+		left.Put(-1, 0)
+		right.Put(2000, 0)
+		c.Assert(left.Validate(), IsNil)
+		c.Assert(right.Validate(), IsNil)
+		m.Put(1, 1)
+		c.Assert(m.Validate(), IsNil)
+	}
+}
+
+func (s *SplitSuite) TestSplitBetweenKeys(c *C) {
+	m, keys := fillMapRandKeys(2000, 8)
+	left, right := m.Split(10001)
+	c.Assert(left.Validate(), IsNil)
+	c.Assert(right.Validate(), IsNil)
+	c.Assert(left.Len()+right.Len(), Equals, len(keys))
+	for _, k := range left.Keys() {
+		c.Assert(k.(int) < 10001, Equals, true)
+	}
+	for _, k := range right.Keys() {
+		c.Assert(k.(int) >= 10001, Equals, true)
+	}
+	x, _, _ := right.GetByRank(0)
+	c.Assert(x, Equals, keys[left.Len()])
+}
+
+func (s *SplitSuite) TestSplitKeepsMode(c *C) {
+	m := NewMap(compareInts, WithHashIndex(hashInt, equalInts))
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	left, right := m.Split(50)
+	x, ok := left.Get(10)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 10)
+	_, ok = left.Get(60)
+	c.Assert(ok, Equals, false)
+	x, ok = right.Get(60)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, 60)
+	_, ok = m.Get(60)
+	c.Assert(ok, Equals, false)
+
+	multi := NewMultiMap(compareInts)
+	multi.Put(1, "a")
+	multi.Put(1, "b")
+	left, right = multi.Split(1)
+	c.Assert(left.Len(), Equals, 0)
+	right.Put(1, "c")
+	c.Assert(right.Count(1), Equals, 3)
+}
+
+func (s *SplitSuite) TestConcat(c *C) {
+	for _, at := range []int{0, 1, 500, 999} {
+		m := fillMapRand(1000)
+		keys := m.Keys()
+		left, right := m.Split(keys[at])
+		joined := Concat(left, right)
+		c.Assert(joined.Validate(), IsNil)
+		c.Assert(joined.Keys(), DeepEquals, keys)
+		c.Assert(left.Len(), Equals, 0)
+		c.Assert(right.Len(), Equals, 0)
+		c.Assert(left.Validate(), IsNil)
+		c.Assert(right.Validate(), IsNil)
+		joined.Put(-1, 0)
+		c.Assert(joined.Validate(), IsNil)
+		k, _, _ := joined.GetByRank(at + 1)
+		c.Assert(k, Equals, keys[at])
+	}
+}
+
+func (s *SplitSuite) TestConcatSeparateMaps(c *C) {
+	left := fillMap(100)
+	right := NewMap(compareInts)
+	for i := 100; i < 300; i++ {
+		right.Put(i, i*2)
+	}
+	joined := Concat(left, right)
+	c.Assert(joined.Validate(), IsNil)
+	c.Assert(joined.Entries(), DeepEquals, sortedEntries(300))
+	c.Assert(Concat(NewMap(compareInts), joined).Entries(), DeepEquals, sortedEntries(300))
+}
+
+func (s *SplitSuite) TestConcatOverlap(c *C) {
+	left := fillMap(100)
+	right := NewMap(compareInts)
+	right.Put(99, 0)
+	right.Put(200, 0)
+	c.Assert(func() { Concat(left, right) }, PanicMatches, "skiplist: Concat of overlapping maps")
+	// both maps are untouched and unlocked
+	c.Assert(left.Len(), Equals, 100)
+	c.Assert(right.Len(), Equals, 2)
+	right.Remove(99)
+	c.Assert(Concat(left, right).Len(), Equals, 101)
+	c.Assert(func() { Concat(left, left) }, PanicMatches, ".*itself")
+}
+
+func (s *SplitSuite) TestConcatFrozen(c *C) {
+	left := fillMap(10)
+	right := NewMap(compareInts)
+	right.Put(100, 0)
+	right.Freeze()
+	c.Assert(func() { Concat(left, right) }, PanicMatches, ".*frozen.*")
+	c.Assert(func() { Concat(right, left) }, PanicMatches, ".*frozen.*")
+	// neither map is changed or left locked
+	c.Assert(right.Keys(), DeepEquals, []interface{}{100})
+	c.Assert(left.Len(), Equals, 10)
+	left.Put(10, 10)
+}
+
+func (s *SplitSuite) TestConcatOppositeOrders(c *C) {
+	a, b := NewMap(compareInts), NewMap(compareInts)
+	done := make(chan bool)
+	for _, pair := range [][2]*Map{{a, b}, {b, a}} {
+		go func(left, right *Map) {
+			for i := 0; i < 10000; i++ {
+				Concat(left, right)
+			}
+			done <- true
+		}(pair[0], pair[1])
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			c.Fatal("concats deadlocked")
+		}
+	}
+}
+
+func (s *SplitSuite) TestConcatInCallback(c *C) {
+	left := fillMap(10)
+	right := NewMap(compareInts)
+	c.Assert(func() {
+		left.CompactIf(func(v interface{}) bool {
+			Concat(right, left)
+			return false
+		})
+	}, PanicMatches, ".*reentrant.*")
+	c.Assert(right.Len(), Equals, 0)
+	right.Put(100, 0)
+	c.Assert(Concat(left, right).Len(), Equals, 11)
+}
+
+// keptLike returns a map made with the options TestSplitKeepsOptions
+// uses, holding the pairs of m, to check the totals of m against
+func keptLike(m *Map) *Map {
+	ret := NewMap(compareInts, WithCostFunc(func(k, v interface{}) int64 { return int64(v.(int)) }), WithIncrementalFingerprint(hashPair))
+	for _, e := range m.Entries() {
+		ret.Put(e.Key, e.Val)
+	}
+	return ret
+}
+
+func (s *SplitSuite) TestSplitKeepsOptions(c *C) {
+	for _, at := range []int{10, 90} {
+		m := NewMapWithCapacity(compareInts, 100, WithCostFunc(func(k, v interface{}) int64 { return int64(v.(int)) }),
+			WithIncrementalFingerprint(hashPair), WithJournal(8))
+		for i := 0; i < 100; i++ {
+			m.Put(i, i)
+		}
+		left, right := m.Split(at)
+		for _, half := range []*Map{left, right} {
+			c.Assert(half.maxLevels, Equals, 7)
+			c.Assert(half.ceiling, Equals, 7)
+			c.Assert(half.Cost(), Equals, keptLike(half).Cost())
+			c.Assert(half.IncrementalFingerprint(), Equals, keptLike(half).IncrementalFingerprint())
+			c.Assert(half.Validate(), IsNil)
+		}
+		// the journals start over, and go on numbering changes
+		changes, seq, ok := left.ChangesSince(0)
+		c.Assert(ok, Equals, true)
+		c.Assert(changes, HasLen, 0)
+		c.Assert(seq, Equals, uint64(0))
+		right.Put(500, 5)
+		changes, _, _ = right.ChangesSince(0)
+		c.Assert(changes, DeepEquals, []Change{{1, ChangeEvent{OpInsert, 500, nil, 5}}})
+		c.Assert(right.Cost(), Equals, keptLike(right).Cost())
+	}
+}
+
+func (s *SplitSuite) TestConcatKeepsOptions(c *C) {
+	cost := WithCostFunc(func(k, v interface{}) int64 { return int64(v.(int)) })
+	left := NewMapWithCapacity(compareInts, 4, cost, WithIncrementalFingerprint(hashPair))
+	// right is much taller than left, and keeps no totals of its own
+	right := NewMap(compareInts)
+	for i := 0; i < 4; i++ {
+		left.Put(i, i)
+	}
+	for i := 10; i < 2000; i++ {
+		right.Put(i, i)
+	}
+	tall := right.Height()
+	c.Assert(tall > left.maxLevels, Equals, true)
+	ret := Concat(left, right)
+	c.Assert(ret.Validate(), IsNil)
+	c.Assert(ret.Height(), Equals, tall)
+	c.Assert(ret.Len(), Equals, 1994)
+	c.Assert(ret.Cost(), Equals, keptLike(ret).Cost())
+	c.Assert(ret.IncrementalFingerprint(), Equals, keptLike(ret).IncrementalFingerprint())
+	v, ok := ret.Get(1999)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 1999)
+	c.Assert(ret.Rank(1999), Equals, 1993)
+}
@@ -23,17 +23,19 @@ type Int64Int64Record struct {
 }
 
 func (m *Map) Persist(f io.Writer, rp RecordPersister) error {
+	defer m.endOp("persist", nil, m.startOp())
 	rp.Persist(m, f)
 	return nil
 }
 
 func (m *Map) Merge(f io.Reader, rp RecordPersister) error {
+	defer m.endOp("merge", nil, m.startOp())
 	rp.Merge(m, f)
 	return nil
 }
 
 func (ss StringStringRecord) Persist(m *Map, f io.Writer) error {
-	e := m.head[0]
+	e := liveFrom(m.head.next[0])
 	buf := bufio.NewWriter(f)
 	defer buf.Flush()
 	for e != nil {
@@ -59,7 +61,7 @@ func (ss StringStringRecord) Persist(m *Map, f io.Writer) error {
 		if err != nil {
 			log.Println("binary.Write failed:", err)
 		}
-		e = e.next[0]
+		e = liveFrom(e.next[0])
 	}
 	return nil
 }
@@ -86,7 +88,7 @@ func (ss StringStringRecord) Merge(m *Map, f io.Reader) error {
 }
 
 func (ss Int64Int64Record) Persist(m *Map, f io.Writer) error {
-	e := m.head[0]
+	e := liveFrom(m.head.next[0])
 	buf := bufio.NewWriter(f)
 	defer buf.Flush()
 	for e != nil {
@@ -96,7 +98,7 @@ func (ss Int64Int64Record) Persist(m *Map, f io.Writer) error {
 		if err != nil {
 			return err
 		}
-		e = e.next[0]
+		e = liveFrom(e.next[0])
 	}
 	return nil
 }
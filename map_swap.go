@@ -0,0 +1,27 @@
+package skiplist
+
+// SwapValues exchanges the values stored under k1 and k2 under the
+// write lock, so readers see either both old values or both new ones.
+// it returns ErrKeyNotFound, changing nothing, if either key is missing
+func (m *Map) SwapValues(k1, k2 interface{}) error {
+	defer m.endOp("swapvalues", k1, m.startOp())
+	k1, k2 = m.normal(k1), m.normal(k2)
+	m.lock()
+	defer m.mutex.Unlock()
+	e1 := m.find(k1, nil)
+	if e1 == nil {
+		return ErrKeyNotFound
+	}
+	e2 := m.find(k2, nil)
+	if e2 == nil {
+		return ErrKeyNotFound
+	}
+	if e1 != e2 {
+		e1.val, e2.val = e2.val, e1.val
+		m.changed(e1)
+		m.changed(e2)
+		m.notify(OpUpdate, e1.key, e2.val, e1.val)
+		m.notify(OpUpdate, e2.key, e1.val, e2.val)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package skiplist
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type SwapSuite struct{}
+
+var _ = Suite(&SwapSuite{})
+
+func (s *SwapSuite) TestSwapValues(c *C) {
+	m := fillMap(10)
+	c.Assert(m.SwapValues(2, 7), IsNil)
+	x, _ := m.Get(2)
+	c.Assert(x, Equals, 14)
+	x, _ = m.Get(7)
+	c.Assert(x, Equals, 4)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *SwapSuite) TestSwapValuesSameKey(c *C) {
+	m := fillMap(10)
+	c.Assert(m.SwapValues(3, 3), IsNil)
+	x, _ := m.Get(3)
+	c.Assert(x, Equals, 6)
+}
+
+func (s *SwapSuite) TestSwapValuesMissing(c *C) {
+	m := fillMap(10)
+	c.Assert(m.SwapValues(3, 30), Equals, ErrKeyNotFound)
+	c.Assert(m.SwapValues(30, 3), Equals, ErrKeyNotFound)
+	x, _ := m.Get(3)
+	c.Assert(x, Equals, 6)
+	c.Assert(m.FirstN(10), DeepEquals, sortedEntries(10))
+}
+
+// FirstN reads both values under one read lock, so it must only
+// ever see the two values in their before or after positions
+func (s *SwapSuite) TestSwapValuesConcurrentReader(c *C) {
+	m := NewMap(compareInts)
+	m.Put(1, "a")
+	m.Put(2, "b")
+	var w sync.WaitGroup
+	done := make(chan bool)
+	w.Add(1)
+	go func() {
+		for i := 0; i < 10000; i++ {
+			m.SwapValues(1, 2)
+		}
+		close(done)
+		w.Done()
+	}()
+	bad := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		got := m.FirstN(2)
+		if got[0].Val == got[1].Val {
+			bad++
+		}
+	}
+	w.Wait()
+	c.Assert(bad, Equals, 0)
+	x, _ := m.Get(1)
+	c.Assert(x, Equals, "a")
+}
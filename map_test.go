@@ -92,3 +92,41 @@ func (s *MapSuite) TestRandSingleThread(c *C) {
 	}
 	c.Assert(m.Len(), Equals, len(cm))
 }
+
+func (s *MapSuite) TestNilValue(c *C) {
+	m := NewMap(compareInts)
+	c.Assert(m.Put(1, nil), Equals, false)
+	i, ok := m.Get(1)
+	c.Assert(i, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(m.Put(1, nil), Equals, true)
+	c.Assert(m.Len(), Equals, 1)
+	c.Assert(m.Remove(1), Equals, true)
+	_, ok = m.Get(1)
+	c.Assert(ok, Equals, false)
+}
+
+func (s *MapSuite) TestNilKey(c *C) {
+	m := NewMap(compareInts)
+	m.Put(1, 1)
+	c.Assert(func() { m.Put(nil, 1) }, PanicMatches, "skiplist: nil key")
+	// the comparator is never called with nil and the map stays usable
+	i, ok := m.Get(nil)
+	c.Assert(i, IsNil)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.Remove(nil), Equals, false)
+	c.Assert(m.ReKey(nil, 2), Equals, ErrKeyNotFound)
+	c.Assert(func() { m.ReKey(1, nil) }, PanicMatches, "skiplist: nil key")
+	m.Put(2, 2)
+	c.Assert(m.Len(), Equals, 2)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *MapSuite) TestKeys(c *C) {
+	c.Assert(NewMap(compareInts).Keys(), HasLen, 0)
+	m := NewMap(compareInts)
+	m.Put(3, 0)
+	m.Put(1, 0)
+	m.Put(2, 0)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{1, 2, 3})
+}
@@ -0,0 +1,101 @@
+package skiplist
+
+// WithTombstones makes Remove mark the pair's element as deleted
+// rather than unlinking it, which is cheaper for maps that see many
+// deletes. searches and iteration step over the tombstones, Len and
+// the methods that work by position, GetByRank, Rank, SelectRange and
+// the like, count only live pairs, and a Put of a tombstoned key brings
+// its element back. pops and removals by position unlink the
+// tombstones in their way, and Purge unlinks every tombstone in one
+// pass, and should be called every so often: until then tombstones
+// take memory and time to step over. it has no effect on a multimap
+func WithTombstones() Option {
+	return func(m *Map) {
+		m.tombstone = true
+	}
+}
+
+// bury marks e as deleted, reporting it removed as unlinking
+// would. a tombstone takes no room in the spans, so the links over it
+// and into it get a step shorter, as they would if it were unlinked.
+// backPointer holds the element just before e at every level. the
+// caller must hold the write lock
+func (m *Map) bury(e *mapElement, backPointer []*mapElement) {
+	for level := 0; level < m.maxLevels; level++ {
+		if prev := backPointer[level]; prev.next[level] != nil {
+			prev.span[level]--
+		}
+	}
+	m.length--
+	e.deleted = true
+	e.past = nil
+	m.removed(e.key, e.val)
+}
+
+// buried returns the tombstone for k after a find for it came up
+// empty, or nil if there is none. backPointer is as find left it
+func (m *Map) buried(k interface{}, backPointer []*mapElement) *mapElement {
+	if !m.tombstone {
+		return nil
+	}
+	e := backPointer[0].next[0]
+	if e == nil || !e.deleted || m.comp(k, e.key) {
+		return nil
+	}
+	return e
+}
+
+// revive brings a tombstone back to life holding k and v, as if it
+// had been put, undoing what bury did to the spans. the caller must
+// hold the write lock
+func (m *Map) revive(e *mapElement, v interface{}, backPointer []*mapElement) {
+	for level := 0; level < m.maxLevels; level++ {
+		if prev := backPointer[level]; prev.next[level] != nil {
+			prev.span[level]++
+		}
+	}
+	m.length++
+	e.val = v
+	e.deleted = false
+	e.version++
+	m.added(e)
+}
+
+// liveFrom returns the first element from e on that isn't a tombstone
+func liveFrom(e *mapElement) *mapElement {
+	for e != nil && e.deleted {
+		e = e.next[0]
+	}
+	return e
+}
+
+// liveBefore returns the last element up to and including e that
+// isn't a tombstone, or nil if there is none, as there isn't when e is
+// the head. for a tombstone it is found by rank, since only live
+// pairs are counted
+func (m *Map) liveBefore(e *mapElement) *mapElement {
+	if e == m.head {
+		return nil
+	}
+	if e == nil || !e.deleted {
+		return e
+	}
+	return m.elementAt(m.countLess(e.key)-1, nil)
+}
+
+// Purge unlinks every tombstone left by Remove in a map made
+// WithTombstones, and returns how many it unlinked
+func (m *Map) Purge() int {
+	defer m.endOp("purge", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	return m.purge()
+}
+
+// purge does the work of Purge, the caller must hold the write lock
+func (m *Map) purge() int {
+	if !m.tombstone {
+		return 0
+	}
+	return m.removeIf(func(e *mapElement) bool { return e.deleted })
+}
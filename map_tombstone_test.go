@@ -0,0 +1,292 @@
+package skiplist
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type TombstoneSuite struct{}
+
+var _ = Suite(&TombstoneSuite{})
+
+func (s *TombstoneSuite) TestRemoveMarks(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 10; i++ {
+		m.Put(i, i*10)
+	}
+	c.Assert(m.Remove(3), Equals, true)
+	c.Assert(m.Remove(3), Equals, false)
+	c.Assert(m.Remove(0), Equals, true)
+	c.Assert(m.Remove(9), Equals, true)
+	// still linked, but nothing finds them
+	c.Assert(m.NodeCount(), Equals, 10)
+	c.Assert(m.Len(), Equals, 7)
+	_, ok := m.Get(3)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{1, 2, 4, 5, 6, 7, 8})
+	k, _, _ := m.First()
+	c.Assert(k, Equals, 1)
+	k, _, _ = m.Last()
+	c.Assert(k, Equals, 8)
+	k, _, _ = m.Floor(3)
+	c.Assert(k, Equals, 2)
+	k, _, _ = m.Ceiling(3)
+	c.Assert(k, Equals, 4)
+	_, _, ok = m.Ceiling(9)
+	c.Assert(ok, Equals, false)
+	lower, exact, higher := m.Neighbors(3)
+	c.Assert(*lower, Equals, Pair{2, 20})
+	c.Assert(exact, IsNil)
+	c.Assert(*higher, Equals, Pair{4, 40})
+	c.Assert(m.After(2, 2), DeepEquals, []Entry{{4, 40}, {5, 50}})
+	c.Assert(m.Before(5, 3), DeepEquals, []Entry{{1, 10}, {2, 20}, {4, 40}})
+	c.Assert(m.RangeSnapshot(2, 4), DeepEquals, []Pair{{2, 20}, {4, 40}})
+	k, _, _ = m.GetByRank(0)
+	c.Assert(k, Equals, 1)
+	it := m.Iterator()
+	n := 0
+	for it.Next() {
+		c.Assert(it.Key(), Not(Equals), 3)
+		n++
+	}
+	it.Release()
+	c.Assert(n, Equals, 7)
+
+	// a put brings the element back
+	c.Assert(m.Put(3, "back"), Equals, false)
+	c.Assert(m.NodeCount(), Equals, 10)
+	v, _ := m.Get(3)
+	c.Assert(v, Equals, "back")
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TombstoneSuite) TestPurge(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 100; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 100; i += 3 {
+		m.Remove(i)
+	}
+	c.Assert(m.NodeCount(), Equals, 100)
+	c.Assert(m.Purge(), Equals, 34)
+	c.Assert(m.NodeCount(), Equals, 66)
+	c.Assert(m.Len(), Equals, 66)
+	c.Assert(m.Purge(), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+	k, _, _ := m.GetByRank(0)
+	c.Assert(k, Equals, 1)
+	c.Assert(NewMap(compareInts).Purge(), Equals, 0)
+}
+
+func (s *TombstoneSuite) TestAgainstPlainMap(c *C) {
+	r := rand.New(rand.NewSource(11))
+	m := NewMap(compareInts, WithTombstones())
+	plain := NewMap(compareInts)
+	events, cancel := m.Watch(10000)
+	defer cancel()
+	for round := 0; round < 5000; round++ {
+		k := r.Intn(200)
+		switch r.Intn(20) {
+		case 0:
+			m.Purge()
+		case 1:
+			m.PopMin()
+			plain.PopMin()
+		case 2, 3, 4, 5, 6, 7:
+			c.Assert(m.Remove(k), Equals, plain.Remove(k))
+		default:
+			c.Assert(m.Put(k, round), Equals, plain.Put(k, round))
+		}
+		if round%100 == 0 {
+			c.Assert(m.Entries(), DeepEquals, plain.Entries())
+			c.Assert(m.Len(), Equals, plain.Len())
+			c.Assert(m.Validate(), IsNil)
+			sameRanks(c, m, plain)
+			for len(events) > 0 {
+				<-events
+			}
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+// sameRanks checks that every method working by position gives the
+// same answers for m as for plain, which holds the same pairs
+func sameRanks(c *C, m, plain *Map) {
+	n := plain.Len()
+	m.Transaction(func(tx *Txn) {
+		c.Assert(tx.Len(), Equals, n)
+	})
+	for i := -1; i <= n; i++ {
+		k, v, ok := m.GetByRank(i)
+		pk, pv, pok := plain.GetByRank(i)
+		c.Assert([]interface{}{k, v, ok}, DeepEquals, []interface{}{pk, pv, pok}, Commentf("rank %d", i))
+	}
+	for k := -1; k <= 201; k += 7 {
+		c.Assert(m.Rank(k), Equals, plain.Rank(k))
+		c.Assert(m.Before(k, 3), DeepEquals, plain.Before(k, 3))
+	}
+	for _, q := range []float64{0, 0.25, 0.5, 1} {
+		k, _, ok := m.Quantile(q)
+		pk, _, pok := plain.Quantile(q)
+		c.Assert([]interface{}{k, ok}, DeepEquals, []interface{}{pk, pok})
+	}
+	keys, vals := m.SelectRange(2, 9)
+	pkeys, pvals := plain.SelectRange(2, 9)
+	c.Assert(keys, DeepEquals, pkeys)
+	c.Assert(vals, DeepEquals, pvals)
+	keys, _ = m.MaxN(4)
+	pkeys, _ = plain.MaxN(4)
+	c.Assert(keys, DeepEquals, pkeys)
+	keys, _ = m.SelectMany([]int{0, 3, 4, n - 1, n})
+	pkeys, _ = plain.SelectMany([]int{0, 3, 4, n - 1, n})
+	c.Assert(keys, DeepEquals, pkeys)
+	c.Assert(m.GetByRankRange(1, -2), DeepEquals, plain.GetByRankRange(1, -2))
+	c.Assert(m.LastN(3), DeepEquals, plain.LastN(3))
+	k, _, _ := m.Last()
+	pk, _, _ := plain.Last()
+	c.Assert(k, Equals, pk)
+}
+
+func (s *TombstoneSuite) TestRanksCountLivePairs(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	plain := NewMap(compareInts)
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+		plain.Put(i, i)
+	}
+	m.Remove(1)
+	m.Remove(2)
+	plain.Remove(1)
+	plain.Remove(2)
+	m.Transaction(func(tx *Txn) {
+		c.Assert(tx.Len(), Equals, 3)
+	})
+	k, _, ok := m.GetByRank(1)
+	c.Assert(ok, Equals, true)
+	c.Assert(k, Equals, 3)
+	c.Assert(m.Rank(4), Equals, 2)
+	c.Assert(m.GetByRankRange(0, -1), DeepEquals, []Entry{{0, 0}, {3, 3}, {4, 4}})
+	sameRanks(c, m, plain)
+	c.Assert(m.Validate(), IsNil)
+
+	// a tombstone at either end
+	m.Remove(0)
+	m.Remove(4)
+	plain.Remove(0)
+	plain.Remove(4)
+	sameRanks(c, m, plain)
+	c.Assert(m.GetClosestN(2, 3, func(a, b interface{}) float64 { return float64(a.(int) - b.(int)) }), DeepEquals, []Entry{{3, 3}})
+
+	// removing by rank skips the tombstones too
+	m.Put(0, 0)
+	m.Remove(3)
+	e, ok := m.RemoveByRank(0)
+	c.Assert(ok, Equals, true)
+	c.Assert(e, Equals, Entry{0, 0})
+	c.Assert(m.Len(), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TombstoneSuite) TestRemoveByRankRangeLive(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	m.Remove(0)
+	m.Remove(5)
+	c.Assert(m.RemoveByRankRange(0, 1), Equals, 2)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{3, 4, 6, 7, 8, 9})
+	c.Assert(m.Validate(), IsNil)
+}
+
+// buriedMap returns a map of n pairs made WithTombstones with the
+// upper half removed, so half its elements are tombstones
+func buriedMap(n int) *Map {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < n; i++ {
+		m.Put(i, i)
+	}
+	for i := n / 2; i < n; i++ {
+		m.Remove(i)
+	}
+	return m
+}
+
+func (s *TombstoneSuite) TestChangesLeaveOtherTombstones(c *C) {
+	// each of these would once purge every tombstone in the map, an
+	// O(n) walk, but only needs to step over the ones in its way
+	changes := map[string]func(m *Map){
+		"PopMinN":           func(m *Map) { m.PopMinN(3) },
+		"RemoveByRank":      func(m *Map) { m.RemoveByRank(2) },
+		"RemoveByRankRange": func(m *Map) { m.RemoveByRankRange(0, 2) },
+		"BulkUpdateRange": func(m *Map) {
+			m.BulkUpdateRange(0, 3, func(k, v interface{}) (interface{}, bool) { return v, k.(int) == 1 })
+		},
+	}
+	for what, change := range changes {
+		m := buriedMap(1000)
+		nodes := m.NodeCount()
+		change(m)
+		removed := 500 - m.Len()
+		c.Assert(m.NodeCount(), Equals, nodes-removed, Commentf(what))
+		c.Assert(m.Validate(), IsNil, Commentf(what))
+	}
+
+	// popping the largest pairs cuts the tombstones after them
+	m := buriedMap(1000)
+	c.Assert(m.PopMaxN(2), DeepEquals, []Entry{{499, 499}, {498, 498}})
+	c.Assert(m.NodeCount(), Equals, 498)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TombstoneSuite) TestPopAmongTombstones(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	for _, k := range []int{0, 2, 3, 8} {
+		m.Remove(k)
+	}
+	c.Assert(m.PopMinN(2), DeepEquals, []Entry{{1, 1}, {4, 4}})
+	// 0, 2 and 3 went with them
+	c.Assert(m.NodeCount(), Equals, 5)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.PopMaxN(2), DeepEquals, []Entry{{9, 9}, {7, 7}})
+	c.Assert(m.Keys(), DeepEquals, []interface{}{5, 6})
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.RemoveByRankRange(0, -1), Equals, 2)
+	c.Assert(m.NodeCount(), Equals, 0)
+}
+
+func (s *TombstoneSuite) TestEvictAmongTombstones(c *C) {
+	for _, policy := range []EvictPolicy{EvictLowest, EvictHighest} {
+		m := NewMap(compareInts, WithTombstones(), WithMaxCost(5, nil), WithEvictPolicy(policy))
+		for i := 0; i < 5; i++ {
+			m.Put(i, i)
+		}
+		m.Remove(0)
+		m.Remove(4)
+		m.Put(10, 10)
+		m.Put(11, 11)
+		m.Put(12, 12)
+		c.Assert(m.Len(), Equals, 5)
+		want := []interface{}{1, 2, 3, 10, 12}
+		if policy == EvictLowest {
+			want = []interface{}{2, 3, 10, 11, 12}
+		}
+		c.Assert(m.Keys(), DeepEquals, want)
+		c.Assert(m.Validate(), IsNil)
+	}
+}
+
+func (s *TombstoneSuite) BenchmarkPopMinAmongTombstones(c *C) {
+	c.StopTimer()
+	m := buriedMap(2*c.N + 200000)
+	c.StartTimer()
+	for i := 0; i < c.N; i++ {
+		m.PopMin()
+	}
+}
@@ -0,0 +1,91 @@
+package skiplist
+
+// CloneTransform creates a new independent map with the same
+// comparator and options, holding fn(k, v) for every pair in the map.
+// fn may change keys as well as values; when it maps two pairs to the
+// same key the later one (in key order) overwrites the earlier. the
+// pairs are put one by one, so a cost limit applies to them as fn
+// gives them
+func (m *Map) CloneTransform(fn func(k, v interface{}) (interface{}, interface{})) *Map {
+	defer m.endOp("clonetransform", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	ret := m.emptyLike()
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		k, v := fn(e.key, e.val)
+		ret.Put(k, v)
+	}
+	return ret
+}
+
+// ReplaceIf changes the value of every pair pred returns true for to
+// newVal(k, old), in one pass under the write lock, and returns how
+// many it changed. neither function may use the map
+func (m *Map) ReplaceIf(pred func(k, v interface{}) bool, newVal func(k, oldV interface{}) interface{}) int {
+	defer m.endOp("replaceif", nil, m.startOp())
+	m.lock()
+	defer m.mutex.Unlock()
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	n := 0
+	for e := liveFrom(m.head.next[0]); e != nil; e = liveFrom(e.next[0]) {
+		if !pred(e.key, e.val) {
+			continue
+		}
+		old := e.val
+		e.val = newVal(e.key, old)
+		m.changed(e)
+		m.notify(OpUpdate, e.key, old, e.val)
+		n++
+	}
+	return n
+}
+
+// BulkUpdateRange calls fn for every pair with from <= key < to in key
+// order, read as Range reads them, replacing the pair's value with the
+// one fn returns, or removing the pair if fn returns true for remove.
+// it takes the write lock once, finds from once and walks along level
+// 0 from there, so it allocates nothing however long the range. it
+// returns how many pairs it updated and how many it removed. fn must
+// not use the map
+func (m *Map) BulkUpdateRange(from, to interface{}, fn func(k, v interface{}) (newV interface{}, remove bool)) (updated, removed int) {
+	defer m.endOp("bulkupdaterange", from, m.startOp())
+	from, to = m.normal(from), m.normal(to)
+	m.lock()
+	defer m.mutex.Unlock()
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	// backPointer holds the last element kept at each level
+	var backPointer [maxHeight]*mapElement
+	var e *mapElement
+	if from == nil {
+		for level := 0; level < m.maxLevels; level++ {
+			backPointer[level] = m.head
+		}
+		e = m.head.next[0]
+	} else {
+		e = m.lowerBound(from, backPointer[:])
+	}
+	for ; e != nil && m.inRange(e, to); e = e.next[0] {
+		// a tombstone stays linked, so it is passed like a kept pair
+		if !e.deleted {
+			v, remove := fn(e.key, e.val)
+			if remove {
+				m.unlink(e, backPointer[:])
+				removed++
+				continue
+			}
+			old := e.val
+			e.val = v
+			m.changed(e)
+			m.notify(OpUpdate, e.key, old, v)
+			updated++
+		}
+		for level := 0; level < len(e.next); level++ {
+			backPointer[level] = e
+		}
+	}
+	return updated, removed
+}
@@ -0,0 +1,134 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type TransformSuite struct{}
+
+var _ = Suite(&TransformSuite{})
+
+func (s *TransformSuite) TestCloneTransformShift(c *C) {
+	m := fillMap(100)
+	t := m.CloneTransform(func(k, v interface{}) (interface{}, interface{}) {
+		return k.(int) + 1000, v
+	})
+	c.Assert(t.Len(), Equals, 100)
+	c.Assert(t.Validate(), IsNil)
+	i := 0
+	for e := t.head.next[0]; e != nil; e = e.next[0] {
+		c.Assert(e.key, Equals, i+1000)
+		c.Assert(e.val, Equals, i*2)
+		i++
+	}
+
+	// the maps are independent
+	t.Put(1000, -1)
+	m.Remove(0)
+	x, _ := t.Get(1000)
+	c.Assert(x, Equals, -1)
+	_, ok := m.Get(1000)
+	c.Assert(ok, Equals, false)
+	c.Assert(t.Len(), Equals, 100)
+	c.Assert(m.Len(), Equals, 99)
+}
+
+func (s *TransformSuite) TestCloneTransformCollisions(c *C) {
+	m := fillMap(10)
+	t := m.CloneTransform(func(k, v interface{}) (interface{}, interface{}) {
+		return k.(int) / 5, v
+	})
+	c.Assert(t.Len(), Equals, 2)
+	x, _ := t.Get(0)
+	c.Assert(x, Equals, 8)
+	x, _ = t.Get(1)
+	c.Assert(x, Equals, 18)
+}
+
+func (s *TransformSuite) TestReplaceIf(c *C) {
+	m := fillMap(100)
+	events, cancel := m.Watch(100)
+	n := m.ReplaceIf(func(k, v interface{}) bool { return v.(int) > 150 },
+		func(k, old interface{}) interface{} { return old.(int) * 2 })
+	c.Assert(n, Equals, 24)
+	for i := 0; i < 100; i++ {
+		v, _ := m.Get(i)
+		if i*2 > 150 {
+			c.Assert(v, Equals, i*4)
+		} else {
+			c.Assert(v, Equals, i*2)
+		}
+	}
+	cancel()
+	c.Assert(drain(events), HasLen, 24)
+	c.Assert(m.ReplaceIf(func(k, v interface{}) bool { return false }, nil), Equals, 0)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TransformSuite) TestBulkUpdateRange(c *C) {
+	m := fillMap(100)
+	calls := 0
+	updated, removed := m.BulkUpdateRange(10, 20, func(k, v interface{}) (interface{}, bool) {
+		calls++
+		return v.(int) * 10, false
+	})
+	c.Assert(updated, Equals, 10)
+	c.Assert(removed, Equals, 0)
+	c.Assert(calls, Equals, 10)
+	for i := 0; i < 100; i++ {
+		v, _ := m.Get(i)
+		if i >= 10 && i < 20 {
+			c.Assert(v, Equals, i*20)
+		} else {
+			c.Assert(v, Equals, i*2)
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TransformSuite) TestBulkUpdateRangeRemoves(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	for i := 0; i < 200; i++ {
+		m.Put(i, i)
+	}
+	m.Remove(51)
+	// drop the odd keys from 50 on and negate the even ones
+	updated, removed := m.BulkUpdateRange(50, nil, func(k, v interface{}) (interface{}, bool) {
+		return -v.(int), k.(int)%2 == 1
+	})
+	c.Assert(updated, Equals, 75)
+	c.Assert(removed, Equals, 74)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 125)
+	v, _ := m.Get(50)
+	c.Assert(v, Equals, -50)
+	v, _ = m.Get(49)
+	c.Assert(v, Equals, 49)
+	_, ok := m.Get(53)
+	c.Assert(ok, Equals, false)
+
+	// from the start, removing everything before 10
+	updated, removed = m.BulkUpdateRange(nil, 10, func(k, v interface{}) (interface{}, bool) {
+		return v, true
+	})
+	c.Assert(updated, Equals, 0)
+	c.Assert(removed, Equals, 10)
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 115)
+	m.Put(0, 0)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *TransformSuite) TestBulkUpdateRangeEmpty(c *C) {
+	m := fillMap(10)
+	never := func(k, v interface{}) (interface{}, bool) {
+		panic("called for an empty range")
+	}
+	updated, removed := m.BulkUpdateRange(5, 5, never)
+	c.Assert(updated+removed, Equals, 0)
+	updated, removed = m.BulkUpdateRange(100, nil, never)
+	c.Assert(updated+removed, Equals, 0)
+	updated, removed = NewMap(compareInts).BulkUpdateRange(nil, nil, never)
+	c.Assert(updated+removed, Equals, 0)
+	c.Assert(m.Entries(), DeepEquals, fillMap(10).Entries())
+}
@@ -0,0 +1,40 @@
+package skiplist
+
+// Txn gives access to a map from inside Transaction or WithLock.
+// its methods work directly on the live map, there is no rollback
+type Txn struct {
+	view
+}
+
+// Transaction runs fn holding the write lock for the whole call,
+// so everything fn does through tx is atomic with respect to other
+// callers of the map. there is no rollback: changes made before fn
+// panics or gives up stay in the map. fn must not call methods on
+// the map itself, which panics rather than deadlocking, and tx must
+// not be kept after fn returns
+func (m *Map) Transaction(fn func(tx *Txn)) {
+	m.lock()
+	defer m.mutex.Unlock()
+	tx := &Txn{view{m}}
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	defer func() { tx.m = nil }()
+	fn(tx)
+}
+
+// Put puts the value in the map for the key, replacing an existing value.
+// returns true if it overwrites, false if it inserts a new key/value pair.
+// it panics if k is nil
+func (tx *Txn) Put(k interface{}, v interface{}) bool {
+	k = tx.m.normal(k)
+	checkKey(k)
+	return tx.m.put(k, v)
+}
+
+// Remove removes the element (k/v pair) for a key,
+// returns true if it found and removed, false otherwise
+func (tx *Txn) Remove(k interface{}) bool {
+	k = tx.m.normal(k)
+	_, ok := tx.m.remove(k)
+	return ok
+}
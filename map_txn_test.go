@@ -0,0 +1,75 @@
+package skiplist
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type TxnSuite struct{}
+
+var _ = Suite(&TxnSuite{})
+
+func (s *TxnSuite) TestTransaction(c *C) {
+	m := NewMap(compareStrings)
+	m.Transaction(func(tx *Txn) {
+		c.Assert(tx.Put("a", 1), Equals, false)
+		c.Assert(tx.Put("a", 2), Equals, true)
+		x, ok := tx.Get("a")
+		c.Assert(ok, Equals, true)
+		c.Assert(x, Equals, 2)
+		tx.Put("b", 3)
+		c.Assert(tx.Remove("b"), Equals, true)
+	})
+	x, _ := m.Get("a")
+	c.Assert(x, Equals, 2)
+	c.Assert(m.Len(), Equals, 1)
+}
+
+func (s *TxnSuite) TestTransactionUseAfterPanics(c *C) {
+	m := NewMap(compareStrings)
+	var saved *Txn
+	m.Transaction(func(tx *Txn) { saved = tx })
+	c.Assert(func() { saved.Put("a", 1) }, PanicMatches, ".*nil pointer.*")
+}
+
+// transfers move money between two accounts while a reader checks
+// the total never changes, which it would if it saw half a transfer
+func (s *TxnSuite) TestTransactionAtomicTransfer(c *C) {
+	m := NewMap(compareStrings)
+	m.Put("alice", 1000)
+	m.Put("bob", 1000)
+	var w sync.WaitGroup
+	done := make(chan bool)
+	w.Add(1)
+	go func() {
+		for i := 0; i < 10000; i++ {
+			m.Transaction(func(tx *Txn) {
+				a, _ := tx.Get("alice")
+				b, _ := tx.Get("bob")
+				amount := i%7 - 3
+				tx.Put("alice", a.(int)-amount)
+				tx.Put("bob", b.(int)+amount)
+			})
+		}
+		close(done)
+		w.Done()
+	}()
+	bad := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		m.Transaction(func(tx *Txn) {
+			a, _ := tx.Get("alice")
+			b, _ := tx.Get("bob")
+			if a.(int)+b.(int) != 2000 {
+				bad++
+			}
+		})
+	}
+	w.Wait()
+	c.Assert(bad, Equals, 0)
+}
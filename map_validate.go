@@ -0,0 +1,156 @@
+package skiplist
+
+import (
+	"fmt"
+)
+
+// Validate checks the structure of the map, returning an error
+// describing the first problem it finds, or nil if the map is sound.
+// it checks that every level is ordered, that every level is a
+// sublist of the one below it, that the spans agree with the
+// positions at level 0 and that the length is right
+func (m *Map) Validate() error {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.validate()
+}
+
+// validate does the work of Validate, the caller must hold the lock
+func (m *Map) validate() error {
+	if len(m.head.next) != m.maxLevels {
+		return fmt.Errorf("skiplist: head has %d levels, expected %d", len(m.head.next), m.maxLevels)
+	}
+	if len(m.head.span) != m.maxLevels {
+		return fmt.Errorf("skiplist: head has %d spans, expected %d", len(m.head.span), m.maxLevels)
+	}
+	// rank holds the one based position of every element, counting
+	// only live pairs as the spans do, so a tombstone shares the rank
+	// of the live pair before it
+	rank := map[*mapElement]int{}
+	live := 0
+	for e := m.head.next[0]; e != nil; e = e.next[0] {
+		if _, ok := rank[e]; ok {
+			return fmt.Errorf("skiplist: cycle at level 0 through %v", e.key)
+		}
+		if !e.deleted {
+			live++
+		}
+		rank[e] = live
+	}
+	if live != m.length {
+		return fmt.Errorf("skiplist: %d live elements but length is %d", live, m.length)
+	}
+	for level := 0; level < m.maxLevels; level++ {
+		// below walks the level underneath in step with this one
+		var below *mapElement
+		if level > 0 {
+			below = m.head.next[level-1]
+		}
+		prev := m.head
+		for e := m.head.next[level]; e != nil; e = e.next[level] {
+			if len(e.next) <= level {
+				return fmt.Errorf("skiplist: element %v linked at level %d but has %d levels", e.key, level, len(e.next))
+			}
+			if len(e.span) != len(e.next) {
+				return fmt.Errorf("skiplist: element %v has %d spans for %d levels", e.key, len(e.span), len(e.next))
+			}
+			if prev.span[level] != rank[e]-rank[prev] {
+				return fmt.Errorf("skiplist: span to %v at level %d is %d, expected %d", e.key, level, prev.span[level], rank[e]-rank[prev])
+			}
+			if prev != m.head {
+				if m.comp(e.key, prev.key) {
+					return fmt.Errorf("skiplist: %v after %v at level %d", e.key, prev.key, level)
+				}
+				if !m.multi && !m.comp(prev.key, e.key) {
+					return fmt.Errorf("skiplist: duplicate key %v at level %d", e.key, level)
+				}
+				if m.tieBreak != nil && !m.comp(prev.key, e.key) && m.tieBreak(e.val, prev.val) {
+					return fmt.Errorf("skiplist: value %v after %v for key %v at level %d", e.val, prev.val, e.key, level)
+				}
+			}
+			if level > 0 {
+				for below != nil && below != e {
+					below = below.next[level-1]
+				}
+				if below == nil {
+					return fmt.Errorf("skiplist: element %v at level %d missing from level %d", e.key, level, level-1)
+				}
+			}
+			prev = e
+		}
+	}
+	return nil
+}
+
+// IsSorted walks level 0 checking that no key is less than the one
+// before it, returning false at the first inversion. it is cheaper
+// than Validate when only the order matters
+func (m *Map) IsSorted() bool {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	ret := true
+	for e := m.head.next[0]; e != nil && e.next[0] != nil; e = e.next[0] {
+		if m.comp(e.next[0].key, e.key) {
+			ret = false
+			break
+		}
+	}
+	return ret
+}
+
+// CheckComparator checks that the map's comparator is a strict weak
+// ordering over samples, returning an error describing the first
+// problem it finds, or nil. no key may be less than itself, two keys
+// can't each be less than the other, and both less and equal must be
+// transitive. it tries every triple of samples, so keep them few
+func (m *Map) CheckComparator(samples []interface{}) error {
+	less := m.comp
+	eq := func(a, b interface{}) bool { return !less(a, b) && !less(b, a) }
+	for _, a := range samples {
+		if less(a, a) {
+			return fmt.Errorf("skiplist: comparator has %v less than itself", a)
+		}
+	}
+	for _, a := range samples {
+		for _, b := range samples {
+			if less(a, b) && less(b, a) {
+				return fmt.Errorf("skiplist: comparator has %v and %v each less than the other", a, b)
+			}
+		}
+	}
+	for _, a := range samples {
+		for _, b := range samples {
+			for _, c := range samples {
+				if less(a, b) && less(b, c) && !less(a, c) {
+					return fmt.Errorf("skiplist: comparator has %v < %v and %v < %v but not %v < %v", a, b, b, c, a, c)
+				}
+				if eq(a, b) && eq(b, c) && !eq(a, c) {
+					return fmt.Errorf("skiplist: comparator has %v equal to %v and %v equal to %v but not %v equal to %v", a, b, b, c, a, c)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// WithComparatorCheck makes Put check, before it overwrites a pair,
+// that the key it found equal to the one put is really in its place,
+// panicking rather than overwriting if it isn't. a comparator that
+// can't order some key, say < on a float64 NaN, finds it equal to the
+// first key it meets and would silently replace that pair. the check
+// compares the key with the pair after the one found, so it costs a
+// call to the comparator per overwrite and can't catch such a key in
+// a map holding only one pair. it has no effect on a multimap
+func WithComparatorCheck() Option {
+	return func(m *Map) {
+		m.checkComp = true
+	}
+}
+
+// checkPlace panics if k, found equal to e, isn't less than the key
+// after e. the caller must hold the write lock
+func (m *Map) checkPlace(k interface{}, e *mapElement) {
+	if next := e.next[0]; next != nil && !m.comp(k, next.key) {
+		panic(fmt.Sprintf("skiplist: incomparable key %v: the comparator finds it equal to %v but not less than %v", k, e.key, next.key))
+	}
+}
@@ -0,0 +1,163 @@
+package skiplist
+
+import (
+	"math/rand"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ValidateSuite struct{}
+
+var _ = Suite(&ValidateSuite{})
+
+func (s *ValidateSuite) TestValidateEmpty(c *C) {
+	c.Assert(NewMap(compareInts).Validate(), IsNil)
+	c.Assert(NewMultiMap(compareInts).Validate(), IsNil)
+}
+
+func (s *ValidateSuite) TestValidateRandom(c *C) {
+	m := NewMap(compareInts)
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 10000; i++ {
+		k := r.Intn(2000)
+		if i%3 == 0 {
+			m.Remove(k)
+		} else {
+			m.Put(k, i)
+		}
+	}
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ValidateSuite) TestValidateOutOfOrder(c *C) {
+	m := fillMap(100)
+	m.head.next[0].next[0].key = 1000
+	c.Assert(m.Validate(), NotNil)
+}
+
+func (s *ValidateSuite) TestValidateLength(c *C) {
+	m := fillMap(100)
+	m.length++
+	c.Assert(m.Validate(), ErrorMatches, ".*length.*")
+}
+
+func (s *ValidateSuite) TestValidateDuplicate(c *C) {
+	m := fillMap(10)
+	m.head.next[0].next[0].key = m.head.next[0].key
+	c.Assert(m.Validate(), ErrorMatches, ".*duplicate.*")
+	m = NewMultiMap(compareInts)
+	m.Put(1, 1)
+	m.Put(1, 2)
+	c.Assert(m.Validate(), IsNil)
+}
+
+func (s *ValidateSuite) TestIsSorted(c *C) {
+	c.Assert(NewMap(compareInts).IsSorted(), Equals, true)
+	m := fillMapRand(1000)
+	c.Assert(m.IsSorted(), Equals, true)
+	m.head.next[0].next[0].next[0].key = -1
+	c.Assert(m.IsSorted(), Equals, false)
+
+	multi := NewMultiMap(compareInts)
+	multi.Put(1, 1)
+	multi.Put(1, 2)
+	c.Assert(multi.IsSorted(), Equals, true)
+}
+
+func (s *ValidateSuite) TestCheckComparator(c *C) {
+	ints := []interface{}{3, 1, 4, 1, 5, 9, 2, 6, -5}
+	c.Assert(NewMap(compareInts).CheckComparator(ints), IsNil)
+	c.Assert(NewMap(Reverse(compareInts)).CheckComparator(ints), IsNil)
+	c.Assert(NewMap(compareInts).CheckComparator(nil), IsNil)
+	strs := []interface{}{"a", "B", "b", "A", "c"}
+	c.Assert(NewMap(CaseInsensitiveLess).CheckComparator(strs), IsNil)
+	c.Assert(NewMap(FoldLess).CheckComparator(strs), IsNil)
+}
+
+func (s *ValidateSuite) TestCheckComparatorBroken(c *C) {
+	ints := []interface{}{1, 2, 3}
+	lessEq := func(a, b interface{}) bool { return a.(int) <= b.(int) }
+	c.Assert(NewMap(lessEq).CheckComparator(ints), ErrorMatches, "skiplist: comparator has 1 less than itself")
+	// rock, paper, scissors
+	beats := func(a, b interface{}) bool { return (a.(int)+1)%3 == b.(int)%3 }
+	c.Assert(NewMap(beats).CheckComparator(ints), ErrorMatches, "skiplist: comparator has 1 < 2 and 2 < 3 but not 1 < 3")
+	both := func(a, b interface{}) bool { return a.(int) != b.(int) }
+	c.Assert(NewMap(both).CheckComparator(ints), ErrorMatches, "skiplist: comparator has 1 and 2 each less than the other")
+	// keys within 1 of each other are equal, so 1 = 2 = 3 but 1 < 3
+	near := func(a, b interface{}) bool { return a.(int) < b.(int)-1 }
+	c.Assert(NewMap(near).CheckComparator(ints), ErrorMatches, "skiplist: comparator has 1 equal to 2 and 2 equal to 3 but not 1 equal to 3")
+}
+
+// cursed is a key the comparator panics on, when comparing it with a
+// key close by, so a search for it gets part way down before panicking
+const cursed = 13
+
+func cursedLess(a, b interface{}) bool {
+	if a == cursed && b.(int) > 10 && b.(int) < 20 || b == cursed && a.(int) > 10 && a.(int) < 20 {
+		panic("cursed key")
+	}
+	return a.(int) < b.(int)
+}
+
+// usable checks that m is unlocked and whole after a panic, taking
+// the write lock from another goroutine so a lock left held fails
+// the test rather than hanging it
+func usable(c *C, m *Map, n int) {
+	done := make(chan bool)
+	go func() {
+		m.Put(1000, 0)
+		m.Remove(1000)
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatal("map left locked")
+	}
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, n)
+}
+
+func (s *ValidateSuite) TestComparatorPanics(c *C) {
+	for _, m := range []*Map{NewMap(cursedLess), NewMultiMap(cursedLess), NewMap(cursedLess, WithTombstones())} {
+		for i := 0; i < 100; i += 2 {
+			m.Put(i, i)
+		}
+		ops := []func(){
+			func() { m.Put(cursed, 0) },
+			func() { m.Get(cursed) },
+			func() { m.Remove(cursed) },
+			func() { m.PutOrMerge(cursed, 0, nil) },
+			func() { m.Floor(cursed) },
+			func() { m.Range(cursed, nil, func(k, v interface{}) bool { return true }) },
+			func() { m.Split(cursed) },
+			func() { m.SwapValues(2, cursed) },
+			func() { m.ReKey(2, cursed) },
+			func() { m.Transaction(func(tx *Txn) { tx.Put(1, 1); tx.Put(cursed, 0) }) },
+		}
+		n := 50
+		for i, op := range ops {
+			c.Assert(op, PanicMatches, "cursed key", Commentf("op %d", i))
+			if i == len(ops)-1 {
+				// the transaction's first Put stays, there is no rollback
+				n++
+			}
+			usable(c, m, n)
+		}
+	}
+}
+
+func (s *ValidateSuite) TestCallbackPanics(c *C) {
+	m := fillMap(20)
+	boom := func(k, v interface{}) bool { panic("boom") }
+	c.Assert(func() { m.Range(nil, nil, boom) }, PanicMatches, "boom")
+	c.Assert(func() { m.ForEachKey(func(k interface{}) bool { panic("boom") }) }, PanicMatches, "boom")
+	c.Assert(func() { m.CompactIf(func(v interface{}) bool { return v == 10 || boom(nil, v) }) }, PanicMatches, "boom")
+	c.Assert(func() {
+		m.PutOrMerge(3, 0, func(existing, incoming interface{}) interface{} { panic("boom") })
+	}, PanicMatches, "boom")
+	// the goroutine isn't left marked as running a callback either
+	c.Assert(m.Put(3, 3), Equals, true)
+	usable(c, m, 20)
+}
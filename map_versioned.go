@@ -0,0 +1,43 @@
+package skiplist
+
+// PutVersioned is Put, returning the version the pair for k is at
+// afterwards. a new pair starts at version 1, and every change to its
+// value through the map, Put, PutOrMerge, ReplaceIf and the like,
+// moves it on by one, so a client can tell if a pair has changed since
+// it last looked. a removed key starts again at 1 when it is put back,
+// unless the map keeps tombstones. in a multimap every Put adds a new
+// pair, at version 1. it panics if k is nil
+func (m *Map) PutVersioned(k, v interface{}) uint64 {
+	_, version, _ := m.putE(k, v, true, nil)
+	return version
+}
+
+// putVersion returns the version of the pair just put for k,
+// the caller must hold the write lock
+func (m *Map) putVersion(k interface{}) uint64 {
+	if m.multi {
+		return 1
+	}
+	if e := m.find(k, nil); e != nil {
+		return e.version
+	}
+	return 0
+}
+
+// GetVersioned returns the value for a key and its version, as
+// returned by PutVersioned, and true if it finds the key, false
+// otherwise. in a multimap it returns the first pair for the key
+func (m *Map) GetVersioned(k interface{}) (v interface{}, version uint64, ok bool) {
+	defer m.endOp("getversioned", k, m.startOp())
+	k = m.normal(k)
+	if k == nil {
+		return nil, 0, false
+	}
+	locked := m.rlock()
+	defer m.runlock(locked)
+	e := m.find(k, nil)
+	if e == nil {
+		return nil, 0, false
+	}
+	return e.val, e.version, true
+}
@@ -0,0 +1,45 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type VersionedSuite struct{}
+
+var _ = Suite(&VersionedSuite{})
+
+func (s *VersionedSuite) TestPutVersioned(c *C) {
+	m := NewMap(compareStrings)
+	_, _, ok := m.GetVersioned("a")
+	c.Assert(ok, Equals, false)
+	for i := uint64(1); i <= 5; i++ {
+		c.Assert(m.PutVersioned("a", i), Equals, i)
+		v, version, ok := m.GetVersioned("a")
+		c.Assert(ok, Equals, true)
+		c.Assert(v, Equals, i)
+		c.Assert(version, Equals, i)
+	}
+	// other keys have versions of their own
+	c.Assert(m.PutVersioned("b", 0), Equals, uint64(1))
+	// other ways of changing the value move the version on too
+	m.Put("a", 6)
+	m.PutOrMerge("a", 1, func(existing, incoming interface{}) interface{} { return existing.(int) + 1 })
+	m.SwapValues("a", "b")
+	_, version, _ := m.GetVersioned("a")
+	c.Assert(version, Equals, uint64(8))
+	m.Remove("a")
+	c.Assert(m.PutVersioned("a", 0), Equals, uint64(1))
+}
+
+func (s *VersionedSuite) TestTombstonesKeepVersions(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	m.PutVersioned(1, "x")
+	m.PutVersioned(1, "y")
+	m.Remove(1)
+	_, _, ok := m.GetVersioned(1)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.PutVersioned(1, "z"), Equals, uint64(3))
+	multi := NewMultiMap(compareInts)
+	c.Assert(multi.PutVersioned(1, "x"), Equals, uint64(1))
+	c.Assert(multi.PutVersioned(1, "y"), Equals, uint64(1))
+}
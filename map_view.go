@@ -0,0 +1,109 @@
+package skiplist
+
+// ReadView reads a map that is already locked, see WithRLock
+type ReadView interface {
+	Get(k interface{}) (interface{}, bool)
+	First() (interface{}, interface{}, bool)
+	Last() (interface{}, interface{}, bool)
+	Range(from, to interface{}, fn func(k, v interface{}) bool)
+	Len() int
+}
+
+// WriteView reads and changes a map that is already
+// write locked, see WithLock
+type WriteView interface {
+	ReadView
+	Put(k interface{}, v interface{}) bool
+	Remove(k interface{}) bool
+}
+
+// view is the ReadView handed out by WithRLock, its methods
+// assume the lock is held
+type view struct {
+	m *Map
+}
+
+// WithRLock runs fn holding the read lock for the whole call, so the
+// reads fn makes through view are consistent with each other. fn must
+// not call methods on the map itself, which panics rather than risking
+// a deadlock, and view must not be kept after fn returns
+func (m *Map) WithRLock(fn func(view ReadView)) {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	v := &view{m}
+	id := m.callback.enter()
+	defer m.callback.leave(id)
+	defer func() { v.m = nil }()
+	fn(v)
+}
+
+// WithLock runs fn holding the write lock for the whole call, like
+// Transaction, with view giving the reads and writes fn may make
+func (m *Map) WithLock(fn func(view WriteView)) {
+	m.Transaction(func(tx *Txn) { fn(tx) })
+}
+
+// Get returns the value for a key, and true if it finds the key,
+// false otherwise
+func (v *view) Get(k interface{}) (interface{}, bool) {
+	k = v.m.normal(k)
+	return v.m.get(k)
+}
+
+// First returns the pair with the smallest key, and true if
+// the map is not empty, false otherwise
+func (v *view) First() (interface{}, interface{}, bool) {
+	return v.m.first()
+}
+
+// Last returns the pair with the largest key, and true if
+// the map is not empty, false otherwise
+func (v *view) Last() (interface{}, interface{}, bool) {
+	return v.m.last()
+}
+
+// Range calls fn for every pair with from <= key < to in key order,
+// like Map.Range
+func (v *view) Range(from, to interface{}, fn func(k, v interface{}) bool) {
+	from, to = v.m.normal(from), v.m.normal(to)
+	v.m.scan(from, to, fn)
+}
+
+// Len returns the number of pairs in the map
+func (v *view) Len() int {
+	return v.m.length
+}
+
+// First returns the pair with the smallest key, and true if
+// the map is not empty, false otherwise
+func (m *Map) First() (interface{}, interface{}, bool) {
+	defer m.endOp("first", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.first()
+}
+
+// Last returns the pair with the largest key, and true if
+// the map is not empty, false otherwise
+func (m *Map) Last() (interface{}, interface{}, bool) {
+	defer m.endOp("last", nil, m.startOp())
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.last()
+}
+
+// first does the work of First, the caller must hold the lock
+func (m *Map) first() (interface{}, interface{}, bool) {
+	if e := liveFrom(m.head.next[0]); e != nil {
+		return e.key, e.val, true
+	}
+	return nil, nil, false
+}
+
+// last does the work of Last, the caller must hold the lock
+func (m *Map) last() (interface{}, interface{}, bool) {
+	if e := m.elementAt(m.length-1, nil); e != nil {
+		return e.key, e.val, true
+	}
+	return nil, nil, false
+}
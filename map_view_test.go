@@ -0,0 +1,108 @@
+package skiplist
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type ViewSuite struct{}
+
+var _ = Suite(&ViewSuite{})
+
+func (s *ViewSuite) TestFirstLast(c *C) {
+	m := NewMap(compareInts)
+	_, _, ok := m.First()
+	c.Assert(ok, Equals, false)
+	_, _, ok = m.Last()
+	c.Assert(ok, Equals, false)
+	m = fillMapRand(1000)
+	keys := m.Keys()
+	k, v, ok := m.First()
+	c.Assert(ok, Equals, true)
+	c.Assert(k, Equals, keys[0])
+	c.Assert(v, Equals, k.(int)*2)
+	k, v, ok = m.Last()
+	c.Assert(ok, Equals, true)
+	c.Assert(k, Equals, keys[999])
+	c.Assert(v, Equals, k.(int)*2)
+}
+
+// using the view inside the callbacks must not take the lock again
+func (s *ViewSuite) TestViewsDontDeadlock(c *C) {
+	m := fillMap(100)
+	m.WithRLock(func(view ReadView) {
+		x, ok := view.Get(5)
+		c.Assert(ok, Equals, true)
+		c.Assert(x, Equals, 10)
+		k, _, _ := view.First()
+		c.Assert(k, Equals, 0)
+		k, _, _ = view.Last()
+		c.Assert(k, Equals, 99)
+		c.Assert(view.Len(), Equals, 100)
+		n := 0
+		view.Range(10, 20, func(k, v interface{}) bool {
+			n++
+			return true
+		})
+		c.Assert(n, Equals, 10)
+	})
+	m.WithLock(func(view WriteView) {
+		c.Assert(view.Put(100, 200), Equals, false)
+		c.Assert(view.Remove(0), Equals, true)
+		k, _, _ := view.First()
+		c.Assert(k, Equals, 1)
+		k, _, _ = view.Last()
+		c.Assert(k, Equals, 100)
+		c.Assert(view.Len(), Equals, 100)
+	})
+	c.Assert(m.Validate(), IsNil)
+}
+
+// writers keep first+last at 0 and len at 10 between their locks,
+// readers under one read lock must never see anything else
+func (s *ViewSuite) TestViewsConsistent(c *C) {
+	m := NewMap(compareInts)
+	for i := 0; i < 10; i++ {
+		m.Put(i, 0)
+	}
+	m.Put(0, -1)
+	m.Put(9, 1)
+	var w sync.WaitGroup
+	done := make(chan bool)
+	for i := 0; i < 2; i++ {
+		w.Add(1)
+		go func() {
+			for j := 0; j < 5000; j++ {
+				m.WithLock(func(view WriteView) {
+					_, v, _ := view.First()
+					view.Put(0, -v.(int)-1)
+					view.Put(9, v.(int)+1)
+					view.Remove(5)
+					view.Put(5, j)
+				})
+			}
+			w.Done()
+		}()
+	}
+	go func() {
+		w.Wait()
+		close(done)
+	}()
+	bad := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		m.WithRLock(func(view ReadView) {
+			_, first, _ := view.First()
+			_, last, _ := view.Last()
+			if first.(int)+last.(int) != 0 || view.Len() != 10 {
+				bad++
+			}
+		})
+	}
+	c.Assert(bad, Equals, 0)
+}
@@ -0,0 +1,149 @@
+package skiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrBadWAL is returned by ReplayWAL for a log it can't read
+var ErrBadWAL = errors.New("skiplist: bad write-ahead log record")
+
+// the kinds of record in a write-ahead log
+const (
+	walPut    = 'P'
+	walRemove = 'R'
+	walClear  = 'C'
+)
+
+// wal is where a map logs its changes, see EnableWAL
+type wal struct {
+	w      io.Writer
+	encode func(interface{}) []byte
+	buf    []byte
+	err    error
+}
+
+// EnableWAL logs every change made to the map's pairs from now on to
+// w, however it is made, with encode turning keys and values into
+// bytes. a change is logged where watchers are told of it, as a put of
+// the new value or a remove of the key, and emptying the map, as Clear,
+// Drain, Split and Concat do, is logged as one record. a record is
+// written with a single call to w while the write lock is held, so
+// w sees the changes in the order they were made. pairs already in
+// the map are not logged. in a multimap a record names a pair by its
+// key alone, so only changes to the first pair for a key replay
+// exactly. if a write fails logging stops, and the error is kept for
+// WALErr
+func (m *Map) EnableWAL(w io.Writer, encode func(interface{}) []byte) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.wal = &wal{w: w, encode: encode}
+}
+
+// WALErr returns the error that stopped the write-ahead log, or nil
+func (m *Map) WALErr() error {
+	var err error
+	locked := m.rlock()
+	defer m.runlock(locked)
+	if m.wal != nil {
+		err = m.wal.err
+	}
+	return err
+}
+
+// change logs a change told to watchers, the caller must hold the
+// write lock
+func (l *wal) change(op ChangeOp, k, new interface{}) {
+	if op == OpDelete {
+		l.write(walRemove, k)
+	} else {
+		l.write(walPut, k, new)
+	}
+}
+
+// write puts a record together in buf and writes it. each field is
+// prefixed with its length as a uvarint
+func (l *wal) write(op byte, fields ...interface{}) {
+	if l.err != nil {
+		return
+	}
+	l.buf = append(l.buf[:0], op)
+	for _, f := range fields {
+		b := l.encode(f)
+		l.buf = binary.AppendUvarint(l.buf, uint64(len(b)))
+		l.buf = append(l.buf, b...)
+	}
+	_, l.err = l.w.Write(l.buf)
+}
+
+// ReplayWAL reads a log written by EnableWAL from r and makes its
+// changes to the map in order, with decode turning bytes back into
+// keys and values. it returns nil at the end of the log, or ErrBadWAL
+// if it finds a broken record, say the last one cut off by a crash,
+// in which case the changes before it have been made. replayed
+// changes are not logged again
+func (m *Map) ReplayWAL(r io.Reader, decode func([]byte) interface{}) error {
+	defer m.endOp("replaywal", nil, m.startOp())
+	br := bufio.NewReader(r)
+	field := func() (interface{}, error) {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, ErrBadWAL
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, ErrBadWAL
+		}
+		return decode(b), nil
+	}
+	for {
+		op, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if op == walClear {
+			m.replay(op, nil, nil)
+			continue
+		}
+		if op != walPut && op != walRemove {
+			return ErrBadWAL
+		}
+		k, err := field()
+		if err != nil {
+			return err
+		}
+		var v interface{}
+		if op == walPut {
+			if v, err = field(); err != nil {
+				return err
+			}
+		}
+		if k == nil {
+			return ErrBadWAL
+		}
+		m.replay(op, k, v)
+	}
+}
+
+// replay applies one logged operation under the write lock, with
+// the map's own log put aside so it isn't logged again
+func (m *Map) replay(op byte, k, v interface{}) {
+	m.lock()
+	defer m.mutex.Unlock()
+	l := m.wal
+	m.wal = nil
+	defer func() { m.wal = l }()
+	switch op {
+	case walPut:
+		m.put(k, v)
+	case walRemove:
+		m.remove(k)
+	default:
+		m.reset()
+	}
+}
@@ -0,0 +1,236 @@
+package skiplist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type WALSuite struct{}
+
+var _ = Suite(&WALSuite{})
+
+func encodeJSON(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+func decodeInt(b []byte) interface{} {
+	var i int
+	json.Unmarshal(b, &i)
+	return i
+}
+
+func (s *WALSuite) TestReplay(c *C) {
+	m := NewMap(compareInts)
+	m.Put(-1, -1)
+	var log bytes.Buffer
+	m.EnableWAL(&log, encodeJSON)
+	for i := 0; i < 500; i++ {
+		m.Put(i, i)
+	}
+	for i := 0; i < 500; i += 3 {
+		m.Remove(i)
+	}
+	m.Remove(1000)
+	m.Remove(-1)
+	m.Transaction(func(tx *Txn) {
+		tx.Put(1, 100)
+		tx.Remove(2)
+	})
+	c.Assert(m.WALErr(), IsNil)
+
+	r := NewMap(compareInts)
+	r.Put(-1, -1)
+	c.Assert(r.ReplayWAL(bytes.NewReader(log.Bytes()), decodeInt), IsNil)
+	c.Assert(r.Entries(), DeepEquals, m.Entries())
+	c.Assert(r.Validate(), IsNil)
+}
+
+func (s *WALSuite) TestTruncated(c *C) {
+	m := NewMap(compareInts)
+	var log bytes.Buffer
+	m.EnableWAL(&log, encodeJSON)
+	m.Put(1, 10)
+	m.Put(2, 20)
+	m.Put(3, 3000)
+	b := log.Bytes()
+	r := NewMap(compareInts)
+	c.Assert(r.ReplayWAL(bytes.NewReader(b[:len(b)-2]), decodeInt), Equals, ErrBadWAL)
+	c.Assert(r.Keys(), DeepEquals, []interface{}{1, 2})
+	c.Assert(NewMap(compareInts).ReplayWAL(bytes.NewReader([]byte("X")), decodeInt), Equals, ErrBadWAL)
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.n--
+	return len(b), nil
+}
+
+func (s *WALSuite) TestWriteError(c *C) {
+	m := NewMap(compareInts)
+	w := &failWriter{n: 2}
+	m.EnableWAL(w, encodeJSON)
+	m.Put(1, 1)
+	m.Put(2, 2)
+	c.Assert(m.WALErr(), IsNil)
+	m.Put(3, 3)
+	c.Assert(m.WALErr(), ErrorMatches, "disk full")
+	// the map still changes after logging stops
+	m.Put(4, 4)
+	c.Assert(m.Len(), Equals, 4)
+}
+
+// memCodec encodes any value as its index in a slice, so values
+// JSON can't round trip, like a TTLMap's, can be logged
+type memCodec struct{ vals []interface{} }
+
+func (mc *memCodec) encode(v interface{}) []byte {
+	mc.vals = append(mc.vals, v)
+	return encodeJSON(len(mc.vals) - 1)
+}
+
+func (mc *memCodec) decode(b []byte) interface{} {
+	return mc.vals[decodeInt(b).(int)]
+}
+
+// replayed checks that replaying the log of m into a new map gives
+// the pairs m holds
+func replayed(c *C, m *Map, log *bytes.Buffer, mc *memCodec, what string) {
+	c.Assert(m.WALErr(), IsNil, Commentf(what))
+	r := NewMap(compareInts)
+	c.Assert(r.ReplayWAL(bytes.NewReader(log.Bytes()), mc.decode), IsNil, Commentf(what))
+	c.Assert(r.Entries(), DeepEquals, m.Entries(), Commentf(what))
+	c.Assert(r.Validate(), IsNil, Commentf(what))
+}
+
+func (s *WALSuite) TestReplayClear(c *C) {
+	m := NewMap(compareInts)
+	var log bytes.Buffer
+	mc := &memCodec{}
+	m.EnableWAL(&log, mc.encode)
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+	}
+	m.PopMin()
+	m.Clear()
+	m.Put(9, 9)
+	c.Assert(m.Keys(), DeepEquals, []interface{}{9})
+	replayed(c, m, &log, mc, "clear")
+}
+
+func (s *WALSuite) TestReplayEveryChange(c *C) {
+	even := func(v interface{}) bool { return v.(int)%2 == 0 }
+	changes := map[string]func(m *Map){
+		"PopMin":          func(m *Map) { m.PopMin() },
+		"PopMax":          func(m *Map) { m.PopMax() },
+		"PopMinN":         func(m *Map) { m.PopMinN(3) },
+		"PopMaxN":         func(m *Map) { m.PopMaxN(3) },
+		"Clear":           func(m *Map) { m.Clear() },
+		"ClearAndRecycle": func(m *Map) { m.ClearAndRecycle() },
+		"Drain":           func(m *Map) { m.Drain(func(k, v interface{}) bool { return false }) },
+		"CompactIf":       func(m *Map) { m.CompactIf(even) },
+		"ReplaceIf": func(m *Map) {
+			m.ReplaceIf(func(k, v interface{}) bool { return even(v) }, func(k, v interface{}) interface{} { return -v.(int) })
+		},
+		"SwapValues":        func(m *Map) { m.SwapValues(2, 7) },
+		"ReKey":             func(m *Map) { m.ReKey(3, 30) },
+		"ReKeyOverwrite":    func(m *Map) { m.ReKeyOverwrite(3, 7) },
+		"RemoveByRank":      func(m *Map) { m.RemoveByRank(4) },
+		"RemoveByRankRange": func(m *Map) { m.RemoveByRankRange(2, 6) },
+		"PutOrMerge": func(m *Map) {
+			m.PutOrMerge(5, 1, func(a, b interface{}) interface{} { return a.(int) + b.(int) })
+		},
+		"BulkUpdateRange": func(m *Map) {
+			m.BulkUpdateRange(2, 8, func(k, v interface{}) (interface{}, bool) { return v.(int) * 10, even(v) })
+		},
+		"Split": func(m *Map) { m.Split(5) },
+		"Concat": func(m *Map) {
+			left := NewMap(compareInts)
+			left.Put(-1, -1)
+			Concat(left, m)
+		},
+		"Transaction": func(m *Map) {
+			m.Transaction(func(tx *Txn) {
+				tx.Put(1, 100)
+				tx.Remove(2)
+			})
+		},
+	}
+	for what, change := range changes {
+		m := NewMap(compareInts)
+		var log bytes.Buffer
+		mc := &memCodec{}
+		m.EnableWAL(&log, mc.encode)
+		for i := 0; i < 10; i++ {
+			m.Put(i, i)
+		}
+		change(m)
+		m.Put(20, 20)
+		replayed(c, m, &log, mc, what)
+	}
+}
+
+func (s *WALSuite) TestReplayPurge(c *C) {
+	m := NewMap(compareInts, WithTombstones())
+	var log bytes.Buffer
+	mc := &memCodec{}
+	m.EnableWAL(&log, mc.encode)
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	m.Remove(3)
+	m.Remove(4)
+	m.Purge()
+	m.Put(4, 40)
+	replayed(c, m, &log, mc, "purge")
+}
+
+func (s *WALSuite) TestReplayEvictions(c *C) {
+	m := NewMap(compareInts, WithMaxCost(3, nil))
+	var log bytes.Buffer
+	mc := &memCodec{}
+	m.EnableWAL(&log, mc.encode)
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.Len(), Equals, 3)
+	replayed(c, m, &log, mc, "evictions")
+}
+
+func (s *WALSuite) TestReplayReap(c *C) {
+	t := NewTTLMap(compareInts, time.Minute)
+	now := time.Now()
+	t.now = func() time.Time { return now }
+	var log bytes.Buffer
+	mc := &memCodec{}
+	t.m.EnableWAL(&log, mc.encode)
+	for i := 0; i < 10; i++ {
+		t.Put(i, i)
+		now = now.Add(10 * time.Second)
+	}
+	c.Assert(t.Reap(), Equals, 5)
+	replayed(c, t.m, &log, mc, "reap")
+}
+
+func (s *WALSuite) TestReplayNotLoggedAgain(c *C) {
+	m := NewMap(compareInts)
+	var log bytes.Buffer
+	m.EnableWAL(&log, encodeJSON)
+	m.Put(1, 1)
+	m.Clear()
+	m.Put(2, 2)
+	var again bytes.Buffer
+	r := NewMap(compareInts)
+	r.EnableWAL(&again, encodeJSON)
+	c.Assert(r.ReplayWAL(bytes.NewReader(log.Bytes()), decodeInt), IsNil)
+	c.Assert(r.Keys(), DeepEquals, []interface{}{2})
+	c.Assert(again.Len(), Equals, 0)
+}
@@ -0,0 +1,132 @@
+package skiplist
+
+import (
+	"sync"
+)
+
+// ChangeOp is the kind of change a ChangeEvent describes
+type ChangeOp int
+
+const (
+	// OpInsert is a new pair put in the map
+	OpInsert ChangeOp = iota
+	// OpUpdate is a new value replacing the old one for a key
+	OpUpdate
+	// OpDelete is a pair removed from the map
+	OpDelete
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case OpInsert:
+		return "insert"
+	case OpUpdate:
+		return "update"
+	case OpDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// ChangeEvent describes one change to a map, Old is nil for
+// inserts and New is nil for deletes
+type ChangeEvent struct {
+	Op  ChangeOp
+	Key interface{}
+	Old interface{}
+	New interface{}
+}
+
+// watcher is a channel registered with Watch
+type watcher struct {
+	ch chan ChangeEvent
+}
+
+// Watch returns a channel receiving an event for every change to the
+// map, in the order the changes are made, and a func to stop watching
+// which also closes the channel. events are sent without blocking
+// writers: when the channel's buffer is full the event is dropped and
+// counted, see DroppedEvents, so a watcher that can't keep up should
+// use a bigger buffer or resync when the count moves
+func (m *Map) Watch(buffer int) (<-chan ChangeEvent, func()) {
+	w := &watcher{make(chan ChangeEvent, buffer)}
+	m.addWatcher(w)
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			m.removeWatcher(w)
+		})
+	}
+}
+
+// addWatcher adds w to the watchers under the write lock
+func (m *Map) addWatcher(w *watcher) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.watchers = append(m.watchers, w)
+}
+
+// removeWatcher takes w out of the watchers and closes its
+// channel under the write lock
+func (m *Map) removeWatcher(w *watcher) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i, x := range m.watchers {
+		if x == w {
+			m.watchers = append(m.watchers[:i:i], m.watchers[i+1:]...)
+			break
+		}
+	}
+	close(w.ch)
+}
+
+// DroppedEvents returns how many events have been dropped because a
+// watcher's channel was full
+func (m *Map) DroppedEvents() uint64 {
+	locked := m.rlock()
+	defer m.runlock(locked)
+	return m.dropped
+}
+
+// watched returns true if changes to the map need telling to
+// watchers or a journal one by one
+func (m *Map) watched() bool {
+	return len(m.watchers) > 0 || m.journal != nil
+}
+
+// notify records a change in the map's version, journal and
+// write-ahead log and sends it to every watcher, the caller must hold
+// the write lock
+func (m *Map) notify(op ChangeOp, k, old, new interface{}) {
+	m.version++
+	if m.wal != nil {
+		m.wal.change(op, k, new)
+	}
+	if m.journal != nil {
+		m.journal.record(op, k, old, new)
+	}
+	if m.digest != nil {
+		m.digest.change(op, k, old, new)
+	}
+	if m.cost != nil {
+		m.cost.change(op, k, old, new)
+	}
+	for _, w := range m.watchers {
+		select {
+		case w.ch <- ChangeEvent{op, k, old, new}:
+		default:
+			m.dropped++
+		}
+	}
+}
+
+// OnChange registers fn to be called after every successful Put and
+// Remove, with op "put" for a new key, "overwrite" for a new value and
+// "remove" with the value removed. fn is called without the lock held,
+// so it may use the map, but by then other changes may have been made.
+// other changes, such as those made in a Transaction, are only seen by Watch
+func (m *Map) OnChange(fn func(op string, k, v interface{})) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.onChange = append(m.onChange[:len(m.onChange):len(m.onChange)], fn)
+}
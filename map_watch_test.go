@@ -0,0 +1,140 @@
+package skiplist
+
+import (
+	"runtime"
+
+	. "gopkg.in/check.v1"
+)
+
+type WatchSuite struct{}
+
+var _ = Suite(&WatchSuite{})
+
+// drain reads the events waiting on ch
+func drain(ch <-chan ChangeEvent) []ChangeEvent {
+	ret := []ChangeEvent{}
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return ret
+			}
+			ret = append(ret, ev)
+		default:
+			return ret
+		}
+	}
+}
+
+func (s *WatchSuite) TestWatchOrder(c *C) {
+	m := NewMap(compareInts)
+	m.Put(0, "before")
+	ch, cancel := m.Watch(100)
+	defer cancel()
+	m.Put(1, "a")
+	m.Put(2, "b")
+	m.Put(1, "c")
+	m.Remove(2)
+	m.Remove(3)
+	m.SwapValues(0, 1)
+	m.PopMinN(1)
+	m.Transaction(func(tx *Txn) {
+		tx.Put(5, "e")
+		tx.Remove(5)
+	})
+	c.Assert(drain(ch), DeepEquals, []ChangeEvent{
+		{OpInsert, 1, nil, "a"},
+		{OpInsert, 2, nil, "b"},
+		{OpUpdate, 1, "a", "c"},
+		{OpDelete, 2, "b", nil},
+		{OpUpdate, 0, "before", "c"},
+		{OpUpdate, 1, "c", "before"},
+		{OpDelete, 0, "c", nil},
+		{OpInsert, 5, nil, "e"},
+		{OpDelete, 5, "e", nil},
+	})
+	c.Assert(m.DroppedEvents(), Equals, uint64(0))
+}
+
+func (s *WatchSuite) TestWatchCancel(c *C) {
+	goroutines := runtime.NumGoroutine()
+	m := NewMap(compareInts)
+	ch1, cancel1 := m.Watch(10)
+	ch2, cancel2 := m.Watch(10)
+	m.Put(1, 1)
+	cancel1()
+	cancel1()
+	m.Put(2, 2)
+	c.Assert(drain(ch1), DeepEquals, []ChangeEvent{{OpInsert, 1, nil, 1}})
+	_, open := <-ch1
+	c.Assert(open, Equals, false)
+	c.Assert(drain(ch2), HasLen, 2)
+	cancel2()
+	m.Put(3, 3)
+	_, open = <-ch2
+	c.Assert(open, Equals, false)
+	c.Assert(m.watchers, HasLen, 0)
+	c.Assert(runtime.NumGoroutine() <= goroutines, Equals, true)
+}
+
+func (s *WatchSuite) TestWatchDrops(c *C) {
+	m := NewMap(compareInts)
+	ch, cancel := m.Watch(2)
+	defer cancel()
+	for i := 0; i < 5; i++ {
+		m.Put(i, i)
+	}
+	c.Assert(m.DroppedEvents(), Equals, uint64(3))
+	c.Assert(drain(ch), DeepEquals, []ChangeEvent{{OpInsert, 0, nil, 0}, {OpInsert, 1, nil, 1}})
+	m.Put(9, 9)
+	c.Assert(drain(ch), HasLen, 1)
+}
+
+func (s *WatchSuite) TestChangeOpString(c *C) {
+	c.Assert(OpInsert.String(), Equals, "insert")
+	c.Assert(OpUpdate.String(), Equals, "update")
+	c.Assert(OpDelete.String(), Equals, "delete")
+}
+
+func (s *WatchSuite) TestOnChange(c *C) {
+	m := NewMap(compareInts)
+	type event struct {
+		op   string
+		k, v interface{}
+	}
+	got := []event{}
+	m.OnChange(func(op string, k, v interface{}) {
+		got = append(got, event{op, k, v})
+	})
+	m.Put(1, "a")
+	m.Put(2, "b")
+	m.Put(1, "c")
+	m.Remove(2)
+	m.Remove(2)
+	m.Put(3, nil)
+	c.Assert(got, DeepEquals, []event{
+		{"put", 1, "a"},
+		{"put", 2, "b"},
+		{"overwrite", 1, "c"},
+		{"remove", 2, "b"},
+		{"put", 3, nil},
+	})
+}
+
+// hooks run without the lock, so they can use the map
+func (s *WatchSuite) TestOnChangeReentrant(c *C) {
+	m := NewMap(compareInts)
+	n := 0
+	m.OnChange(func(op string, k, v interface{}) {
+		if op == "put" && k.(int) < 100 {
+			m.Put(k.(int)+100, v)
+		}
+	})
+	m.OnChange(func(op string, k, v interface{}) { n++ })
+	m.Put(1, "a")
+	x, ok := m.Get(101)
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, "a")
+	c.Assert(m.Len(), Equals, 2)
+	c.Assert(n, Equals, 2)
+}
@@ -0,0 +1,89 @@
+package skiplist
+
+import (
+	"cmp"
+	"sync"
+)
+
+// OrderedMap is a map with keys of an ordered type, kept in their
+// natural order, so it needs no comparison function. unlike Map its
+// zero value is an empty map ready to use, set up on first use
+type OrderedMap[K cmp.Ordered, V any] struct {
+	once sync.Once
+	m    *Map
+}
+
+// NewOrdered creates a new empty map with keys of an ordered type,
+// kept in their natural order by <, so there is no comparison function
+// to write. the map is made up front rather than on first use
+func NewOrdered[K cmp.Ordered, V any]() *OrderedMap[K, V] {
+	o := &OrderedMap[K, V]{}
+	o.init()
+	return o
+}
+
+// orderedLess compares keys of type K with cmp.Less, which puts
+// NaNs before every other float
+func orderedLess[K cmp.Ordered](a, b interface{}) bool {
+	return cmp.Less(a.(K), b.(K))
+}
+
+// init returns the map underneath, creating it the first time. every
+// method goes through it, and once makes the creation safe for
+// goroutines all using a zero value at the same time
+func (o *OrderedMap[K, V]) init() *Map {
+	o.once.Do(func() {
+		o.m = NewMap(orderedLess[K])
+	})
+	return o.m
+}
+
+// Put takes a key and value, and puts the value in the map for the
+// key, replacing an existing value. returns true if it overwrites,
+// false if it inserts a new key/value pair
+func (o *OrderedMap[K, V]) Put(k K, v V) bool {
+	return o.init().Put(k, v)
+}
+
+// Get returns the value for a key, and true if it finds the key,
+// or the zero value and false otherwise
+func (o *OrderedMap[K, V]) Get(k K) (V, bool) {
+	v, ok := o.init().Get(k)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	// a nil value is not a V even when V is an interface
+	ret, _ := v.(V)
+	return ret, true
+}
+
+// Remove removes the pair for a key,
+// returns true if it found and removed it, false otherwise
+func (o *OrderedMap[K, V]) Remove(k K) bool {
+	return o.init().Remove(k)
+}
+
+// Len returns the number of pairs in the map
+func (o *OrderedMap[K, V]) Len() int {
+	return o.init().Len()
+}
+
+// Keys returns all the keys in the map in order
+func (o *OrderedMap[K, V]) Keys() []K {
+	keys := o.init().Keys()
+	ret := make([]K, len(keys))
+	for i, k := range keys {
+		ret[i] = k.(K)
+	}
+	return ret
+}
+
+// ForEach calls fn for every pair in key order, holding the read
+// lock throughout, so fn must not use the map
+func (o *OrderedMap[K, V]) ForEach(fn func(k K, v V)) {
+	o.init().ForEach(func(k, v interface{}) {
+		val, _ := v.(V)
+		fn(k.(K), val)
+	})
+}
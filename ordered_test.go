@@ -0,0 +1,93 @@
+package skiplist
+
+import (
+	"strconv"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type OrderedSuite struct{}
+
+var _ = Suite(&OrderedSuite{})
+
+func (s *OrderedSuite) TestZeroValue(c *C) {
+	var m OrderedMap[int, string]
+	c.Assert(m.Len(), Equals, 0)
+	_, ok := m.Get(1)
+	c.Assert(ok, Equals, false)
+	c.Assert(m.Put(3, "c"), Equals, false)
+	c.Assert(m.Put(1, "a"), Equals, false)
+	c.Assert(m.Put(2, "b"), Equals, false)
+	c.Assert(m.Put(1, "A"), Equals, true)
+	v, ok := m.Get(1)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "A")
+	c.Assert(m.Keys(), DeepEquals, []int{1, 2, 3})
+	c.Assert(m.Remove(2), Equals, true)
+	vals := ""
+	m.ForEach(func(k int, v string) { vals += v })
+	c.Assert(vals, Equals, "Ac")
+}
+
+func (s *OrderedSuite) TestZeroValueConcurrent(c *C) {
+	var m OrderedMap[int, string]
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			// every goroutine may be the first to use it
+			for i := 0; i < 100; i++ {
+				m.Put(g*100+i, "x")
+				m.Get(i)
+			}
+		}(g)
+	}
+	wg.Wait()
+	c.Assert(m.Len(), Equals, 800)
+	c.Assert(m.m.Validate(), IsNil)
+}
+
+func (s *OrderedSuite) TestNilValues(c *C) {
+	var m OrderedMap[string, error]
+	m.Put("ok", nil)
+	v, ok := m.Get("ok")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, IsNil)
+}
+
+func (s *OrderedSuite) TestZeroMapPanics(c *C) {
+	var m Map
+	c.Assert(func() { m.Put(1, 1) }, PanicMatches, "skiplist: Map used without NewMap.*")
+	c.Assert(func() { m.Get(1) }, PanicMatches, "skiplist: Map used without NewMap.*")
+}
+
+func (s *OrderedSuite) TestNewOrderedInt(c *C) {
+	m := NewOrdered[int, string]()
+	for _, k := range []int{5, -3, 12, 0, 7, -100} {
+		m.Put(k, strconv.Itoa(k))
+	}
+	c.Assert(m.Keys(), DeepEquals, []int{-100, -3, 0, 5, 7, 12})
+	v, ok := m.Get(-3)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "-3")
+	c.Assert(m.Len(), Equals, 6)
+}
+
+func (s *OrderedSuite) TestNewOrderedString(c *C) {
+	m := NewOrdered[string, int]()
+	for i, k := range []string{"pear", "apple", "Zebra", "apples", "", "banana"} {
+		m.Put(k, i)
+	}
+	// byte order: upper case before lower, a prefix before its extensions
+	c.Assert(m.Keys(), DeepEquals, []string{"", "Zebra", "apple", "apples", "banana", "pear"})
+	keys := []string{}
+	m.ForEach(func(k string, v int) { keys = append(keys, k) })
+	c.Assert(keys, DeepEquals, m.Keys())
+	v, ok := m.Get("apples")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 3)
+	_, ok = m.Get("grape")
+	c.Assert(ok, Equals, false)
+}
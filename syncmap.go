@@ -0,0 +1,108 @@
+package skiplist
+
+// SyncMap has the methods of sync.Map, with the same signatures and
+// behaviour, over an ordered map, so it can replace a sync.Map without
+// changing the code using it. Range visits the keys in order. unlike
+// sync.Map it needs a comparison function, and panics on a nil key.
+// every change, conditional or not, is made the way Put or Remove
+// makes it, so a persister, OnChange hooks, the slow operation hook
+// and WithMaxCost see it as one. a persister's error is dropped, the
+// change not being made
+type SyncMap struct {
+	m *Map
+}
+
+// NewSyncMap creates a new empty SyncMap, it takes a comparison
+// function that should implement Less and any options for the map
+func NewSyncMap(less func(a, b interface{}) bool, opts ...Option) *SyncMap {
+	return &SyncMap{NewMap(less, opts...)}
+}
+
+// Map returns the ordered map underneath, for everything else it can do
+func (s *SyncMap) Map() *Map {
+	return s.m
+}
+
+// Load returns the value stored for a key, or nil if there is none.
+// ok says whether a value was found
+func (s *SyncMap) Load(key interface{}) (value interface{}, ok bool) {
+	return s.m.Get(key)
+}
+
+// Store sets the value for a key
+func (s *SyncMap) Store(key, value interface{}) {
+	s.m.Put(key, value)
+}
+
+// Delete deletes the value for a key
+func (s *SyncMap) Delete(key interface{}) {
+	s.m.Remove(key)
+}
+
+// LoadOrStore returns the existing value for the key if there is one.
+// otherwise it stores and returns the given value. loaded is true if
+// the value was loaded, false if stored
+func (s *SyncMap) LoadOrStore(key, value interface{}) (actual interface{}, loaded bool) {
+	actual = value
+	s.m.putE(key, value, false, func(old interface{}, ok bool) bool {
+		if ok {
+			actual, loaded = old, true
+		}
+		return !ok
+	})
+	return actual, loaded
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous
+// value if any. loaded says whether the key was there
+func (s *SyncMap) LoadAndDelete(key interface{}) (value interface{}, loaded bool) {
+	value, loaded, _ = s.m.removeE(key, nil)
+	return value, loaded
+}
+
+// Swap swaps the value for a key and returns the previous value if
+// any. loaded says whether the key was there
+func (s *SyncMap) Swap(key, value interface{}) (previous interface{}, loaded bool) {
+	s.m.putE(key, value, false, func(old interface{}, ok bool) bool {
+		previous, loaded = old, ok
+		return true
+	})
+	return previous, loaded
+}
+
+// CompareAndSwap swaps the old and new values for a key if the value
+// stored is equal to old, which must be of a comparable type
+func (s *SyncMap) CompareAndSwap(key, old, new interface{}) (swapped bool) {
+	if s.m.normal(key) == nil {
+		return false
+	}
+	_, _, err := s.m.putE(key, new, false, func(v interface{}, ok bool) bool {
+		swapped = ok && v == old
+		return swapped
+	})
+	return swapped && err == nil
+}
+
+// CompareAndDelete deletes the entry for a key if its value is equal
+// to old, which must be of a comparable type. if there is no value for
+// the key it returns false, even if old is nil
+func (s *SyncMap) CompareAndDelete(key, old interface{}) (deleted bool) {
+	_, deleted, _ = s.m.removeE(key, func(v interface{}, ok bool) bool {
+		return ok && v == old
+	})
+	return deleted
+}
+
+// Range calls f for each key and value in key order, stopping if f
+// returns false. as with sync.Map no lock is held while f runs, so f
+// may change the map, and Range doesn't see a consistent snapshot:
+// it visits each key at most once, with the value it has at the time
+func (s *SyncMap) Range(f func(key, value interface{}) bool) {
+	it := s.m.Iterator()
+	for it.Next() {
+		if !f(it.Key(), it.Val()) {
+			break
+		}
+	}
+	it.Release()
+}
@@ -0,0 +1,203 @@
+package skiplist
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SyncMapSuite struct{}
+
+var _ = Suite(&SyncMapSuite{})
+
+// syncMapAPI is the method set shared with sync.Map
+type syncMapAPI interface {
+	Load(key interface{}) (value interface{}, ok bool)
+	Store(key, value interface{})
+	LoadOrStore(key, value interface{}) (actual interface{}, loaded bool)
+	LoadAndDelete(key interface{}) (value interface{}, loaded bool)
+	Delete(key interface{})
+	Swap(key, value interface{}) (previous interface{}, loaded bool)
+	CompareAndSwap(key, old, new interface{}) (swapped bool)
+	CompareAndDelete(key, old interface{}) (deleted bool)
+	Range(f func(key, value interface{}) bool)
+}
+
+var _ syncMapAPI = &sync.Map{}
+var _ syncMapAPI = &SyncMap{}
+
+// checkSyncMapSemantics runs the same calls on sm as on a sync.Map
+func checkSyncMapSemantics(c *C, sm syncMapAPI) {
+	v, ok := sm.Load(1)
+	c.Assert(v, IsNil)
+	c.Assert(ok, Equals, false)
+	sm.Store(1, "a")
+	v, ok = sm.Load(1)
+	c.Assert(v, Equals, "a")
+	c.Assert(ok, Equals, true)
+
+	v, loaded := sm.LoadOrStore(1, "b")
+	c.Assert(v, Equals, "a")
+	c.Assert(loaded, Equals, true)
+	v, loaded = sm.LoadOrStore(2, "b")
+	c.Assert(v, Equals, "b")
+	c.Assert(loaded, Equals, false)
+
+	v, loaded = sm.LoadAndDelete(2)
+	c.Assert(v, Equals, "b")
+	c.Assert(loaded, Equals, true)
+	v, loaded = sm.LoadAndDelete(2)
+	c.Assert(v, IsNil)
+	c.Assert(loaded, Equals, false)
+
+	v, loaded = sm.Swap(1, "c")
+	c.Assert(v, Equals, "a")
+	c.Assert(loaded, Equals, true)
+	v, loaded = sm.Swap(3, "d")
+	c.Assert(v, IsNil)
+	c.Assert(loaded, Equals, false)
+	v, _ = sm.Load(3)
+	c.Assert(v, Equals, "d")
+
+	c.Assert(sm.CompareAndSwap(1, "a", "x"), Equals, false)
+	c.Assert(sm.CompareAndSwap(1, "c", "x"), Equals, true)
+	c.Assert(sm.CompareAndSwap(4, nil, "x"), Equals, false)
+	v, _ = sm.Load(1)
+	c.Assert(v, Equals, "x")
+
+	c.Assert(sm.CompareAndDelete(1, "c"), Equals, false)
+	c.Assert(sm.CompareAndDelete(4, nil), Equals, false)
+	c.Assert(sm.CompareAndDelete(1, "x"), Equals, true)
+	_, ok = sm.Load(1)
+	c.Assert(ok, Equals, false)
+
+	sm.Store(5, nil)
+	c.Assert(sm.CompareAndSwap(5, nil, "y"), Equals, true)
+	sm.Delete(5)
+	sm.Delete(5)
+	_, ok = sm.Load(5)
+	c.Assert(ok, Equals, false)
+
+	// Range may change the map as it goes
+	for i := 10; i < 20; i++ {
+		sm.Store(i, i)
+	}
+	n := 0
+	sm.Range(func(k, v interface{}) bool {
+		sm.Delete(k)
+		n++
+		return true
+	})
+	c.Assert(n, Equals, 11)
+	sm.Range(func(k, v interface{}) bool {
+		c.Fatalf("%v left after deleting all", k)
+		return true
+	})
+}
+
+func (s *SyncMapSuite) TestMatchesSyncMap(c *C) {
+	checkSyncMapSemantics(c, &sync.Map{})
+	checkSyncMapSemantics(c, NewSyncMap(compareInts))
+}
+
+func (s *SyncMapSuite) TestRangeOrderAndStop(c *C) {
+	sm := NewSyncMap(compareInts)
+	for _, k := range []int{5, 3, 9, 1, 7} {
+		sm.Store(k, k)
+	}
+	keys := []interface{}{}
+	sm.Range(func(k, v interface{}) bool {
+		keys = append(keys, k)
+		return len(keys) < 4
+	})
+	c.Assert(keys, DeepEquals, []interface{}{1, 3, 5, 7})
+	c.Assert(sm.Map().Len(), Equals, 5)
+}
+
+func (s *SyncMapSuite) TestConcurrentLoadOrStore(c *C) {
+	sm := NewSyncMap(compareInts)
+	var wg sync.WaitGroup
+	stored := make([]bool, 50)
+	for i := range stored {
+		wg.Add(1)
+		go func(i int) {
+			_, loaded := sm.LoadOrStore(1, i)
+			stored[i] = !loaded
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	n := 0
+	for _, s := range stored {
+		if s {
+			n++
+		}
+	}
+	c.Assert(n, Equals, 1)
+}
+
+// the conditional changes are made as Put and Remove make theirs
+func (s *SyncMapSuite) TestChangesLikePutAndRemove(c *C) {
+	for _, opts := range [][]Option{nil, {WithPersistFirst()}} {
+		p := &logPersister{reject: map[interface{}]bool{}}
+		slow := []string{}
+		sm := NewSyncMap(compareInts, append(opts, WithPersister(p),
+			WithSlowOpHook(-1, func(op string, k interface{}, d time.Duration) { slow = append(slow, op) }))...)
+		changes := []string{}
+		sm.Map().OnChange(func(op string, k, v interface{}) {
+			changes = append(changes, fmt.Sprint(op, " ", k, " ", v))
+		})
+		sm.LoadOrStore(1, "a")
+		sm.LoadOrStore(1, "b")
+		sm.Swap(2, "c")
+		sm.Swap(2, "d")
+		sm.CompareAndSwap(2, "x", "e")
+		sm.CompareAndSwap(2, "d", "e")
+		sm.CompareAndDelete(1, "x")
+		sm.CompareAndDelete(1, "a")
+		sm.LoadAndDelete(2)
+		sm.LoadAndDelete(2)
+		c.Assert(changes, DeepEquals, []string{
+			"put 1 a", "put 2 c", "overwrite 2 d", "overwrite 2 e", "remove 1 a", "remove 2 e",
+		})
+		c.Assert(slow, HasLen, 10)
+		c.Assert(p.replay(c).Len(), Equals, 0)
+		c.Assert(p.log, HasLen, 6)
+
+		// a change the persister rejects isn't made
+		p.reject[3] = true
+		v, loaded := sm.LoadOrStore(3, "f")
+		c.Assert([]interface{}{v, loaded}, DeepEquals, []interface{}{"f", false})
+		_, ok := sm.Load(3)
+		c.Assert(ok, Equals, false)
+		p.reject[3] = false
+		sm.Store(3, "f")
+		p.reject[3] = true
+		c.Assert(sm.CompareAndSwap(3, "f", "g"), Equals, false)
+		c.Assert(sm.CompareAndDelete(3, "f"), Equals, false)
+		v, _ = sm.Load(3)
+		c.Assert(v, Equals, "f")
+		c.Assert(sm.Map().Validate(), IsNil)
+	}
+}
+
+func (s *SyncMapSuite) TestMaxCost(c *C) {
+	sm := NewSyncMap(compareInts, WithMaxCost(2, nil))
+	for i := 0; i < 5; i++ {
+		sm.LoadOrStore(i, i)
+	}
+	c.Assert(sm.Map().Keys(), DeepEquals, []interface{}{3, 4})
+	sm.Swap(9, 9)
+	c.Assert(sm.Map().Len(), Equals, 2)
+}
+
+func (s *SyncMapSuite) TestVersions(c *C) {
+	sm := NewSyncMap(compareInts)
+	sm.Store(1, "a")
+	sm.Swap(1, "b")
+	sm.CompareAndSwap(1, "b", "c")
+	_, version, _ := sm.Map().GetVersioned(1)
+	c.Assert(version, Equals, uint64(3))
+}
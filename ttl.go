@@ -0,0 +1,109 @@
+package skiplist
+
+import (
+	"time"
+)
+
+// TTLMap is a map whose pairs expire a fixed time after they are put
+type TTLMap struct {
+	m   *Map
+	ttl time.Duration
+	now func() time.Time
+}
+
+// ttlValue is a value stored in a TTLMap with the time it expires
+type ttlValue struct {
+	val      interface{}
+	deadline time.Time
+}
+
+// NewTTLMap creates a new empty map, it takes a comparison function
+// that should implement Less and how long pairs live after a Put
+func NewTTLMap(less func(a, b interface{}) bool, ttl time.Duration) *TTLMap {
+	return &TTLMap{
+		m:   NewMap(less),
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// expired returns true if v has passed its deadline at now
+func (t *TTLMap) expired(v interface{}, now time.Time) bool {
+	return !now.Before(v.(ttlValue).deadline)
+}
+
+// Put takes a key and value, and puts the value in the map for the
+// key until the ttl runs out, replacing an existing value and its
+// deadline. returns true if it overwrites a live pair, false otherwise.
+// it panics if k is nil
+func (t *TTLMap) Put(k interface{}, v interface{}) bool {
+	checkKey(k)
+	now := t.now()
+	t.m.mutex.Lock()
+	defer t.m.mutex.Unlock()
+	old, ok := t.m.get(k)
+	ok = ok && !t.expired(old, now)
+	t.m.put(k, ttlValue{v, now.Add(t.ttl)})
+	return ok
+}
+
+// Get returns the value for a key, and true if it finds the key
+// before it expires, false otherwise. an expired pair is removed
+func (t *TTLMap) Get(k interface{}) (interface{}, bool) {
+	now := t.now()
+	v, ok := t.peek(k)
+	if !ok {
+		return nil, false
+	}
+	if !t.expired(v, now) {
+		return v.(ttlValue).val, true
+	}
+	t.expire(k, now)
+	return nil, false
+}
+
+// peek returns the stored value for a key under the read lock,
+// expired or not
+func (t *TTLMap) peek(k interface{}) (interface{}, bool) {
+	t.m.mutex.RLock()
+	defer t.m.mutex.RUnlock()
+	return t.m.get(k)
+}
+
+// expire removes the pair for a key if it has expired at now
+func (t *TTLMap) expire(k interface{}, now time.Time) {
+	t.m.mutex.Lock()
+	defer t.m.mutex.Unlock()
+	// it may have been put again since the read lock was let go
+	if v, ok := t.m.get(k); ok && t.expired(v, now) {
+		t.m.remove(k)
+	}
+}
+
+// Remove removes the pair for a key,
+// returns true if it found and removed a live pair, false otherwise
+func (t *TTLMap) Remove(k interface{}) bool {
+	now := t.now()
+	t.m.mutex.Lock()
+	defer t.m.mutex.Unlock()
+	v, ok := t.m.remove(k)
+	return ok && !t.expired(v, now)
+}
+
+// Reap removes every expired pair and returns how many it removed.
+// it is meant to be called every so often, say from a goroutine with
+// a time.Ticker, so pairs that are never read again don't pile up
+func (t *TTLMap) Reap() int {
+	now := t.now()
+	t.m.mutex.Lock()
+	defer t.m.mutex.Unlock()
+	return t.m.removeIf(func(e *mapElement) bool { return t.expired(e.val, now) })
+}
+
+// Len returns the number of pairs in the map, counting expired
+// pairs that have not been removed yet
+func (t *TTLMap) Len() int {
+	t.m.mutex.RLock()
+	defer t.m.mutex.RUnlock()
+	return t.m.length
+}
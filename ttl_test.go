@@ -0,0 +1,81 @@
+package skiplist
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TTLSuite struct{}
+
+var _ = Suite(&TTLSuite{})
+
+// fakeClock returns a TTLMap whose clock only moves when told to
+func fakeClock(ttl time.Duration) (*TTLMap, func(d time.Duration)) {
+	t := NewTTLMap(compareInts, ttl)
+	now := time.Unix(1000, 0)
+	t.now = func() time.Time { return now }
+	return t, func(d time.Duration) { now = now.Add(d) }
+}
+
+func (s *TTLSuite) TestExpiry(c *C) {
+	t, advance := fakeClock(time.Minute)
+	c.Assert(t.Put(1, "a"), Equals, false)
+	advance(30 * time.Second)
+	t.Put(2, "b")
+	v, ok := t.Get(1)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "a")
+	advance(30 * time.Second)
+	_, ok = t.Get(1)
+	c.Assert(ok, Equals, false)
+	// the expired pair was removed by the Get
+	c.Assert(t.Len(), Equals, 1)
+	v, ok = t.Get(2)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "b")
+	// putting again resets the deadline
+	c.Assert(t.Put(2, "c"), Equals, true)
+	advance(45 * time.Second)
+	v, ok = t.Get(2)
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, "c")
+	advance(15 * time.Second)
+	c.Assert(t.Put(2, "d"), Equals, false)
+	advance(time.Minute)
+	c.Assert(t.Remove(2), Equals, false)
+	c.Assert(t.Len(), Equals, 0)
+}
+
+func (s *TTLSuite) TestReap(c *C) {
+	t, advance := fakeClock(time.Minute)
+	for i := 0; i < 1000; i++ {
+		t.Put(i, i)
+		if i%100 == 99 {
+			advance(10 * time.Second)
+		}
+	}
+	// the first 500 have had their minute
+	c.Assert(t.Reap(), Equals, 500)
+	c.Assert(t.Len(), Equals, 500)
+	c.Assert(t.m.Validate(), IsNil)
+	c.Assert(t.m.Keys()[0], Equals, 500)
+	c.Assert(t.Reap(), Equals, 0)
+	advance(time.Hour)
+	c.Assert(t.Reap(), Equals, 500)
+	c.Assert(t.m.Validate(), IsNil)
+	c.Assert(t.Len(), Equals, 0)
+}
+
+func (s *TTLSuite) TestRealClock(c *C) {
+	t := NewTTLMap(compareInts, 20*time.Millisecond)
+	t.Put(1, 1)
+	_, ok := t.Get(1)
+	c.Assert(ok, Equals, true)
+	time.Sleep(30 * time.Millisecond)
+	_, ok = t.Get(1)
+	c.Assert(ok, Equals, false)
+	t.Put(2, 2)
+	time.Sleep(30 * time.Millisecond)
+	c.Assert(t.Reap(), Equals, 1)
+}
@@ -0,0 +1,48 @@
+package skiplist
+
+// Tuple is a composite key made of several fields, such as
+// a tenant and a timestamp, ordered by a comparator from TupleLess
+type Tuple []interface{}
+
+// tupleMax is a field greater than any other, see PrefixEnd
+type tupleMax struct{}
+
+// TupleLess returns a comparison function ordering Tuples
+// lexicographically, comparing field i with less[i]. a tuple that
+// is a prefix of another sorts before it, so a partial tuple can be
+// used as the lower bound for a Range over all keys starting with it.
+// tuples must not have more fields than there are functions
+func TupleLess(less ...func(a, b interface{}) bool) func(a, b interface{}) bool {
+	return func(a, b interface{}) bool {
+		ta, tb := a.(Tuple), b.(Tuple)
+		for i := 0; i < len(ta) && i < len(tb); i++ {
+			_, amax := ta[i].(tupleMax)
+			_, bmax := tb[i].(tupleMax)
+			switch {
+			case amax && bmax:
+				continue
+			case amax:
+				return false
+			case bmax:
+				return true
+			case less[i](ta[i], tb[i]):
+				return true
+			case less[i](tb[i], ta[i]):
+				return false
+			}
+		}
+		return len(ta) < len(tb)
+	}
+}
+
+// PrefixEnd returns a tuple that sorts after every tuple starting
+// with prefix, for use as the upper bound of a Range:
+//
+//	m.Range(prefix, PrefixEnd(prefix), fn)
+//
+// visits every key that starts with prefix
+func PrefixEnd(prefix Tuple) Tuple {
+	ret := make(Tuple, len(prefix), len(prefix)+1)
+	copy(ret, prefix)
+	return append(ret, tupleMax{})
+}
@@ -0,0 +1,69 @@
+package skiplist
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type TupleSuite struct{}
+
+var _ = Suite(&TupleSuite{})
+
+func tenantMap() *Map {
+	m := NewMap(TupleLess(compareStrings, compareInt64s))
+	for _, tenant := range []string{"acme", "globex", "initech"} {
+		for ts := int64(5); ts > 0; ts-- {
+			m.Put(Tuple{tenant, ts * 100}, tenant)
+		}
+	}
+	return m
+}
+
+func (s *TupleSuite) TestTupleOrdering(c *C) {
+	m := tenantMap()
+	c.Assert(m.Validate(), IsNil)
+	c.Assert(m.Len(), Equals, 15)
+	k, _, _ := m.GetByRank(0)
+	c.Assert(k, DeepEquals, Tuple{"acme", int64(100)})
+	// ordering crosses from the last acme to the first globex
+	k, _, _ = m.GetByRank(4)
+	c.Assert(k, DeepEquals, Tuple{"acme", int64(500)})
+	k, _, _ = m.GetByRank(5)
+	c.Assert(k, DeepEquals, Tuple{"globex", int64(100)})
+	x, ok := m.Get(Tuple{"initech", int64(300)})
+	c.Assert(ok, Equals, true)
+	c.Assert(x, Equals, "initech")
+}
+
+func (s *TupleSuite) TestTuplePrefixOrdering(c *C) {
+	less := TupleLess(compareStrings, compareInt64s)
+	c.Assert(less(Tuple{"a"}, Tuple{"a", int64(0)}), Equals, true)
+	c.Assert(less(Tuple{"a", int64(0)}, Tuple{"a"}), Equals, false)
+	c.Assert(less(Tuple{"a", int64(9)}, Tuple{"b"}), Equals, true)
+	c.Assert(less(Tuple{"a", int64(9)}, PrefixEnd(Tuple{"a"})), Equals, true)
+	c.Assert(less(PrefixEnd(Tuple{"a"}), Tuple{"b"}), Equals, true)
+	c.Assert(less(PrefixEnd(Tuple{"a"}), PrefixEnd(Tuple{"a"})), Equals, false)
+}
+
+func (s *TupleSuite) TestTuplePartialRange(c *C) {
+	m := tenantMap()
+	prefix := Tuple{"globex"}
+	got := collect(m, prefix, PrefixEnd(prefix))
+	c.Assert(got, HasLen, 5)
+	for i, e := range got {
+		c.Assert(e.Key, DeepEquals, Tuple{"globex", int64(i+1) * 100})
+	}
+	// a partial bound with both fields narrows within the tenant
+	got = collect(m, Tuple{"globex", int64(200)}, Tuple{"globex", int64(400)})
+	c.Assert(got, HasLen, 2)
+	c.Assert(collect(m, Tuple{"hooli"}, PrefixEnd(Tuple{"hooli"})), HasLen, 0)
+}
+
+func (s *TupleSuite) TestPrefixEndCopies(c *C) {
+	prefix := make(Tuple, 1, 5)
+	prefix[0] = "a"
+	end := PrefixEnd(prefix)
+	c.Assert(len(end), Equals, 2)
+	c.Assert(len(prefix), Equals, 1)
+	prefix = append(prefix, "b")
+	c.Assert(end[1], Equals, tupleMax{})
+}
@@ -0,0 +1,230 @@
+package skiplist
+
+import (
+	"math"
+	"sync"
+)
+
+// WeightedMap is a map whose pairs each carry a weight, say the size of
+// the value. every link records the total weight it passes over, the
+// way a Map's spans count steps, so the pair where the running total of
+// weights passes some amount can be found in O(log n)
+type WeightedMap struct {
+	less   func(a, b interface{}) bool
+	head   *weightedElement
+	length int
+	rnd    levelDraw
+	mutex  sync.RWMutex
+}
+
+// WeightedEntry is a key/value pair and its weight copied out of a
+// WeightedMap
+type WeightedEntry struct {
+	Key    interface{}
+	Val    interface{}
+	Weight float64
+}
+
+// weightedElement is a pair in the list. sum[level] is the total weight
+// of the elements after this one up to and including next[level], it is
+// only meaningful while next[level] is not nil
+type weightedElement struct {
+	WeightedEntry
+	next []*weightedElement
+	sum  []float64
+}
+
+// NewWeightedMap creates a new empty weighted map, it takes a
+// comparison function that should implement Less. it chooses levels
+// the way a Map does
+func NewWeightedMap(less func(a, b interface{}) bool) *WeightedMap {
+	return NewWeightedMapWithSeed(less, defaultSeed)
+}
+
+// NewWeightedMapWithSeed creates a new empty weighted map like
+// NewWeightedMap, choosing levels from a source seeded with seed, as a
+// Map made WithSeed(seed) does
+func NewWeightedMapWithSeed(less func(a, b interface{}) bool, seed uint64) *WeightedMap {
+	return &WeightedMap{
+		less: less,
+		head: &weightedElement{
+			next: make([]*weightedElement, maxHeight),
+			sum:  make([]float64, maxHeight),
+		},
+		rnd: newLevelDraw(seed),
+	}
+}
+
+// search fills backPointer with the last element before k at every
+// level and returns the element for k, or nil if there is none
+func (wm *WeightedMap) search(k interface{}, backPointer []*weightedElement) *weightedElement {
+	prev := wm.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		for next := prev.next[level]; next != nil && wm.less(next.Key, k); next = prev.next[level] {
+			prev = next
+		}
+		backPointer[level] = prev
+	}
+	e := prev.next[0]
+	if e == nil || wm.less(k, e.Key) {
+		return nil
+	}
+	return e
+}
+
+// fixSum recomputes e.sum[level] from the level below. adding up the
+// level below rather than adjusting by the change keeps rounding
+// errors from building up as weights change
+func (wm *WeightedMap) fixSum(e *weightedElement, level int) {
+	next := e.next[level]
+	if next == nil {
+		e.sum[level] = 0
+		return
+	}
+	if level == 0 {
+		e.sum[0] = next.Weight
+		return
+	}
+	total := 0.0
+	for x := e; x != next; x = x.next[level-1] {
+		total += x.sum[level-1]
+	}
+	e.sum[level] = total
+}
+
+// fixSums recomputes the sums of the links from backPointer, bottom up
+// so each level is built from a finished one
+func (wm *WeightedMap) fixSums(backPointer []*weightedElement) {
+	for level := 0; level < maxHeight; level++ {
+		wm.fixSum(backPointer[level], level)
+	}
+}
+
+// checkWeight panics if w can't be used as a weight
+func checkWeight(w float64) {
+	if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+		panic("skiplist: weight must be finite and not negative")
+	}
+}
+
+// PutWeighted takes a key, value and weight, and puts the value in the
+// map for the key with the weight, replacing an existing value and
+// weight. returns true if it overwrites, false if it inserts a new pair.
+// it panics if k is nil or w is negative, infinite or NaN
+func (wm *WeightedMap) PutWeighted(k interface{}, v interface{}, w float64) bool {
+	checkKey(k)
+	checkWeight(w)
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	var backPointer [maxHeight]*weightedElement
+	if e := wm.search(k, backPointer[:]); e != nil {
+		e.Val, e.Weight = v, w
+		wm.fixSums(backPointer[:])
+		return true
+	}
+	levels := wm.rnd.draw(maxHeight)
+	e := &weightedElement{WeightedEntry{k, v, w}, make([]*weightedElement, levels), make([]float64, levels)}
+	for level := 0; level < levels; level++ {
+		e.next[level] = backPointer[level].next[level]
+		backPointer[level].next[level] = e
+	}
+	for level := 0; level < maxHeight; level++ {
+		if level < levels {
+			wm.fixSum(e, level)
+		}
+		wm.fixSum(backPointer[level], level)
+	}
+	wm.length++
+	return false
+}
+
+// Get returns the value and weight for a key, and true if it
+// finds the key, false otherwise
+func (wm *WeightedMap) Get(k interface{}) (interface{}, float64, bool) {
+	if k == nil {
+		return nil, 0, false
+	}
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	var backPointer [maxHeight]*weightedElement
+	e := wm.search(k, backPointer[:])
+	if e == nil {
+		return nil, 0, false
+	}
+	return e.Val, e.Weight, true
+}
+
+// Remove removes the pair for a key,
+// returns true if it found and removed it, false otherwise
+func (wm *WeightedMap) Remove(k interface{}) bool {
+	if k == nil {
+		return false
+	}
+	wm.mutex.Lock()
+	defer wm.mutex.Unlock()
+	var backPointer [maxHeight]*weightedElement
+	e := wm.search(k, backPointer[:])
+	if e == nil {
+		return false
+	}
+	for level := range e.next {
+		backPointer[level].next[level] = e.next[level]
+	}
+	wm.fixSums(backPointer[:])
+	wm.length--
+	return true
+}
+
+// SeekWeight returns the first pair at which the running total of
+// weights, counting the pair's own, is more than x, and true, or false
+// if the total weight of the map is not more than x
+func (wm *WeightedMap) SeekWeight(x float64) (Entry, bool) {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	total := 0.0
+	prev := wm.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		for prev.next[level] != nil && total+prev.sum[level] <= x {
+			total += prev.sum[level]
+			prev = prev.next[level]
+		}
+	}
+	e := prev.next[0]
+	if e == nil {
+		return Entry{}, false
+	}
+	return Entry{e.Key, e.Val}, true
+}
+
+// TotalWeight returns the sum of the weights of every pair in the map
+func (wm *WeightedMap) TotalWeight() float64 {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	total := 0.0
+	prev := wm.head
+	for level := maxHeight - 1; level >= 0; level-- {
+		for prev.next[level] != nil {
+			total += prev.sum[level]
+			prev = prev.next[level]
+		}
+	}
+	return total
+}
+
+// Entries returns every pair in the map with its weight, in order
+func (wm *WeightedMap) Entries() []WeightedEntry {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	ret := make([]WeightedEntry, 0, wm.length)
+	for e := wm.head.next[0]; e != nil; e = e.next[0] {
+		ret = append(ret, e.WeightedEntry)
+	}
+	return ret
+}
+
+// Len returns the number of pairs in the map
+func (wm *WeightedMap) Len() int {
+	wm.mutex.RLock()
+	defer wm.mutex.RUnlock()
+	return wm.length
+}
@@ -0,0 +1,112 @@
+package skiplist
+
+import (
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+)
+
+type WeightedSuite struct{}
+
+var _ = Suite(&WeightedSuite{})
+
+// seekPrefix finds the pair SeekWeight should return for x the slow
+// way, from running totals over the entries in order
+func seekPrefix(entries []WeightedEntry, x float64) (Entry, bool) {
+	total := 0.0
+	for _, e := range entries {
+		total += e.Weight
+		if total > x {
+			return Entry{e.Key, e.Val}, true
+		}
+	}
+	return Entry{}, false
+}
+
+func (s *WeightedSuite) TestAgainstPrefixSums(c *C) {
+	r := rand.New(rand.NewSource(42))
+	wm := NewWeightedMap(compareInts)
+	weights := map[int]float64{}
+	for round := 0; round < 2000; round++ {
+		k := r.Intn(300)
+		if r.Intn(4) == 0 {
+			_, ok := weights[k]
+			c.Assert(wm.Remove(k), Equals, ok)
+			delete(weights, k)
+		} else {
+			// whole numbers add up without rounding
+			w := float64(r.Intn(50))
+			_, ok := weights[k]
+			c.Assert(wm.PutWeighted(k, round, w), Equals, ok)
+			weights[k] = w
+		}
+		entries := wm.Entries()
+		c.Assert(entries, HasLen, len(weights))
+		c.Assert(wm.Len(), Equals, len(weights))
+		total := 0.0
+		for _, e := range entries {
+			c.Assert(e.Weight, Equals, weights[e.Key.(int)])
+			total += e.Weight
+		}
+		c.Assert(wm.TotalWeight(), Equals, total)
+		for i := 0; i < 5; i++ {
+			x := float64(r.Intn(int(total)+20)) - 10
+			got, ok := wm.SeekWeight(x)
+			want, wantOK := seekPrefix(entries, x)
+			c.Assert(ok, Equals, wantOK)
+			c.Assert(got, DeepEquals, want, Commentf("seek %v", x))
+		}
+	}
+}
+
+func (s *WeightedSuite) TestSeekWeight(c *C) {
+	wm := NewWeightedMap(compareStrings)
+	_, ok := wm.SeekWeight(0)
+	c.Assert(ok, Equals, false)
+	c.Assert(wm.TotalWeight(), Equals, 0.0)
+	wm.PutWeighted("a", 1, 10)
+	wm.PutWeighted("b", 2, 0)
+	wm.PutWeighted("c", 3, 5)
+	e, _ := wm.SeekWeight(9.5)
+	c.Assert(e.Key, Equals, "a")
+	// the total reaches 10 at a, and only passes it at c
+	e, _ = wm.SeekWeight(10)
+	c.Assert(e.Key, Equals, "c")
+	_, ok = wm.SeekWeight(15)
+	c.Assert(ok, Equals, false)
+
+	// overwriting moves the totals
+	c.Assert(wm.PutWeighted("a", 4, 2), Equals, true)
+	c.Assert(wm.TotalWeight(), Equals, 7.0)
+	e, _ = wm.SeekWeight(2)
+	c.Assert(e, DeepEquals, Entry{"c", 3})
+	v, w, ok := wm.Get("a")
+	c.Assert(ok, Equals, true)
+	c.Assert(v, Equals, 4)
+	c.Assert(w, Equals, 2.0)
+	c.Assert(func() { wm.PutWeighted("d", 0, -1) }, PanicMatches, "skiplist: weight must be .*")
+	c.Assert(wm.Len(), Equals, 3)
+}
+
+func (s *WeightedSuite) TestWithSeed(c *C) {
+	// levels come from the same source as a Map's, so the same seed
+	// gives the same heights
+	m := NewMap(compareInts, WithSeed(5))
+	wm := NewWeightedMapWithSeed(compareInts, 5)
+	other := NewWeightedMapWithSeed(compareInts, 6)
+	heights := func(wm *WeightedMap) []int {
+		ret := []int{}
+		for e := wm.head.next[0]; e != nil; e = e.next[0] {
+			ret = append(ret, len(e.next))
+		}
+		return ret
+	}
+	for i := 0; i < 200; i++ {
+		m.Put(i, i)
+		wm.PutWeighted(i, i, 1)
+		other.PutWeighted(i, i, 1)
+	}
+	c.Assert(heights(wm), DeepEquals, shape(m))
+	c.Assert(heights(other), Not(DeepEquals), shape(m))
+	c.Assert(other.TotalWeight(), Equals, 200.0)
+}
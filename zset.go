@@ -0,0 +1,161 @@
+package skiplist
+
+import (
+	"math"
+)
+
+// ZSet is a scored set in the style of a Redis sorted set: members are
+// kept in score order, ties broken by member, alongside a lookup from
+// member to its place in the list so scores can be found and changed
+// without a search by score
+type ZSet struct {
+	m       *Map
+	members map[interface{}]*mapElement
+}
+
+// ZEntry is a member and its score copied out of a ZSet
+type ZEntry struct {
+	Member interface{}
+	Score  float64
+}
+
+// zsetKey is the key the list is ordered by. low marks a bound that
+// goes before every member with the same score
+type zsetKey struct {
+	score  float64
+	low    bool
+	member interface{}
+}
+
+// NewZSet creates a new empty scored set. memberLess orders members
+// with equal scores, and members must be usable as Go map keys
+func NewZSet(memberLess func(a, b interface{}) bool) *ZSet {
+	less := func(a, b interface{}) bool {
+		ka, kb := a.(zsetKey), b.(zsetKey)
+		if ka.score != kb.score {
+			return ka.score < kb.score
+		}
+		if ka.low != kb.low {
+			return ka.low
+		}
+		return memberLess(ka.member, kb.member)
+	}
+	return &ZSet{
+		m:       NewMap(less),
+		members: make(map[interface{}]*mapElement),
+	}
+}
+
+func checkScore(score float64) {
+	if math.IsNaN(score) {
+		panic("skiplist: NaN score")
+	}
+}
+
+// Add puts member in the set with score, moving it if it is already
+// there. returns true if the member was already in the set, false if
+// it is new. it panics if score is NaN
+func (z *ZSet) Add(member interface{}, score float64) bool {
+	checkKey(member)
+	checkScore(score)
+	z.m.mutex.Lock()
+	defer z.m.mutex.Unlock()
+	_, ok := z.members[member]
+	z.add(member, score)
+	return ok
+}
+
+// add does the work of Add, the caller must hold the write lock
+func (z *ZSet) add(member interface{}, score float64) {
+	if e, ok := z.members[member]; ok {
+		if e.key.(zsetKey).score == score {
+			return
+		}
+		z.m.remove(e.key)
+	}
+	var backPointer [maxHeight]*mapElement
+	k := zsetKey{score: score, member: member}
+	z.m.lowerBound(k, backPointer[:])
+	e := newMapElement(k, nil, randomLevels(z.m))
+	z.m.link(e, backPointer[:])
+	z.members[member] = e
+}
+
+// Score returns the score of member, and true if it is in the set,
+// false otherwise
+func (z *ZSet) Score(member interface{}) (float64, bool) {
+	z.m.mutex.RLock()
+	defer z.m.mutex.RUnlock()
+	e, ok := z.members[member]
+	if !ok {
+		return 0, false
+	}
+	return e.key.(zsetKey).score, true
+}
+
+// IncrBy adds delta to the score of member and returns the new score,
+// adding the member with a score of delta if it is not in the set.
+// it panics if the new score is NaN, leaving the set unchanged
+func (z *ZSet) IncrBy(member interface{}, delta float64) float64 {
+	checkKey(member)
+	z.m.mutex.Lock()
+	defer z.m.mutex.Unlock()
+	score := delta
+	if e, ok := z.members[member]; ok {
+		score += e.key.(zsetKey).score
+	}
+	if math.IsNaN(score) {
+		panic("skiplist: NaN score")
+	}
+	z.add(member, score)
+	return score
+}
+
+// Remove takes member out of the set,
+// returns true if it found and removed, false otherwise
+func (z *ZSet) Remove(member interface{}) bool {
+	z.m.mutex.Lock()
+	defer z.m.mutex.Unlock()
+	e, ok := z.members[member]
+	if ok {
+		z.m.remove(e.key)
+		delete(z.members, member)
+	}
+	return ok
+}
+
+// Rank returns the zero based position of member in score order,
+// and true if it is in the set, false otherwise
+func (z *ZSet) Rank(member interface{}) (int, bool) {
+	z.m.mutex.RLock()
+	defer z.m.mutex.RUnlock()
+	e, ok := z.members[member]
+	rank := 0
+	if ok {
+		rank = z.m.countLess(e.key)
+	}
+	return rank, ok
+}
+
+// RangeByScore returns the members with min <= score <= max
+// in score order
+func (z *ZSet) RangeByScore(min, max float64) []ZEntry {
+	ret := []ZEntry{}
+	z.m.mutex.RLock()
+	defer z.m.mutex.RUnlock()
+	for e := z.m.lowerBound(zsetKey{score: min, low: true}, nil); e != nil; e = e.next[0] {
+		k := e.key.(zsetKey)
+		if k.score > max {
+			break
+		}
+		ret = append(ret, ZEntry{k.member, k.score})
+	}
+	return ret
+}
+
+// Len returns the number of members in the set
+func (z *ZSet) Len() int {
+	z.m.mutex.RLock()
+	defer z.m.mutex.RUnlock()
+	return len(z.members)
+}
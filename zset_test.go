@@ -0,0 +1,114 @@
+package skiplist
+
+import (
+	"math"
+
+	. "gopkg.in/check.v1"
+)
+
+type ZSetSuite struct{}
+
+var _ = Suite(&ZSetSuite{})
+
+func members(entries []ZEntry) []interface{} {
+	ret := []interface{}{}
+	for _, e := range entries {
+		ret = append(ret, e.Member)
+	}
+	return ret
+}
+
+func (s *ZSetSuite) TestAddScore(c *C) {
+	z := NewZSet(compareStrings)
+	c.Assert(z.Add("a", 1), Equals, false)
+	c.Assert(z.Add("b", -2.5), Equals, false)
+	c.Assert(z.Add("a", 3), Equals, true)
+	c.Assert(z.Len(), Equals, 2)
+	score, ok := z.Score("a")
+	c.Assert(ok, Equals, true)
+	c.Assert(score, Equals, 3.0)
+	score, ok = z.Score("b")
+	c.Assert(ok, Equals, true)
+	c.Assert(score, Equals, -2.5)
+	_, ok = z.Score("c")
+	c.Assert(ok, Equals, false)
+	c.Assert(z.m.Validate(), IsNil)
+	c.Assert(z.m.Len(), Equals, 2)
+}
+
+func (s *ZSetSuite) TestTiesAndRank(c *C) {
+	z := NewZSet(compareStrings)
+	z.Add("c", 1)
+	z.Add("a", 1)
+	z.Add("b", 1)
+	z.Add("z", -1)
+	z.Add("y", math.Inf(1))
+	z.Add("x", math.Inf(-1))
+	// ties are in member order, like redis
+	c.Assert(members(z.RangeByScore(math.Inf(-1), math.Inf(1))), DeepEquals,
+		[]interface{}{"x", "z", "a", "b", "c", "y"})
+	for i, m := range []string{"x", "z", "a", "b", "c", "y"} {
+		rank, ok := z.Rank(m)
+		c.Assert(ok, Equals, true)
+		c.Assert(rank, Equals, i)
+	}
+	_, ok := z.Rank("q")
+	c.Assert(ok, Equals, false)
+	// moving a member takes it out of its old place
+	z.Add("a", -5)
+	rank, _ := z.Rank("a")
+	c.Assert(rank, Equals, 1)
+	rank, _ = z.Rank("b")
+	c.Assert(rank, Equals, 3)
+	c.Assert(z.m.Validate(), IsNil)
+}
+
+func (s *ZSetSuite) TestIncrBy(c *C) {
+	z := NewZSet(compareStrings)
+	c.Assert(z.IncrBy("a", 2), Equals, 2.0)
+	c.Assert(z.IncrBy("a", -5), Equals, -3.0)
+	z.Add("b", -1)
+	rank, _ := z.Rank("a")
+	c.Assert(rank, Equals, 0)
+	c.Assert(z.IncrBy("a", 2), Equals, -1.0)
+	// a and b tie now, a goes first
+	c.Assert(members(z.RangeByScore(-1, -1)), DeepEquals, []interface{}{"a", "b"})
+	z.Add("c", math.Inf(1))
+	c.Assert(func() { z.IncrBy("c", math.Inf(-1)) }, PanicMatches, "skiplist: NaN score")
+	score, _ := z.Score("c")
+	c.Assert(score, Equals, math.Inf(1))
+	c.Assert(func() { z.Add("d", math.NaN()) }, PanicMatches, "skiplist: NaN score")
+	c.Assert(z.Len(), Equals, 3)
+}
+
+func (s *ZSetSuite) TestRemove(c *C) {
+	z := NewZSet(compareInts)
+	for i := 0; i < 100; i++ {
+		z.Add(i, float64(i%10))
+	}
+	for i := 0; i < 100; i += 2 {
+		c.Assert(z.Remove(i), Equals, true)
+	}
+	c.Assert(z.Remove(0), Equals, false)
+	c.Assert(z.Len(), Equals, 50)
+	c.Assert(z.m.Validate(), IsNil)
+	rank, _ := z.Rank(99)
+	c.Assert(rank, Equals, 49)
+	rank, _ = z.Rank(11)
+	// only 1 scores lower or ties with a lower member
+	c.Assert(rank, Equals, 1)
+}
+
+func (s *ZSetSuite) TestRangeByScore(c *C) {
+	z := NewZSet(compareStrings)
+	z.Add("a", -3)
+	z.Add("b", -2)
+	z.Add("c", -2)
+	z.Add("d", 0)
+	z.Add("e", 4)
+	c.Assert(z.RangeByScore(-2, 0), DeepEquals,
+		[]ZEntry{{"b", -2}, {"c", -2}, {"d", 0}})
+	c.Assert(z.RangeByScore(-2.5, -2.5), DeepEquals, []ZEntry{})
+	c.Assert(z.RangeByScore(5, 1), DeepEquals, []ZEntry{})
+	c.Assert(members(z.RangeByScore(math.Inf(-1), -3)), DeepEquals, []interface{}{"a"})
+}